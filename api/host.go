@@ -10,11 +10,13 @@ type IndexHosts struct {
 // 	* `GET /api/hosts/ => [ { ... } ]`
 // 	* `GET /api/hosts/:id => { ... }`
 type HostIndex struct {
-	ID       string
-	SNMP     string
-	Online   bool
-	Location string `json:",omitempty"`
-	Error    *Error `json:",omitempty"`
+	ID          string
+	SNMP        string // without the community for any Credentials
+	Credentials string `json:",omitempty"` // name of the configured credentials used for SNMP
+	Quirks      string `json:",omitempty"` // name of the configured quirks profile used for SNMP
+	Online      bool
+	Location    string `json:",omitempty"`
+	Error       *Error `json:",omitempty"`
 }
 
 // Optional URL ?query params
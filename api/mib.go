@@ -11,9 +11,62 @@ type MIBIndex struct {
 // MIB metadata
 //
 //	 * `GET /api/mibs/:mib => { ... }`
+//	 * `POST /api/mibs/ { "Name": ..., "OID": ..., "Objects": [...], ... } => { ... }`
+//	 * `PUT /api/mibs/:mib { "Name": ..., "OID": ..., "Objects": [...], ... } => { ... }`
+//	 * `DELETE /api/mibs/:mib`
+//
+// The POST/PUT request body is the JSON MIB config, as generated from the SMI MIB sources by scripts/mib-import.py.
+// Loading, replacing or unloading an MIB fails with 409 Conflict if tables or notifications in other MIBs still refer to its objects.
 type MIB struct {
 	MIBIndex
 
 	Objects []ObjectIndex
 	Tables  []TableIndex
 }
+
+// MIB tree node, for browsing the OID hierarchy of the loaded MIBs.
+//
+// Each node is nested under the closest parent node with a registered OID.
+//
+//	* `GET /api/mibs/tree?root=.1.3.6.1.2.1.2 => { ... }`
+//	* `GET /api/mibs/tree?root=IF-MIB::ifTable => { ... }`
+type MIBTree struct {
+	ID   string
+	OID  string
+	Type string `json:",omitempty"` // MIB, Object, Table, Notification
+
+	Children []MIBTree `json:",omitempty"`
+}
+
+// Optional URL ?query params
+//
+// The `root` is either a numeric OID or a MIB name, and defaults to the entire tree.
+//
+//	* `GET /api/mibs/tree`
+type MIBTreeQuery struct {
+	Root string `schema:"root"`
+}
+
+// MIB search result, in order of relevance.
+//
+//	* `GET /api/mibs/search?q=ifdescr => [ { ... } ]`
+//	* `GET /api/mibs/search?q=IF-MIB::ifHC => [ { ... } ]`
+type MIBSearchResult struct {
+	ID          string
+	OID         string
+	Type        string // MIB, Object, Table, Notification
+	Match       string // exact, prefix, substring, fuzzy, description
+	Description string `json:",omitempty"`
+}
+
+// Required URL ?query params
+//
+// The `q` is matched case-insensitively against MIB, object, table and notification names, and object/table descriptions.
+// A `MIB::` prefix restricts the search to names within that MIB.
+// The optional `limit` restricts the number of results.
+//
+//	* `GET /api/mibs/search?q=sysname&limit=10`
+type MIBSearchQuery struct {
+	Query string `schema:"q"`
+	Limit uint   `schema:"limit"`
+}
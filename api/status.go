@@ -0,0 +1,44 @@
+package api
+
+// SNMP client engine counters, with durations in seconds
+//
+// 	* `GET /api/status => { ... }`
+type Status struct {
+	Engine EngineStatus
+	Agents []AgentStatus
+}
+
+type EngineStatus struct {
+	Requests         uint64
+	Responses        uint64
+	Timeouts         uint64
+	Retries          uint64
+	SendErrors       uint64
+	UnknownResponses uint64
+	UnknownTimeouts  uint64
+	ProtocolErrors   uint64
+	Pending          int
+	Queued           int
+}
+
+type AgentStatus struct {
+	Addr      string
+	Requests  uint64
+	Responses uint64
+	Timeouts  uint64
+	Retries   uint64
+	Pending   int
+	Queued    int
+
+	SRTT    float64 `json:",omitempty"`
+	RTTVar  float64 `json:",omitempty"`
+	Latency Histogram
+}
+
+// Cumulative histogram: Counts[i] is the number of observations within Buckets[i].
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
@@ -33,11 +33,15 @@ type Table struct {
 
 	Entries []TableEntry
 	Errors  []TableError `json:",omitempty"`
+
+	// Index of the last returned entry per HostID, if the ?limit= was reached: use as ?after=HostID:Index for the next page
+	Next map[string]string `json:",omitempty"`
 }
 
 type TableIndexMap map[string]interface{}
 type TableObjectsMap map[string]interface{}
 
+// The Index includes the context table index keys for any ?contexts= query.
 type TableEntry struct {
 	HostID  string `json:",omitempty"` // XXX: always?
 	Index   TableIndexMap
@@ -54,21 +58,37 @@ type TableError struct {
 //
 // Multiple values for the same field are OR, multiple fields are AND.
 //
+// The `limit` and `after` params paginate the entries of each host, using the returned `Next` index as `after=HOST:INDEX`.
+// Only the hosts with an `after` index are queried. A plain `after=INDEX` is only supported for a single host.
+//
+// The `contexts` param walks the table using each SNMP community@context from the index of the contexts table,
+// e.g. `?contexts=Q-BRIDGE-MIB::dot1qVlanStaticTable` for per-VLAN tables. Not supported with `limit` or `after`.
+//
 // 	* `GET /api/tables/:table`
 // 	* `GET /api/hosts/:host/tables/:table`
 type TableQuery struct {
-	Hosts   []string `schema:"host"`
-	Objects []string `schema:"object"`
+	Hosts    []string `schema:"host"`
+	Objects  []string `schema:"object"`
+	Limit    int      `schema:"limit"`
+	After    []string `schema:"after"`
+	Contexts string   `schema:"contexts"`
 }
 
 // Optional URL ?query params
 //
 // Multiple values for the same field are OR, multiple fields are AND.
 //
+// The `contexts` param is the same as for the TableQuery.
+//
+// The `limit` and `after` params are not supported when querying multiple tables, and are rejected.
+//
 // 	* `GET /api/tables/`
 // 	* `GET /api/hosts/:host/tables/`
 type TablesQuery struct {
-	Hosts   []string `schema:"host"`
-	Tables  []string `schema:"table"`
-	Objects []string `schema:"object"`
+	Hosts    []string `schema:"host"`
+	Tables   []string `schema:"table"`
+	Objects  []string `schema:"object"`
+	Limit    int      `schema:"limit"`
+	After    []string `schema:"after"`
+	Contexts string   `schema:"contexts"`
 }
@@ -1,16 +1,20 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"github.com/qmsk/go-logging"
 	"github.com/qmsk/snmpbot/snmp"
 	"net"
+	"sync/atomic"
 )
 
 func NewClient(engine *Engine, config Config) (*Client, error) {
 	var client = makeClient(engine, config.Options)
 
-	if addr, err := engine.transport.Resolve(config.Address); err != nil {
+	client.transport = config.Transport
+
+	if addr, err := engine.resolve(config.Transport, config.Network, config.Address); err != nil {
 		return nil, fmt.Errorf("Resolve Config.Address=%v: %v", config.Address, err)
 	} else {
 		client.addr = addr
@@ -23,26 +27,55 @@ func NewClient(engine *Engine, config Config) (*Client, error) {
 
 func makeClient(engine *Engine, options Options) Client {
 	return Client{
-		engine:  engine,
-		options: options,
+		requests: new(uint64),
+		engine:   engine,
+		options:  options,
 	}
 }
 
 type Client struct {
+	requests *uint64 // atomic, shared with any Context() clients
+
 	engine  *Engine
 	options Options
 	log     logging.PrefixLogging
 
-	addr net.Addr // host or host:port
+	transport string   // named engine transport
+	addr      net.Addr // host or host:port
 }
 
 func (client *Client) String() string {
+	if client.options.SecretCommunity {
+		return fmt.Sprintf("%v", client.addr)
+	}
+
 	return fmt.Sprintf("%v@%v", string(client.options.Community), client.addr)
 }
 
+// Total number of requests made by this client, not including retries.
+func (client *Client) Requests() uint64 {
+	return atomic.LoadUint64(client.requests)
+}
+
+// Returns a client for the same agent, using the SNMPv2c community@context convention, e.g. for per-VLAN BRIDGE-MIB tables.
+//
+// The requests made by the context client are also included in the Requests() of this client.
+func (client *Client) Context(context string) *Client {
+	var contextClient = *client
+
+	contextClient.options.Community = client.options.Community + "@" + context
+	contextClient.log = logging.WithPrefix(log, fmt.Sprintf("Client<%v>", &contextClient))
+
+	return &contextClient
+}
+
 func (client *Client) request(send IO) (IO, error) {
 	var request = NewRequest(client.options, send)
 
+	atomic.AddUint64(client.requests, 1)
+
+	request.transport = client.transport
+
 	if err := client.engine.Request(request); err != nil {
 		client.log.Infof("Request %v: %v", request, err)
 
@@ -53,7 +86,7 @@ func (client *Client) request(send IO) (IO, error) {
 
 		return recv, err
 	} else {
-		client.log.Infof("Request %v", request)
+		client.log.Infof("Request %v (rtt=%v retries=%d)", request, request.RTT(), request.Retries())
 
 		return recv, nil
 	}
@@ -79,10 +112,53 @@ func (client *Client) requestPDU(requestType snmp.PDUType, pdu snmp.PDU, respons
 	} else if responsePDU, ok := recv.PDU.(snmp.GenericPDU); !ok {
 		return nil, fmt.Errorf("Invalid %v response type, expected %v, got %v with PDU of type %T", requestType, responseType, recv.PDUType, recv.PDU)
 	} else {
+		client.fixVarBinds(requestType, responsePDU.VarBinds)
+
 		return responsePDU.VarBinds, nil
 	}
 }
 
+// Work around broken agents using the NoSuchEndOfMibView and TruncateCounter64 options.
+func (client *Client) fixVarBinds(requestType snmp.PDUType, varBinds []snmp.VarBind) {
+	var walk = requestType == snmp.GetNextRequestType || requestType == snmp.GetBulkRequestType
+
+	for i := range varBinds {
+		var errorValue = varBinds[i].ErrorValue()
+
+		if client.options.NoSuchEndOfMibView && walk && (errorValue == snmp.NoSuchObjectValue || errorValue == snmp.NoSuchInstanceValue) {
+			varBinds[i].SetError(snmp.EndOfMibViewValue)
+		} else if client.options.TruncateCounter64 {
+			if err := varBinds[i].TruncateCounter64(); err != nil {
+				client.log.Debugf("Invalid Counter64 %v: %v", varBinds[i].OID(), err)
+			}
+		}
+	}
+}
+
+// Forward a request PDU of any type, returning the response, including any SNMP error status.
+//
+// The request ID is assigned by the Engine, and the PDU RequestID is ignored.
+func (client *Client) Forward(requestType snmp.PDUType, pdu snmp.PDU) (snmp.PDUType, snmp.PDU, error) {
+	var send = IO{
+		Addr: client.addr,
+		Packet: snmp.Packet{
+			Version:   SNMPVersion,
+			Community: []byte(client.options.Community),
+		},
+		PDUMeta: snmp.PDUMeta{
+			PDUType: requestType,
+		},
+		PDU: pdu,
+	}
+	var snmpError SNMPError
+
+	if recv, err := client.request(send); err != nil && !errors.As(err, &snmpError) {
+		return 0, nil, err
+	} else {
+		return recv.PDUType, recv.PDU, nil
+	}
+}
+
 func (client *Client) requestGeneric(requestType snmp.PDUType, varBinds []snmp.VarBind, responseType snmp.PDUType) ([]snmp.VarBind, error) {
 	var pdu = snmp.GenericPDU{
 		VarBinds: varBinds,
@@ -136,6 +212,13 @@ func (client *Client) requestSplit(requestType snmp.PDUType, varBinds []snmp.Var
 	return retVars, nil
 }
 
+// Number of requests used by requestSplit for the given number of OIDs
+func (client *Client) splitCount(count int) uint {
+	var maxVars = client.maxVars()
+
+	return (uint(count) + maxVars - 1) / maxVars
+}
+
 func makeGetVars(oids []snmp.OID) []snmp.VarBind {
 	var varBinds = make([]snmp.VarBind, len(oids))
 
@@ -158,16 +241,22 @@ func (client *Client) GetNextSplit(oids []snmp.OID) ([]snmp.VarBind, error) {
 	return client.requestSplit(snmp.GetNextRequestType, makeGetVars(oids), snmp.GetResponseType)
 }
 
+func (client *Client) maxVars() uint {
+	if client.options.MaxVars != 0 {
+		return client.options.MaxVars
+	} else {
+		return DefaultMaxVars
+	}
+}
+
+// Repetitions for the remaining MaxVars after the scalars, or 1 if the entries do not fit.
 func (client *Client) getBulkMaxRepetitions(scalarsLen uint, entriesLen uint) uint {
 	var maxRepetitions = DefaultMaxRepetitions
-	var maxVars = DefaultMaxVars
+	var maxVars = client.maxVars()
 
 	if client.options.MaxRepetitions != 0 {
 		maxRepetitions = client.options.MaxRepetitions
 	}
-	if client.options.MaxVars != 0 {
-		maxVars = client.options.MaxVars
-	}
 
 	if scalarsLen >= maxVars || entriesLen >= maxVars-scalarsLen {
 		return 1
@@ -192,14 +281,16 @@ func makeBulkVars(scalars []snmp.OID, entries []snmp.OID) []snmp.VarBind {
 }
 
 func unpackBulkVars(scalarCount int, entryLen int, varBinds []snmp.VarBind) ([]snmp.VarBind, [][]snmp.VarBind, error) {
-	var scalarVars = varBinds[:scalarCount]
-	var entryCount = (len(varBinds) - scalarCount) / entryLen
-	var entryList = make([][]snmp.VarBind, entryCount)
-
 	if len(varBinds) < scalarCount+entryLen {
 		return nil, nil, fmt.Errorf("Invalid bulk response for %d+%d => %d vars", scalarCount, entryLen, len(varBinds))
+	} else if entryLen == 0 {
+		return varBinds[:scalarCount], nil, nil
 	}
 
+	var scalarVars = varBinds[:scalarCount]
+	var entryCount = (len(varBinds) - scalarCount) / entryLen
+	var entryList = make([][]snmp.VarBind, entryCount)
+
 	for i := 0; i < entryCount; i++ {
 		var enrtryVars = make([]snmp.VarBind, entryLen)
 
@@ -215,9 +306,13 @@ func unpackBulkVars(scalarCount int, entryLen int, varBinds []snmp.VarBind) ([]s
 }
 
 func (client *Client) GetBulk(scalars []snmp.OID, entries []snmp.OID) ([]snmp.VarBind, [][]snmp.VarBind, error) {
+	return client.getBulk(scalars, entries, client.getBulkMaxRepetitions(uint(len(scalars)), uint(len(entries))))
+}
+
+func (client *Client) getBulk(scalars []snmp.OID, entries []snmp.OID, maxRepetitions uint) ([]snmp.VarBind, [][]snmp.VarBind, error) {
 	var pdu = snmp.BulkPDU{
 		NonRepeaters:   len(scalars),
-		MaxRepetitions: int(client.getBulkMaxRepetitions(uint(len(scalars)), uint(len(entries)))),
+		MaxRepetitions: int(maxRepetitions),
 		VarBinds:       makeBulkVars(scalars, entries),
 	}
 
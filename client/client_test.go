@@ -252,6 +252,77 @@ func TestGetRequestTooBig(t *testing.T) {
 	})
 }
 
+func TestForwardSetError(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+	var varBind = snmp.MakeVarBind(oid, []byte("test"))
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		transport.On("SetRequest", IO{
+			Addr: testAddr("test"),
+			Packet: snmp.Packet{
+				Version:   snmp.SNMPv2c,
+				Community: []byte("public"),
+			},
+			PDUMeta: snmp.PDUMeta{PDUType: snmp.SetRequestType},
+			PDU: snmp.GenericPDU{
+				RequestID: 1234, // packed using the PDUMeta.RequestID
+				VarBinds:  []snmp.VarBind{varBind},
+			},
+		}).Return(error(nil), IO{
+			Addr: testAddr("test"),
+			Packet: snmp.Packet{
+				Version:   snmp.SNMPv2c,
+				Community: []byte("public"),
+			},
+			PDUMeta: snmp.PDUMeta{PDUType: snmp.GetResponseType},
+			PDU: snmp.GenericPDU{
+				ErrorStatus: snmp.ReadOnlyError,
+				ErrorIndex:  1,
+				VarBinds:    []snmp.VarBind{varBind},
+			},
+		})
+
+		pduType, pdu, err := client.Forward(snmp.SetRequestType, snmp.GenericPDU{RequestID: 1234, VarBinds: []snmp.VarBind{varBind}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, snmp.GetResponseType, pduType)
+		assert.Equal(t, snmp.ReadOnlyError, pdu.GetError().ErrorStatus)
+	})
+}
+
+func TestClientContext(t *testing.T) {
+	var dot1dTpFdbPort = snmp.MustParseOID(".1.3.6.1.2.1.17.4.3.1.2") // BRIDGE-MIB::dot1dTpFdbPort
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		var contextClient = client.Context("10")
+
+		transport.agent = []snmp.VarBind{snmp.MakeVarBind(dot1dTpFdbPort.Extend(0, 1, 2, 3, 4, 5), int(1))}
+
+		if _, err := contextClient.GetNext(dot1dTpFdbPort); err != nil {
+			t.Fatalf("GetNext: %v", err)
+		}
+
+		assert.Equal(t, "public@10@test", contextClient.String())
+		assert.Equal(t, []byte("public@10"), transport.agentRequests[0].Packet.Community)
+		assert.Equal(t, uint64(1), contextClient.Requests())
+		assert.Equal(t, uint64(1), client.Requests())
+	})
+}
+
+func TestClientSecretCommunity(t *testing.T) {
+	var engine = makeEngine(&testTransport{})
+
+	client, err := NewClient(&engine, Config{Options: Options{Community: "secret", SecretCommunity: true}, Address: "test"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	assert.Equal(t, "test", client.String())
+	assert.NotContains(t, client.log.Prefix, "secret")
+	assert.NotContains(t, client.Context("10").String(), "secret")
+	assert.NotContains(t, client.Context("10").log.Prefix, "secret")
+}
+
 func TestGetNothing(t *testing.T) {
 	withTestClient(t, "test", func(transport *testTransport, client *Client) {
 		if varBinds, err := client.Get(); err != nil {
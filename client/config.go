@@ -1,35 +1,98 @@
 package client
 
 import (
+	"fmt"
+	"net"
 	"net/url"
+	"strings"
 )
 
+const ConfigScheme = "udp+snmp"
+
+type configScheme struct {
+	transport string
+	network   string
+}
+
+// URL schemes for the Config.Transport and Network, any other NAME+snmp schemes are used for the Config.Transport
+var configSchemes = map[string]configScheme{
+	"udp+snmp":  {},
+	"udp4+snmp": {network: "udp4"},
+	"udp6+snmp": {network: "udp6"},
+	"tcp+snmp":  {transport: TCPTransport},
+	"tcp4+snmp": {transport: TCPTransport, network: "tcp4"},
+	"tcp6+snmp": {transport: TCPTransport, network: "tcp6"},
+}
+
 type Config struct {
-	Options        // overrides community from URL user@
-	Address string // host or host:port from URL
+	Options          // overrides community from URL user@
+	Transport string // optional named engine transport from URL scheme, defaults to the engine transport
+	Network   string // optional udp4, udp6, tcp4 or tcp6 from URL scheme, defaults to the engine network
+	Address string // host or host:port from URL, without any IPv6 brackets if there is no port
 	Object  string // optional object from URL /path
 }
 
 // Parse a pseudo-URL config string:
-//  [community "@"] Host
+//  [Scheme "://"] [community "@"] Host [":" Port] ["/" Object]
+//
+// The Scheme is one of udp+snmp, udp4+snmp, udp6+snmp, tcp+snmp, tcp4+snmp, tcp6+snmp, or NAME+snmp for a named engine transport.
+// An IPv6 Host may be given without brackets if there is no Port.
 func ParseConfig(options Options, clientURL string) (Config, error) {
 	var config = Config{
 		Options: options,
 	}
+	var scheme = ConfigScheme
+
+	if i := strings.Index(clientURL, "://"); i >= 0 {
+		scheme, clientURL = clientURL[:i], clientURL[i+3:]
+	}
 
-	if parseURL, err := url.Parse("udp+snmp://" + clientURL); err != nil {
+	if configScheme, ok := configSchemes[scheme]; ok {
+		config.Transport = configScheme.transport
+		config.Network = configScheme.network
+	} else if name := strings.TrimSuffix(scheme, "+snmp"); name != scheme && name != "" {
+		config.Transport = name
+	} else {
+		return config, fmt.Errorf("Invalid scheme: %v", scheme)
+	}
+
+	if parseURL, err := url.Parse(scheme + "://" + bracketIPv6(clientURL)); err != nil {
 		return config, err
 	} else {
 		return config, config.parseURL(parseURL)
 	}
 }
 
+// Add brackets to a bare IPv6 host, which would otherwise be parsed as a host:port.
+func bracketIPv6(clientURL string) string {
+	var hostStart, hostEnd = 0, len(clientURL)
+
+	if i := strings.Index(clientURL, "/"); i >= 0 {
+		hostEnd = i
+	}
+	if i := strings.LastIndex(clientURL[:hostEnd], "@"); i >= 0 {
+		hostStart = i + 1
+	}
+
+	var host = clientURL[hostStart:hostEnd]
+
+	if ip := net.ParseIP(host); ip != nil && strings.Contains(host, ":") {
+		return clientURL[:hostStart] + "[" + host + "]" + clientURL[hostEnd:]
+	} else {
+		return clientURL
+	}
+}
+
 func (config *Config) parseURL(configURL *url.URL) error {
 	if configURL.User != nil {
 		config.Community = configURL.User.Username()
 	}
 
-	config.Address = configURL.Host
+	if port := configURL.Port(); port != "" {
+		config.Address = net.JoinHostPort(configURL.Hostname(), port)
+	} else {
+		config.Address = configURL.Hostname()
+	}
 
 	if configURL.Path != "" {
 		config.Object = configURL.Path[1:]
@@ -43,11 +106,23 @@ func (config *Config) parseURL(configURL *url.URL) error {
 func (config Config) String() string {
 	str := ""
 
-	if config.Community != "" {
+	if config.Transport == TCPTransport && config.Network != "" {
+		str += config.Network + "+snmp://"
+	} else if config.Transport != "" {
+		str += config.Transport + "+snmp://"
+	} else if config.Network != "" {
+		str += config.Network + "+snmp://"
+	}
+
+	if config.Community != "" && !config.SecretCommunity {
 		str += config.Community + "@"
 	}
 
-	str += config.Address
+	if _, _, err := net.SplitHostPort(config.Address); err != nil && strings.Contains(config.Address, ":") {
+		str += "[" + config.Address + "]"
+	} else {
+		str += config.Address
+	}
 
 	if config.Object != "" {
 		str += "/" + config.Object
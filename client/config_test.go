@@ -0,0 +1,62 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	var options = Options{Community: "public"}
+
+	for _, test := range []struct {
+		url    string
+		config Config
+		str    string
+	}{
+		{"localhost", Config{Address: "localhost"}, "public@localhost"},
+		{"private@localhost:1161/system", Config{Address: "localhost:1161", Object: "system"}, "private@localhost:1161/system"},
+		{"private@[2001:db8::1]:161", Config{Address: "[2001:db8::1]:161"}, "private@[2001:db8::1]:161"},
+		{"private@[2001:db8::1]", Config{Address: "2001:db8::1"}, "private@[2001:db8::1]"},
+		{"private@2001:db8::1/system", Config{Address: "2001:db8::1", Object: "system"}, "private@[2001:db8::1]/system"},
+		{"2001:db8::1", Config{Address: "2001:db8::1"}, "public@[2001:db8::1]"},
+		{"udp6+snmp://private@switch", Config{Network: "udp6", Address: "switch"}, "udp6+snmp://private@switch"},
+		{"udp4+snmp://192.0.2.1:161", Config{Network: "udp4", Address: "192.0.2.1:161"}, "udp4+snmp://public@192.0.2.1:161"},
+		{"udp+snmp://switch", Config{Address: "switch"}, "public@switch"},
+		{"tcp+snmp://private@switch", Config{Transport: "tcp", Address: "switch"}, "tcp+snmp://private@switch"},
+		{"tcp6+snmp://private@switch", Config{Transport: "tcp", Network: "tcp6", Address: "switch"}, "tcp6+snmp://private@switch"},
+		{"mgmt+snmp://private@switch", Config{Transport: "mgmt", Address: "switch"}, "mgmt+snmp://private@switch"},
+	} {
+		config, err := ParseConfig(options, test.url)
+
+		if assert.NoError(t, err, "ParseConfig %v", test.url) {
+			assert.Equal(t, test.config.Transport, config.Transport, "ParseConfig %v: Transport", test.url)
+			assert.Equal(t, test.config.Network, config.Network, "ParseConfig %v: Network", test.url)
+			assert.Equal(t, test.config.Address, config.Address, "ParseConfig %v: Address", test.url)
+			assert.Equal(t, test.config.Object, config.Object, "ParseConfig %v: Object", test.url)
+			assert.Equal(t, test.str, config.String(), "ParseConfig %v: String", test.url)
+		}
+	}
+}
+
+func TestConfigSecretCommunity(t *testing.T) {
+	config, err := ParseConfig(Options{Community: "secret", SecretCommunity: true}, "vrf1+snmp://localhost")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "secret", config.Community)
+		assert.Equal(t, "vrf1+snmp://localhost", config.String())
+	}
+}
+
+func TestParseConfigError(t *testing.T) {
+	_, err := ParseConfig(Options{}, "http://switch")
+
+	assert.EqualError(t, err, "Invalid scheme: http")
+
+	_, err = ParseConfig(Options{}, "+snmp://switch")
+
+	assert.EqualError(t, err, "Invalid scheme: +snmp")
+
+	_, err = ParseConfig(Options{}, "localhost:asdf")
+
+	assert.EqualError(t, err, `parse "udp+snmp://localhost:asdf": invalid port ":asdf" after host`)
+}
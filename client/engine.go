@@ -5,7 +5,10 @@ import (
 	"github.com/qmsk/go-logging"
 	"io"
 	"math/rand"
+	"net"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type requestIDPool uint32
@@ -23,22 +26,29 @@ func NewUDPEngine(udpOptions UDPOptions) (*Engine, error) {
 	if udp, err := NewUDP(udpOptions); err != nil {
 		return nil, err
 	} else {
-		var engine = makeEngine(udp)
+		return NewEngine(udp), nil
+	}
+}
 
-		engine.log = logging.WithPrefix(log, fmt.Sprintf("Engine<%v>", &engine))
+func NewEngine(transport Transport) *Engine {
+	var engine = makeEngine(transport)
 
-		return &engine, nil
-	}
+	engine.log = logging.WithPrefix(log, fmt.Sprintf("Engine<%v>", &engine))
+
+	return &engine
 }
 
 func makeEngine(transport Transport) Engine {
 	return Engine{
-		transport: transport,
+		transport:  transport,
+		transports: map[string]Transport{"": transport},
 
 		requestIDPool: randomizedRequestIDPool(),
 		requests:      make(requestMap),
+		agents:        make(map[string]*engineAgent),
 		requestChan:   make(chan *Request),
 		timeoutChan:   make(chan ioKey),
+		statsChan:     make(chan chan EngineStats),
 		recvChan:      make(chan IO),
 		closeChan:     make(chan struct{}),
 		closedChan:    make(chan struct{}),
@@ -46,21 +56,71 @@ func makeEngine(transport Transport) Engine {
 }
 
 type Engine struct {
-	log       logging.PrefixLogging
-	transport Transport
+	protocolErrors uint64 // atomic, 64-bit aligned
+
+	log        logging.PrefixLogging
+	transport  Transport            // default
+	transports map[string]Transport // named, including the default
 
 	requestIDPool requestIDPool
 	requests      requestMap
 	requestChan   chan *Request
 	timeoutChan   chan ioKey
 
-	recvChan chan IO
-	recvErr  error
+	// per-agent queued requests and RTT estimates
+	agents      map[string]*engineAgent
+	waiting     []*engineAgent // round-robin order
+	inFlight    uint
+	maxInFlight uint
+	wakeTimer   *time.Timer
+	wakeChan    <-chan time.Time
+
+	stats     EngineStats
+	statsChan chan chan EngineStats
+
+	recvChan  chan IO
+	recvMutex sync.Mutex
+	recvErr   error
 
 	closeChan  chan struct{}
 	closedChan chan struct{}
 }
 
+// Limit the total number of outstanding requests across all agents, zero is unlimited.
+//
+// Must be called before Run().
+func (engine *Engine) SetMaxInFlight(maxInFlight uint) {
+	engine.maxInFlight = maxInFlight
+}
+
+// Add a named transport, for clients using Config.Transport.
+//
+// Must be called before Run().
+func (engine *Engine) AddTransport(name string, transport Transport) error {
+	if name == "" {
+		return fmt.Errorf("Invalid transport name: %#v", name)
+	} else if _, exists := engine.transports[name]; exists {
+		return fmt.Errorf("Duplicate transport: %v", name)
+	}
+
+	engine.transports[name] = transport
+
+	return nil
+}
+
+// Resolve using the named transport, with any specific network.
+func (engine *Engine) resolve(name string, network string, addr string) (net.Addr, error) {
+	if transport, ok := engine.transports[name]; !ok {
+		return nil, fmt.Errorf("Unknown transport: %v", name)
+	} else if network == "" {
+		return transport.Resolve(addr)
+	} else if resolver, ok := transport.(NetworkResolver); !ok {
+		return nil, fmt.Errorf("Network %v is not supported by the %v transport", network, transport)
+	} else {
+		return resolver.ResolveNetwork(network, addr)
+	}
+}
+
 func (engine *Engine) String() string {
 	return fmt.Sprintf("%v", engine.transport)
 }
@@ -80,16 +140,35 @@ func (engine *Engine) teardown() {
 		request.close()
 	}
 
+	// cancel any waiting requests
+	for _, agent := range engine.waiting {
+		for _, request := range agent.queue {
+			request.close()
+		}
+	}
+
 	// cancel any active requests
 	for _, request := range engine.requests {
 		request.close()
 	}
 
-	// close transport
-	if err := engine.transport.Close(); err != nil {
-		engine.log.Warnf("SNMP<%v> close failed: %v", engine.transport, err)
-	} else {
-		// flush recv to let goroutine complete
+	if engine.wakeTimer != nil {
+		engine.wakeTimer.Stop()
+	}
+
+	// close transports
+	var closed = true
+
+	for _, transport := range engine.transports {
+		if err := transport.Close(); err != nil {
+			engine.log.Warnf("SNMP<%v> close failed: %v", transport, err)
+
+			closed = false
+		}
+	}
+
+	if closed {
+		// flush recv to let goroutines complete
 		for range engine.recvChan {
 
 		}
@@ -98,27 +177,49 @@ func (engine *Engine) teardown() {
 	close(engine.closedChan)
 }
 
-func (engine *Engine) receiver() {
-	defer close(engine.recvChan)
+// Receive from each transport, closing the recvChan once all of them have stopped.
+func (engine *Engine) receive() {
+	var waitGroup sync.WaitGroup
+
+	for _, transport := range engine.transports {
+		waitGroup.Add(1)
+
+		go func(transport Transport) {
+			defer waitGroup.Done()
+
+			if err := engine.receiver(transport); err != nil {
+				engine.recvMutex.Lock()
+				defer engine.recvMutex.Unlock()
 
+				if engine.recvErr == nil {
+					engine.recvErr = err
+				}
+			}
+		}(transport)
+	}
+
+	waitGroup.Wait()
+
+	close(engine.recvChan)
+}
+
+func (engine *Engine) receiver(transport Transport) error {
 	for {
-		if recv, err := engine.transport.Recv(); err != nil {
+		if recv, err := transport.Recv(); err != nil {
 			if protocolErr, ok := err.(ProtocolError); ok {
 				engine.log.Warnf("Recv: %v", protocolErr)
 
+				atomic.AddUint64(&engine.protocolErrors, 1)
+
 				continue
 			} else if err == io.EOF {
-				engine.log.Debugf("Recv: %v", err)
+				engine.log.Debugf("Recv %v: %v", transport, err)
 
-				engine.recvErr = nil
-
-				return
+				return nil
 			} else {
-				engine.log.Errorf("Recv: %v", err)
-
-				engine.recvErr = err
+				engine.log.Errorf("Recv %v: %v", transport, err)
 
-				return
+				return err
 			}
 		} else {
 			engine.log.Debugf("Recv: %#v", recv)
@@ -131,14 +232,113 @@ func (engine *Engine) receiver() {
 func (engine *Engine) sendRequest(request *Request) error {
 	engine.log.Debugf("Send: %#v", request.send)
 
-	if err := engine.transport.Send(request.send); err != nil {
-		return fmt.Errorf("SNMP<%v> send failed: %v", engine.transport, err)
+	request.sendTime = time.Now()
+
+	var transport = engine.transports[request.transport]
+
+	if transport == nil {
+		return fmt.Errorf("Unknown transport: %v", request.transport)
+	} else if err := transport.Send(request.send); err != nil {
+		return fmt.Errorf("SNMP<%v> send failed: %v", transport, err)
 	}
 
 	return nil
 }
 
-func (engine *Engine) startRequest(request *Request) {
+// Queue request for sending within the agent limits
+func (engine *Engine) queueRequest(request *Request) {
+	var key = request.send.Addr.String()
+
+	if request.transport != "" {
+		key = request.transport + "/" + key
+	}
+	var now = time.Now()
+	var agent = engine.agents[key]
+
+	if agent == nil {
+		agent = newEngineAgent(key, request.limits, now)
+
+		engine.agents[key] = agent
+	} else {
+		agent.limits = agent.limits.min(request.limits)
+		agent.active = now
+	}
+
+	if len(agent.queue) == 0 {
+		engine.waiting = append(engine.waiting, agent)
+	}
+
+	agent.queue = append(agent.queue, request)
+}
+
+// Start queued requests in round-robin order across agents, until blocked by the limits.
+func (engine *Engine) dispatch() {
+	var now = time.Now()
+	var blocked []*engineAgent
+	var wait time.Duration
+
+	for len(engine.waiting) > 0 {
+		if engine.maxInFlight > 0 && engine.inFlight >= engine.maxInFlight {
+			break
+		}
+
+		var agent = engine.waiting[0]
+
+		engine.waiting = engine.waiting[1:]
+
+		if ok, delay := agent.ready(now); !ok {
+			if delay > 0 && (wait == 0 || delay < wait) {
+				wait = delay
+			}
+
+			blocked = append(blocked, agent)
+
+			continue
+		}
+
+		var request = agent.pop()
+
+		if len(agent.queue) > 0 {
+			engine.waiting = append(engine.waiting, agent)
+		}
+
+		request.agent = agent
+		request.initTimeout(&agent.rtt)
+
+		if engine.startRequest(request) {
+			agent.pending++
+			agent.stats.Requests++
+			engine.inFlight++
+		}
+	}
+
+	// blocked agents keep their turn
+	engine.waiting = append(blocked, engine.waiting...)
+
+	if wait > 0 {
+		engine.wakeup(wait)
+	}
+}
+
+func (engine *Engine) wakeup(delay time.Duration) {
+	if engine.wakeTimer == nil {
+		engine.wakeTimer = time.NewTimer(delay)
+	} else {
+		if !engine.wakeTimer.Stop() {
+			select {
+			case <-engine.wakeTimer.C:
+			default:
+			}
+		}
+
+		engine.wakeTimer.Reset(delay)
+	}
+
+	engine.wakeChan = engine.wakeTimer.C
+}
+
+// Returns true if the request was sent
+func (engine *Engine) startRequest(request *Request) bool {
 	// initialize request with next request ID to get the request key used to track send/recv/timeout
 	requestKey := request.init(engine.nextRequestID())
 
@@ -147,16 +347,48 @@ func (engine *Engine) startRequest(request *Request) {
 
 		request.fail(fmt.Errorf("Request ID collision: %v", requestKey))
 
+		return false
+
 	} else if err := engine.sendRequest(request); err != nil {
 		engine.log.Debugf("Start request %v failed: %v", requestKey, err)
 
+		engine.stats.SendErrors++
+
 		request.fail(err)
+
+		return false
 	} else {
 		engine.log.Debugf("Start request %v: %v", requestKey, request)
 
 		engine.requests[requestKey] = request
+		engine.stats.Requests++
 
 		request.startTimeout(engine.timeoutChan, requestKey)
+
+		return true
+	}
+}
+
+// Release the limits for a started request
+func (engine *Engine) endRequest(requestKey ioKey, request *Request) {
+	delete(engine.requests, requestKey)
+
+	engine.inFlight--
+
+	if agent := request.agent; agent != nil {
+		agent.pending--
+		agent.active = time.Now()
+	}
+}
+
+// Forget the limits, RTT estimates and stats of any idle agents.
+func (engine *Engine) expireAgents(now time.Time) {
+	for key, agent := range engine.agents {
+		if agent.idle(now) {
+			engine.log.Debugf("Expire idle agent %v", key)
+
+			delete(engine.agents, key)
+		}
 	}
 }
 
@@ -165,12 +397,26 @@ func (engine *Engine) recvRequest(recv IO) {
 
 	if request, ok := engine.requests[requestKey]; !ok {
 		engine.log.Warnf("Unknown request %v recv", requestKey)
+
+		engine.stats.UnknownResponses++
 	} else {
 		engine.log.Debugf("Request %v done: %v", requestKey, request)
 
 		request.done(recv)
 
-		delete(engine.requests, requestKey)
+		engine.stats.Responses++
+
+		if agent := request.agent; agent != nil {
+			agent.stats.Responses++
+			agent.stats.Latency.observe(request.rtt)
+
+			if request.retries == 0 {
+				// the RTT is ambiguous for retried requests
+				agent.rtt.sample(request.rtt)
+			}
+		}
+
+		engine.endRequest(requestKey, request)
 	}
 }
 
@@ -178,23 +424,41 @@ func (engine *Engine) timeoutRequest(requestKey ioKey) {
 	if request, ok := engine.requests[requestKey]; !ok {
 		engine.log.Warnf("Unknown request %v timeout", requestKey)
 
+		engine.stats.UnknownTimeouts++
+
 	} else if request.retry <= 0 {
 		engine.log.Debugf("Timeout %v request: %v", requestKey, request)
 
-		request.failTimeout(engine.transport)
+		engine.stats.Timeouts++
+
+		if agent := request.agent; agent != nil {
+			agent.stats.Timeouts++
+		}
+
+		request.failTimeout(engine.transports[request.transport])
 
-		delete(engine.requests, requestKey)
+		engine.endRequest(requestKey, request)
 
 	} else {
 		request.retry--
+		request.retries++
+		request.backoffTimeout()
+
+		engine.stats.Retries++
+
+		if agent := request.agent; agent != nil {
+			agent.stats.Retries++
+		}
 
-		engine.log.Debugf("Retry request %v on timeout (%d attempts remaining): %v", requestKey, request.retry, request)
+		engine.log.Debugf("Retry request %v on timeout after %v (%d attempts remaining): %v", requestKey, request.timeout, request.retry, request)
 
 		if err := engine.sendRequest(request); err != nil {
 			engine.log.Debugf("Retry request %v failed: %v", requestKey, err)
 
+			engine.stats.SendErrors++
+
 			// cleanup
-			delete(engine.requests, requestKey)
+			engine.endRequest(requestKey, request)
 
 			request.fail(err)
 		} else {
@@ -204,12 +468,15 @@ func (engine *Engine) timeoutRequest(requestKey ioKey) {
 }
 
 func (engine *Engine) run() error {
+	var expireTicker = time.NewTicker(AgentIdleTimeout)
+
 	defer engine.teardown()
+	defer expireTicker.Stop()
 
 	for {
 		select {
 		case request := <-engine.requestChan:
-			engine.startRequest(request)
+			engine.queueRequest(request)
 
 		case recvIO, ok := <-engine.recvChan:
 			if !ok {
@@ -221,9 +488,20 @@ func (engine *Engine) run() error {
 		case requestKey := <-engine.timeoutChan:
 			engine.timeoutRequest(requestKey)
 
+		case <-engine.wakeChan:
+			engine.wakeChan = nil
+
+		case now := <-expireTicker.C:
+			engine.expireAgents(now)
+
+		case statsChan := <-engine.statsChan:
+			statsChan <- engine.makeStats()
+
 		case <-engine.closeChan:
 			return nil
 		}
+
+		engine.dispatch()
 	}
 }
 
@@ -234,7 +512,7 @@ func (engine *Engine) Transport() Transport {
 func (engine *Engine) Run() error {
 	engine.log.Debugf("Run...")
 
-	go engine.receiver()
+	go engine.receive()
 
 	return engine.run()
 }
@@ -248,6 +526,50 @@ func (engine *Engine) Request(request *Request) error {
 	return request.wait()
 }
 
+func (engine *Engine) makeStats() EngineStats {
+	var stats = engine.stats
+
+	stats.ProtocolErrors = atomic.LoadUint64(&engine.protocolErrors)
+	stats.Pending = len(engine.requests)
+	stats.Agents = make(map[string]AgentStats, len(engine.agents))
+
+	for key, agent := range engine.agents {
+		var agentStats = agent.stats
+
+		agentStats.Pending = int(agent.pending)
+		agentStats.Queued = len(agent.queue)
+		agentStats.SRTT = agent.rtt.srtt
+		agentStats.RTTVar = agent.rtt.rttvar
+		agentStats.Latency = agent.stats.Latency.copy()
+
+		stats.Queued += agentStats.Queued
+		stats.Agents[key] = agentStats
+	}
+
+	return stats
+}
+
+// Returns a snapshot of the engine counters, including the current number of outstanding and queued requests.
+//
+// Blocks until the engine is running.
+func (engine *Engine) Stats() EngineStats {
+	var statsChan = make(chan EngineStats, 1)
+
+	select {
+	case engine.statsChan <- statsChan:
+		return <-statsChan
+	case <-engine.closedChan:
+		return engine.makeStats()
+	}
+}
+
+// Close the transports of an engine that is not running
+func (engine *Engine) closeTransports() {
+	for _, transport := range engine.transports {
+		transport.Close()
+	}
+}
+
 func (engine *Engine) close() {
 	close(engine.closeChan)
 }
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEngineTransports(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+	var transport, engine, defaultClient = makeTestClient(t, "test")
+	var otherTransport = makeTestTransport()
+
+	if err := engine.AddTransport("other", &otherTransport); err != nil {
+		t.Fatalf("AddTransport: %v", err)
+	}
+
+	assert.EqualError(t, engine.AddTransport("other", &otherTransport), "Duplicate transport: other")
+
+	otherClient, err := NewClient(engine, Config{Options: defaultClient.options, Transport: "other", Address: "test"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = NewClient(engine, Config{Options: defaultClient.options, Transport: "unknown", Address: "test"})
+
+	assert.EqualError(t, err, "Resolve Config.Address=test: Unknown transport: unknown")
+
+	go engine.Run()
+	defer engine.Close()
+
+	transport.mockGet("test", oid, snmp.MakeVarBind(oid, []byte("default")))
+	otherTransport.mockGet("test", oid, snmp.MakeVarBind(oid, []byte("other")))
+
+	if varBinds, err := defaultClient.Get(oid); err != nil {
+		t.Fatalf("Get(%v): %v", oid, err)
+	} else {
+		assertVarBind(t, varBinds, 0, oid, []byte("default"))
+	}
+
+	if varBinds, err := otherClient.Get(oid); err != nil {
+		t.Fatalf("Get(%v): %v", oid, err)
+	} else {
+		assertVarBind(t, varBinds, 0, oid, []byte("other"))
+	}
+
+	transport.AssertExpectations(t)
+	otherTransport.AssertExpectations(t)
+
+	var stats = engine.Stats()
+
+	assert.Contains(t, stats.Agents, "test")
+	assert.Contains(t, stats.Agents, "other/test")
+}
+
+func TestParseTransportOptions(t *testing.T) {
+	var options TransportOptions
+
+	assert.NoError(t, options.Set("vrf1=udp4://192.0.2.1"))
+	assert.NoError(t, options.Set("vrf2=[2001:db8::2]:1161"))
+	assert.EqualError(t, options.Set("udp6://::1"), "Invalid transport, expected NAME=[NETWORK://][LOCAL]: udp6://::1")
+
+	assert.Equal(t, TransportOptions{
+		"vrf1": UDPOptions{Network: "udp4", Local: "192.0.2.1"},
+		"vrf2": UDPOptions{Local: "[2001:db8::2]:1161"},
+	}, options)
+	assert.Equal(t, "vrf1=udp4://192.0.2.1,vrf2=://[2001:db8::2]:1161", options.String())
+}
+
+func TestOptionsEngineTransports(t *testing.T) {
+	var options = Options{
+		UDP:        UDPOptions{Network: "udp4", Local: "127.0.0.1"},
+		Transports: TransportOptions{"local": UDPOptions{Network: "udp4", Local: "127.0.0.1"}},
+	}
+
+	engine, err := options.Engine()
+	if err != nil {
+		t.Fatalf("Engine: %v", err)
+	}
+
+	assert.Equal(t, 3, len(engine.transports))
+	assert.Contains(t, engine.transports, TCPTransport)
+
+	engine.closeTransports()
+
+	options.Replay = "test.json"
+
+	_, err = options.Engine()
+
+	assert.EqualError(t, err, "Named transports are not supported with record or replay")
+}
+
+func TestEngineTimeoutTransport(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		var otherTransport limitsTransport
+
+		if err := engine.AddTransport("other", &otherTransport); err != nil {
+			t.Fatalf("AddTransport: %v", err)
+		}
+
+		var request = NewRequest(Options{Timeout: time.Hour, Retry: 1}, IO{
+			Addr:    testAddr("a"),
+			PDUMeta: snmp.PDUMeta{PDUType: snmp.GetRequestType},
+			PDU:     snmp.GenericPDU{},
+		})
+
+		request.transport = "other"
+		request.retry = 0
+
+		engine.queueRequest(request)
+		engine.dispatch()
+		engine.timeoutRequest(request.send.key())
+
+		assert.Equal(t, []string(nil), transport.sent)
+		assert.Equal(t, []string{"a"}, otherTransport.sent)
+
+		if err, ok := (<-request.waitChan).(TimeoutError); assert.True(t, ok, "TimeoutError") {
+			assert.Equal(t, &otherTransport, err.transport)
+		}
+	})
+}
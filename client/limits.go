@@ -0,0 +1,113 @@
+package client
+
+import (
+	"math"
+	"time"
+)
+
+// Forget the agent state of any agents without any requests for this long.
+const AgentIdleTimeout = 10 * time.Minute
+
+// Per-agent request limits, zero values are unlimited.
+//
+// Requests exceeding the limits are queued by the Engine, and sent in round-robin order across agents.
+// Requests to the same agent with different limits use the strictest of the limits, until the agent is idle.
+// Retries are not limited.
+type Limits struct {
+	MaxPending uint    // maximum outstanding requests
+	Rate       float64 // maximum requests per second
+	Burst      uint    // token bucket size for Rate, default 1
+}
+
+func (limits Limits) burst() float64 {
+	if limits.Burst == 0 {
+		return 1
+	} else {
+		return float64(limits.Burst)
+	}
+}
+
+// Returns the stricter of both limits.
+func (limits Limits) min(other Limits) Limits {
+	if other.MaxPending > 0 && (limits.MaxPending == 0 || other.MaxPending < limits.MaxPending) {
+		limits.MaxPending = other.MaxPending
+	}
+
+	if limits.Rate <= 0 {
+		limits.Rate, limits.Burst = other.Rate, other.Burst
+	} else if other.Rate > 0 {
+		limits.Rate = math.Min(limits.Rate, other.Rate)
+
+		if other.burst() < limits.burst() {
+			limits.Burst = other.Burst
+		}
+	}
+
+	return limits
+}
+
+// Engine state for requests to a specific agent address.
+type engineAgent struct {
+	key     string
+	limits  Limits
+	pending uint
+	tokens  float64
+	updated time.Time
+	active  time.Time // last queued or completed request
+	queue   []*Request
+	rtt     rttEstimator
+	stats   AgentStats
+}
+
+func newEngineAgent(key string, limits Limits, now time.Time) *engineAgent {
+	return &engineAgent{
+		key:     key,
+		limits:  limits,
+		tokens:  limits.burst(),
+		updated: now,
+		active:  now,
+	}
+}
+
+func (agent *engineAgent) idle(now time.Time) bool {
+	return agent.pending == 0 && len(agent.queue) == 0 && now.Sub(agent.active) >= AgentIdleTimeout
+}
+
+func (agent *engineAgent) refill(now time.Time) {
+	if agent.limits.Rate <= 0 {
+		return
+	}
+
+	agent.tokens += now.Sub(agent.updated).Seconds() * agent.limits.Rate
+	agent.updated = now
+
+	if burst := agent.limits.burst(); agent.tokens > burst {
+		agent.tokens = burst
+	}
+}
+
+// Returns false if the agent limits do not allow sending a new request,
+// with a non-zero delay if waiting for the rate limit.
+func (agent *engineAgent) ready(now time.Time) (bool, time.Duration) {
+	agent.refill(now)
+
+	if agent.limits.MaxPending > 0 && agent.pending >= agent.limits.MaxPending {
+		return false, 0
+	} else if agent.limits.Rate > 0 && agent.tokens < 1 {
+		return false, time.Duration((1 - agent.tokens) / agent.limits.Rate * float64(time.Second))
+	} else {
+		return true, 0
+	}
+}
+
+func (agent *engineAgent) pop() *Request {
+	var request = agent.queue[0]
+
+	agent.queue = agent.queue[1:]
+
+	if agent.limits.Rate > 0 {
+		agent.tokens--
+	}
+
+	return request
+}
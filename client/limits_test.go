@@ -0,0 +1,198 @@
+package client
+
+import (
+	"github.com/qmsk/go-logging"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+// Records sent requests, without any responses
+type limitsTransport struct {
+	sent []string
+}
+
+func (transport *limitsTransport) Resolve(addr string) (net.Addr, error) {
+	return testAddr(addr), nil
+}
+
+func (transport *limitsTransport) Send(io IO) error {
+	transport.sent = append(transport.sent, io.Addr.String())
+
+	return nil
+}
+
+func (transport *limitsTransport) Recv() (IO, error) {
+	return IO{}, EOF
+}
+
+func (transport *limitsTransport) Close() error {
+	return nil
+}
+
+func withLimitsEngine(t *testing.T, f func(*Engine, *limitsTransport)) {
+	SetLogging(logging.TestLogging(t))
+
+	var transport limitsTransport
+	var engine = makeEngine(&transport)
+
+	engine.log = logging.WithPrefix(log, "Engine<test>")
+
+	f(&engine, &transport)
+
+	for _, request := range engine.requests {
+		request.close()
+	}
+}
+
+func queueLimitsRequest(engine *Engine, addr string, limits Limits) *Request {
+	var request = NewRequest(Options{Timeout: time.Hour, Limits: limits}, IO{
+		Addr:    testAddr(addr),
+		PDUMeta: snmp.PDUMeta{PDUType: snmp.GetRequestType},
+		PDU:     snmp.GenericPDU{},
+	})
+
+	engine.queueRequest(request)
+
+	return request
+}
+
+func TestEngineMaxPending(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		var limits = Limits{MaxPending: 1}
+		var request = queueLimitsRequest(engine, "a", limits)
+
+		queueLimitsRequest(engine, "a", limits)
+		queueLimitsRequest(engine, "b", limits)
+
+		engine.dispatch()
+
+		assert.Equal(t, []string{"a", "b"}, transport.sent)
+		assert.Equal(t, uint(2), engine.inFlight)
+
+		engine.endRequest(request.send.key(), request)
+		engine.dispatch()
+
+		assert.Equal(t, []string{"a", "b", "a"}, transport.sent)
+		assert.Equal(t, uint(2), engine.inFlight)
+	})
+}
+
+func TestEngineMaxInFlight(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		engine.SetMaxInFlight(1)
+
+		var request = queueLimitsRequest(engine, "a", Limits{})
+
+		queueLimitsRequest(engine, "a", Limits{})
+		queueLimitsRequest(engine, "b", Limits{})
+
+		engine.dispatch()
+
+		assert.Equal(t, []string{"a"}, transport.sent)
+
+		engine.endRequest(request.send.key(), request)
+		engine.dispatch()
+
+		// round-robin across agents
+		assert.Equal(t, []string{"a", "b"}, transport.sent)
+		assert.Equal(t, uint(1), engine.inFlight)
+	})
+}
+
+func TestEngineRate(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		var limits = Limits{Rate: 10, Burst: 2}
+
+		queueLimitsRequest(engine, "a", limits)
+		queueLimitsRequest(engine, "a", limits)
+		queueLimitsRequest(engine, "a", limits)
+
+		engine.dispatch()
+
+		assert.Equal(t, []string{"a", "a"}, transport.sent)
+		assert.NotNil(t, engine.wakeChan, "waiting for rate limit")
+
+		<-engine.wakeChan
+		engine.wakeChan = nil
+		engine.dispatch()
+
+		assert.Equal(t, []string{"a", "a", "a"}, transport.sent)
+	})
+}
+
+func TestEngineAgentReady(t *testing.T) {
+	var now = time.Now()
+	var agent = newEngineAgent("a", Limits{Rate: 2}, now)
+
+	agent.queue = []*Request{nil, nil}
+
+	ok, _ := agent.ready(now)
+	assert.True(t, ok)
+
+	agent.pop()
+
+	ok, delay := agent.ready(now)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, delay)
+
+	ok, _ = agent.ready(now.Add(500 * time.Millisecond))
+	assert.True(t, ok)
+}
+
+func TestLimitsMin(t *testing.T) {
+	for _, test := range []struct {
+		limits Limits
+		other  Limits
+		min    Limits
+	}{
+		{Limits{}, Limits{}, Limits{}},
+		{Limits{MaxPending: 2}, Limits{}, Limits{MaxPending: 2}},
+		{Limits{}, Limits{MaxPending: 2, Rate: 10, Burst: 5}, Limits{MaxPending: 2, Rate: 10, Burst: 5}},
+		{Limits{MaxPending: 2, Rate: 10, Burst: 5}, Limits{MaxPending: 4, Rate: 20}, Limits{MaxPending: 2, Rate: 10}},
+		{Limits{Rate: 20, Burst: 2}, Limits{MaxPending: 1, Rate: 10, Burst: 5}, Limits{MaxPending: 1, Rate: 10, Burst: 2}},
+	} {
+		assert.Equalf(t, test.min, test.limits.min(test.other), "%#v.min(%#v)", test.limits, test.other)
+	}
+}
+
+// the agent uses the strictest limits of any request
+func TestEngineAgentLimits(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		queueLimitsRequest(engine, "a", Limits{MaxPending: 1})
+		queueLimitsRequest(engine, "a", Limits{})
+		queueLimitsRequest(engine, "a", Limits{MaxPending: 2})
+
+		engine.dispatch()
+
+		assert.Equal(t, []string{"a"}, transport.sent)
+		assert.Equal(t, Limits{MaxPending: 1}, engine.agents["a"].limits)
+	})
+}
+
+func TestEngineExpireAgents(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		var request = queueLimitsRequest(engine, "a", Limits{})
+
+		queueLimitsRequest(engine, "b", Limits{MaxPending: 1})
+		queueLimitsRequest(engine, "b", Limits{MaxPending: 1})
+
+		engine.dispatch()
+		engine.endRequest(request.send.key(), request)
+
+		engine.expireAgents(time.Now())
+
+		assert.Contains(t, engine.agents, "a", "recently active")
+
+		engine.expireAgents(time.Now().Add(AgentIdleTimeout))
+
+		assert.NotContains(t, engine.agents, "a", "idle")
+		assert.Contains(t, engine.agents, "b", "pending and queued requests")
+
+		queueLimitsRequest(engine, "a", Limits{MaxPending: 2})
+
+		assert.Equal(t, Limits{MaxPending: 2}, engine.agents["a"].limits, "new agent")
+	})
+}
@@ -2,34 +2,194 @@ package client
 
 import (
 	"flag"
+	"fmt"
 	"github.com/qmsk/snmpbot/snmp"
+	"sort"
+	"strings"
 	"time"
 )
 
 const (
 	SNMPVersion           = snmp.SNMPv2c
 	DefaultTimeout        = 1 * time.Second
+	DefaultMinTimeout     = 100 * time.Millisecond
+	DefaultMaxTimeout     = 5 * time.Second
 	DefaultRetry          = uint(3)
 	DefaultMaxVars        = uint(50)
 	DefaultMaxRepetitions = uint(20)
 )
 
 type Options struct {
-	Community      string
-	Timeout        time.Duration
-	Retry          uint
-	UDP            UDPOptions
-	MaxVars        uint
-	MaxRepetitions uint
-	NoBulk         bool
+	Community          string
+	SecretCommunity    bool          // omit the Community from the client String() and logs
+	Timeout            time.Duration // initial timeout, until the agent RTT is known
+	MinTimeout         time.Duration // lower bound for estimated timeouts
+	MaxTimeout         time.Duration // upper bound for estimated and backoff timeouts, at least the Timeout
+	Retry              uint
+	UDP                UDPOptions
+	MaxVars            uint
+	MaxRepetitions     uint
+	NoBulk             bool
+	MaxWalkRows        uint          // default WalkOptions.MaxRows
+	MaxWalkRequests    uint          // default WalkOptions.MaxRequests
+	SkipNonIncreasing  bool          // default WalkOptions.SkipNonIncreasing
+	TableWalkMode      TableWalkMode // default for any WalkOptions.TableMode auto
+	NoSuchEndOfMibView bool          // treat noSuchObject and noSuchInstance values in GetNext and GetBulk responses as endOfMibView
+	TruncateCounter64  bool          // decode Counter64 values encoded with more than 64 bits using the low-order 64 bits
+	Limits             Limits        // per-agent limits
+	MaxInFlight        uint          // engine-wide limit for outstanding requests
+	Transports         TransportOptions
+	Record             string // record all packets to the file
+	Replay             string // replay responses from a recorded file, instead of using UDP
 }
 
 func (options *Options) InitFlags() {
 	flag.StringVar(&options.Community, "snmp-community", "public", "Default SNMP community")
 	flag.DurationVar(&options.Timeout, "snmp-timeout", DefaultTimeout, "SNMP request timeout")
+	flag.DurationVar(&options.MinTimeout, "snmp-min-timeout", DefaultMinTimeout, "SNMP request timeout lower bound, for timeouts estimated from the agent RTT")
+	flag.DurationVar(&options.MaxTimeout, "snmp-max-timeout", DefaultMaxTimeout, "SNMP request timeout upper bound, for exponential backoff on retries, at least -snmp-timeout")
 	flag.UintVar(&options.Retry, "snmp-retry", DefaultRetry, "SNMP request retry")
 	flag.UintVar(&options.UDP.Size, "snmp-udp-size", UDPSize, "Maximum UDP recv size")
+	flag.StringVar(&options.UDP.Network, "snmp-udp-network", UDPNetwork, "UDP network: udp4 for IPv4, udp6 for IPv6, or udp for dual-stack")
+	flag.StringVar(&options.UDP.Local, "snmp-udp-local", "", "Local UDP [HOST][:PORT] address to send requests from")
+	flag.Var(&options.Transports, "snmp-transport", "Named UDP or TCP transport NAME=[udp|udp4|udp6|tcp|tcp4|tcp6://][LOCAL], for hosts using NAME+snmp://")
+	flag.StringVar(&options.UDP.RejectLog, "snmp-udp-reject-log", "", "Append the raw bytes of any rejected packets to the file")
 	flag.UintVar(&options.MaxVars, "snmp-maxvars", DefaultMaxVars, "Maximum request VarBinds")
 	flag.UintVar(&options.MaxRepetitions, "snmp-maxrepetitions", DefaultMaxRepetitions, "Maximum repetitions for GetBulk")
 	flag.BoolVar(&options.NoBulk, "snmp-nobulk", false, "Do not use GetBulk requests")
+	flag.UintVar(&options.MaxWalkRows, "snmp-walk-max-rows", 0, "Fail walks exceeding the maximum number of rows (0 = unlimited)")
+	flag.UintVar(&options.MaxWalkRequests, "snmp-walk-max-requests", 0, "Fail walks exceeding the maximum number of requests (0 = unlimited)")
+	flag.BoolVar(&options.SkipNonIncreasing, "snmp-walk-skip-non-increasing", false, "Skip ahead past any non-increasing OIDs returned by the agent, instead of failing the walk")
+	flag.Var(&options.TableWalkMode, "snmp-walk-table-mode", "Walk tables by rows, columns, or auto to walk sparse tables by columns")
+	flag.BoolVar(&options.NoSuchEndOfMibView, "snmp-walk-nosuch-end", false, "End walks on any noSuchObject or noSuchInstance values returned by agents with broken endOfMibView handling")
+	flag.BoolVar(&options.TruncateCounter64, "snmp-truncate-counter64", false, "Decode any Counter64 values encoded with more than 64 bits using the low-order 64 bits")
+	flag.UintVar(&options.Limits.MaxPending, "snmp-max-pending", 0, "Maximum outstanding requests per agent (0 = unlimited)")
+	flag.Float64Var(&options.Limits.Rate, "snmp-rate", 0, "Maximum requests per second per agent (0 = unlimited)")
+	flag.UintVar(&options.Limits.Burst, "snmp-burst", 1, "Burst size for -snmp-rate")
+	flag.UintVar(&options.MaxInFlight, "snmp-max-inflight", 0, "Maximum outstanding requests across all agents (0 = unlimited)")
+	flag.StringVar(&options.Record, "snmp-record", "", "Record all sent and received packets to the file")
+	flag.StringVar(&options.Replay, "snmp-replay", "", "Replay responses from a -snmp-record file, instead of using UDP")
+}
+
+// Create a new Engine using UDP, or any replay file, with any recording.
+//
+// Unless recording or replaying, the engine also has any named transports, and a default TCPTransport for tcp+snmp:// clients.
+func (options Options) Engine() (*Engine, error) {
+	var transport Transport
+
+	if len(options.Transports) > 0 && (options.Record != "" || options.Replay != "") {
+		return nil, fmt.Errorf("Named transports are not supported with record or replay")
+	}
+
+	if options.Replay != "" {
+		if replay, err := OpenReplay(options.Replay); err != nil {
+			return nil, err
+		} else {
+			transport = replay
+		}
+	} else if udp, err := NewUDP(options.UDP); err != nil {
+		return nil, err
+	} else {
+		transport = udp
+	}
+
+	if options.Record != "" {
+		if record, err := OpenRecord(transport, options.Record); err != nil {
+			transport.Close()
+
+			return nil, err
+		} else {
+			transport = record
+		}
+	}
+
+	var engine = NewEngine(transport)
+
+	engine.SetMaxInFlight(options.MaxInFlight)
+
+	for name, udpOptions := range options.Transports {
+		if transport, err := options.namedTransport(udpOptions); err != nil {
+			engine.closeTransports()
+
+			return nil, fmt.Errorf("Transport %v: %v", name, err)
+		} else if err := engine.AddTransport(name, transport); err != nil {
+			transport.Close()
+			engine.closeTransports()
+
+			return nil, err
+		}
+	}
+
+	if _, exists := options.Transports[TCPTransport]; exists || options.Record != "" || options.Replay != "" {
+
+	} else if tcp, err := NewTCP(TCPOptions{Size: options.UDP.Size, Network: strings.Replace(options.UDP.Network, "udp", "tcp", 1)}); err != nil {
+		engine.closeTransports()
+
+		return nil, fmt.Errorf("Transport %v: %v", TCPTransport, err)
+	} else if err := engine.AddTransport(TCPTransport, tcp); err != nil {
+		tcp.Close()
+		engine.closeTransports()
+
+		return nil, err
+	}
+
+	return engine, nil
+}
+
+// Create a UDP or TCP transport for the NETWORK, with any Size and RejectLog defaulting to the Options.UDP
+func (options Options) namedTransport(udpOptions UDPOptions) (Transport, error) {
+	if udpOptions.Size == 0 {
+		udpOptions.Size = options.UDP.Size
+	}
+	if udpOptions.RejectLog == "" {
+		udpOptions.RejectLog = options.UDP.RejectLog
+	}
+
+	switch udpOptions.Network {
+	case "tcp", "tcp4", "tcp6":
+		return NewTCP(TCPOptions{Size: udpOptions.Size, Network: udpOptions.Network, Local: udpOptions.Local})
+	default:
+		return NewUDP(udpOptions)
+	}
+}
+
+// Named UDP or TCP transports, using the UDPOptions Network and Local for TCP, with any Size and RejectLog defaulting to the Options.UDP
+type TransportOptions map[string]UDPOptions
+
+func (options TransportOptions) String() string {
+	var strs []string
+
+	for name, udpOptions := range options {
+		strs = append(strs, fmt.Sprintf("%v=%v://%v", name, udpOptions.Network, udpOptions.Local))
+	}
+
+	sort.Strings(strs)
+
+	return strings.Join(strs, ",")
+}
+
+// Parse NAME=[NETWORK://][LOCAL]
+func (options *TransportOptions) Set(value string) error {
+	var udpOptions UDPOptions
+
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("Invalid transport, expected NAME=[NETWORK://][LOCAL]: %v", value)
+	}
+
+	var name, local = parts[0], parts[1]
+
+	if i := strings.Index(local, "://"); i >= 0 {
+		udpOptions.Network, local = local[:i], local[i+3:]
+	}
+
+	udpOptions.Local = local
+
+	if *options == nil {
+		*options = make(TransportOptions)
+	}
+
+	(*options)[name] = udpOptions
+
+	return nil
 }
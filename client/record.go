@@ -0,0 +1,153 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	RecordSend = "send"
+	RecordRecv = "recv"
+)
+
+// Recorded transport IO, written as one JSON object per line.
+type Record struct {
+	Time   time.Time
+	Op     string // RecordSend or RecordRecv
+	Addr   string `json:",omitempty"`
+	Packet []byte `json:",omitempty"` // raw BER-encoded packet
+	Error  string `json:",omitempty"` // any rejected recv packet
+}
+
+// Read all records, as written by RecordTransport.
+func ReadRecords(reader io.Reader) ([]Record, error) {
+	var decoder = json.NewDecoder(reader)
+	var records []Record
+
+	for {
+		var record Record
+
+		if err := decoder.Decode(&record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, fmt.Errorf("Invalid record %d: %v", len(records), err)
+		} else {
+			records = append(records, record)
+		}
+	}
+}
+
+// Open a RecordTransport appending to the file.
+func OpenRecord(transport Transport, path string) (*RecordTransport, error) {
+	if file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+		return nil, fmt.Errorf("Open record: %v", err)
+	} else {
+		var recordTransport = NewRecordTransport(transport, file)
+
+		recordTransport.closer = file
+
+		return recordTransport, nil
+	}
+}
+
+// Wrap the transport, recording each sent and received packet to the writer.
+func NewRecordTransport(transport Transport, writer io.Writer) *RecordTransport {
+	return &RecordTransport{
+		transport: transport,
+		encoder:   json.NewEncoder(writer),
+	}
+}
+
+type RecordTransport struct {
+	transport Transport
+
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+func (transport *RecordTransport) String() string {
+	return fmt.Sprintf("%v", transport.transport)
+}
+
+func (transport *RecordTransport) record(op string, addr net.Addr, io IO, err error) {
+	var record = Record{
+		Time: time.Now(),
+		Op:   op,
+	}
+
+	if addr != nil {
+		record.Addr = addr.String()
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if protocolErr, ok := err.(ProtocolError); ok {
+		record.Packet = protocolErr.Bytes
+	} else if io.Bytes != nil {
+		// as received, including any non-canonical encoding
+		record.Packet = io.Bytes
+	} else if err := io.Packet.PackPDU(io.PDUMeta, io.PDU); err != nil {
+		log.Warnf("Record<%v>: pack %v: %v", transport, op, err)
+	} else if buf, err := io.Packet.Marshal(); err != nil {
+		log.Warnf("Record<%v>: marshal %v: %v", transport, op, err)
+	} else {
+		record.Packet = buf
+	}
+
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if err := transport.encoder.Encode(record); err != nil {
+		log.Warnf("Record<%v>: write %v: %v", transport, op, err)
+	}
+}
+
+func (transport *RecordTransport) Resolve(addr string) (net.Addr, error) {
+	return transport.transport.Resolve(addr)
+}
+
+func (transport *RecordTransport) ResolveNetwork(network string, addr string) (net.Addr, error) {
+	if resolver, ok := transport.transport.(NetworkResolver); !ok {
+		return nil, fmt.Errorf("Network %v is not supported by the %v transport", network, transport.transport)
+	} else {
+		return resolver.ResolveNetwork(network, addr)
+	}
+}
+
+func (transport *RecordTransport) Send(send IO) error {
+	transport.record(RecordSend, send.Addr, send, nil)
+
+	return transport.transport.Send(send)
+}
+
+func (transport *RecordTransport) Recv() (IO, error) {
+	recv, err := transport.transport.Recv()
+
+	if err == nil {
+		transport.record(RecordRecv, recv.Addr, recv, nil)
+	} else if protocolErr, ok := err.(ProtocolError); ok {
+		transport.record(RecordRecv, protocolErr.Addr, recv, err)
+	}
+
+	return recv, err
+}
+
+func (transport *RecordTransport) Close() error {
+	err := transport.transport.Close()
+
+	if transport.closer != nil {
+		if err := transport.closer.Close(); err != nil {
+			log.Warnf("Record<%v>: close: %v", transport, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qmsk/go-logging"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func withTestTransportClient(t *testing.T, transport Transport, f func(*Client)) {
+	SetLogging(logging.TestLogging(t))
+
+	var engine = NewEngine(transport)
+	var client = makeClient(engine, Options{
+		Community: "public",
+		Timeout:   10 * time.Millisecond,
+		Retry:     1,
+		NoBulk:    true,
+	})
+
+	client.addr = testAddr("test")
+	client.log = logging.WithPrefix(log, fmt.Sprintf("Client<%v>", &client))
+
+	go engine.Run()
+	defer engine.Close()
+
+	f(&client)
+}
+
+func testWalkValues(t *testing.T, client *Client, oid snmp.OID) []string {
+	var values []string
+
+	if err := client.WalkObjects([]snmp.OID{oid}, func(varBinds []snmp.VarBind) error {
+		for _, varBind := range varBinds {
+			if value, err := varBind.Value(); err != nil {
+				return err
+			} else {
+				values = append(values, fmt.Sprintf("%v = %v", varBind.OID(), value))
+			}
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatalf("WalkObjects(%v): %v", oid, err)
+	}
+
+	return values
+}
+
+func TestRecordReplay(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1") // IF-MIB::ifName
+	var varBinds = []snmp.VarBind{
+		snmp.MakeVarBind(ifName.Extend(1), []byte("if1")),
+		snmp.MakeVarBind(ifName.Extend(2), []byte("if2")),
+	}
+	var expected = []string{
+		".1.3.6.1.2.1.31.1.1.1.1.1 = [105 102 49]",
+		".1.3.6.1.2.1.31.1.1.1.1.2 = [105 102 50]",
+	}
+	var buf bytes.Buffer
+	var transport = makeTestTransport()
+
+	transport.mockGetNext("test", ifName, varBinds[0])
+	transport.mockGetNext("test", ifName.Extend(1), varBinds[1])
+	transport.mockGetNext("test", ifName.Extend(2), snmp.MakeVarBind(snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.2.1"), snmp.Counter32(0)))
+
+	withTestTransportClient(t, NewRecordTransport(&transport, &buf), func(client *Client) {
+		assert.Equal(t, expected, testWalkValues(t, client, ifName))
+	})
+
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecords: %v", err)
+	}
+
+	if assert.Equal(t, 6, len(records)) {
+		assert.Equal(t, RecordSend, records[0].Op)
+		assert.Equal(t, "test", records[0].Addr)
+		assert.Equal(t, RecordRecv, records[1].Op)
+	}
+
+	replay, err := NewReplayTransport(records)
+	if err != nil {
+		t.Fatalf("NewReplayTransport: %v", err)
+	}
+
+	withTestTransportClient(t, replay, func(client *Client) {
+		assert.Equal(t, expected, testWalkValues(t, client, ifName))
+	})
+}
+
+func makeTestRecord(op string, pduType snmp.PDUType, requestID int, varBinds ...snmp.VarBind) Record {
+	var packet = snmp.Packet{Version: snmp.SNMPv2c, Community: []byte("public")}
+
+	if err := packet.PackPDU(snmp.PDUMeta{PDUType: pduType, RequestID: requestID}, snmp.GenericPDU{RequestID: requestID, VarBinds: varBinds}); err != nil {
+		panic(err)
+	} else if buf, err := packet.Marshal(); err != nil {
+		panic(err)
+	} else {
+		return Record{Op: op, Addr: "192.0.2.1:161", Packet: buf}
+	}
+}
+
+type testRecvTransport struct {
+	Transport
+	recv IO
+}
+
+func (transport testRecvTransport) Recv() (IO, error) {
+	return transport.recv, nil
+}
+
+func TestRecordRecvBytes(t *testing.T) {
+	var response = makeTestRecord(RecordRecv, snmp.GetResponseType, 1, snmp.MakeVarBind(snmp.MustParseOID(".1.3.6.1.2.1.1.5.0"), []byte("test")))
+	var recv = IO{Addr: testAddr("test")}
+	var buf bytes.Buffer
+
+	if pduMeta, pdu, err := recv.Packet.Decode(response.Packet); err != nil {
+		t.Fatalf("Decode: %v", err)
+	} else {
+		recv.PDUMeta = pduMeta
+		recv.PDU = pdu
+	}
+
+	// non-canonical long-form SEQUENCE length, normalized by any re-encoding
+	recv.Bytes = append([]byte{response.Packet[0], 0x81}, response.Packet[1:]...)
+
+	var transport = NewRecordTransport(testRecvTransport{recv: recv}, &buf)
+
+	if _, err := transport.Recv(); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecords: %v", err)
+	}
+
+	if assert.Equal(t, 1, len(records)) {
+		assert.Equal(t, RecordRecv, records[0].Op)
+		assert.Equal(t, recv.Bytes, records[0].Packet)
+	}
+}
+
+func TestReplayRetry(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+	var records = []Record{
+		makeTestRecord(RecordSend, snmp.GetRequestType, 1, snmp.MakeVarBind(oid, nil)),
+		makeTestRecord(RecordSend, snmp.GetRequestType, 1, snmp.MakeVarBind(oid, nil)),
+		makeTestRecord(RecordRecv, snmp.GetResponseType, 1, snmp.MakeVarBind(oid, []byte("test"))),
+	}
+
+	replay, err := NewReplayTransport(records)
+	if err != nil {
+		t.Fatalf("NewReplayTransport: %v", err)
+	}
+
+	withTestTransportClient(t, replay, func(client *Client) {
+		// first request times out, and the retry gets the response
+		if varBinds, err := client.Get(oid); err != nil {
+			t.Fatalf("Get(%v): %v", oid, err)
+		} else {
+			assertVarBind(t, varBinds, 0, oid, []byte("test"))
+		}
+
+		// repeated
+		if varBinds, err := client.Get(oid); err != nil {
+			t.Fatalf("Get(%v): %v", oid, err)
+		} else {
+			assertVarBind(t, varBinds, 0, oid, []byte("test"))
+		}
+
+		// unknown
+		if _, err := client.Get(oid.Extend(1)); err == nil {
+			t.Errorf("Get(%v): no error", oid.Extend(1))
+		} else {
+			assert.EqualError(t, err, "SNMP<replay> send failed: No recorded request for GetRequest<.1.3.6.1.2.1.1.5.0.1>")
+		}
+	})
+}
+
+func TestReplayInvalid(t *testing.T) {
+	var records = []Record{
+		{Op: RecordSend, Packet: []byte{0x30, 0x00}},
+	}
+
+	_, err := NewReplayTransport(records)
+
+	assert.EqualError(t, err, "Invalid record 0 packet: Invalid version at offset 2: truncated")
+}
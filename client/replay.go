@@ -0,0 +1,206 @@
+package client
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+type replayAddr string
+
+func (addr replayAddr) Network() string {
+	return "replay"
+}
+
+func (addr replayAddr) String() string {
+	return string(addr)
+}
+
+// Requests are matched by PDU type and varbind OIDs.
+func replayMatch(pduType snmp.PDUType, pdu snmp.PDU) string {
+	var varBinds []snmp.VarBind
+	var oids []string
+
+	switch pdu := pdu.(type) {
+	case snmp.GenericPDU:
+		varBinds = pdu.VarBinds
+	case snmp.BulkPDU:
+		varBinds = pdu.VarBinds
+	}
+
+	for _, varBind := range varBinds {
+		oids = append(oids, varBind.OID().String())
+	}
+
+	return fmt.Sprintf("%v<%v>", pduType, strings.Join(oids, ", "))
+}
+
+type replayExchange struct {
+	match string
+	recv  []byte // nil if the request was not answered
+	used  bool
+}
+
+// Open a ReplayTransport using the records from the file.
+func OpenReplay(path string) (*ReplayTransport, error) {
+	if file, err := os.Open(path); err != nil {
+		return nil, fmt.Errorf("Open replay: %v", err)
+	} else if records, err := ReadRecords(file); err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("Read replay %v: %v", path, err)
+	} else {
+		file.Close()
+
+		return NewReplayTransport(records)
+	}
+}
+
+// Answer requests using the responses from the records written by a RecordTransport.
+//
+// Each sent request is answered using the next unused recorded request with the same PDU type and varbind OIDs,
+// ignoring the request IDs, community and address. Recorded requests that were never answered do not get any
+// response, and will time out. Once all matching requests have been used, the last answered request is repeated.
+func NewReplayTransport(records []Record) (*ReplayTransport, error) {
+	var transport = ReplayTransport{
+		recvChan:  make(chan IO),
+		closeChan: make(chan struct{}),
+	}
+	var pending = make(map[ioKey]*replayExchange)
+
+	for i, record := range records {
+		var io = IO{Addr: replayAddr(record.Addr)}
+
+		if record.Error != "" {
+			// rejected packets never got a response
+			continue
+		} else if pduMeta, pdu, err := io.Packet.Decode(record.Packet); err != nil {
+			return nil, fmt.Errorf("Invalid record %d packet: %v", i, err)
+		} else {
+			io.PDUMeta = pduMeta
+			io.PDU = pdu
+		}
+
+		switch record.Op {
+		case RecordSend:
+			var exchange = replayExchange{match: replayMatch(io.PDUType, io.PDU)}
+
+			transport.exchanges = append(transport.exchanges, &exchange)
+
+			pending[io.key()] = &exchange
+
+		case RecordRecv:
+			if exchange, ok := pending[io.key()]; ok {
+				exchange.recv = record.Packet
+
+				delete(pending, io.key())
+			}
+
+		default:
+			return nil, fmt.Errorf("Invalid record %d op: %v", i, record.Op)
+		}
+	}
+
+	return &transport, nil
+}
+
+type ReplayTransport struct {
+	mutex     sync.Mutex
+	exchanges []*replayExchange
+
+	recvChan  chan IO
+	closeChan chan struct{}
+}
+
+func (transport *ReplayTransport) String() string {
+	return "replay"
+}
+
+// Does not resolve any hostnames, the recorded addresses are not used for matching requests.
+func (transport *ReplayTransport) Resolve(addr string) (net.Addr, error) {
+	if _, port, _ := net.SplitHostPort(addr); port == "" {
+		addr = net.JoinHostPort(addr, UDPPort)
+	}
+
+	return replayAddr(addr), nil
+}
+
+// The network is ignored.
+func (transport *ReplayTransport) ResolveNetwork(network string, addr string) (net.Addr, error) {
+	return transport.Resolve(addr)
+}
+
+func (transport *ReplayTransport) lookup(match string) (*replayExchange, error) {
+	var last *replayExchange
+
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	for _, exchange := range transport.exchanges {
+		if exchange.match != match {
+			continue
+		} else if !exchange.used {
+			exchange.used = true
+
+			return exchange, nil
+		} else if exchange.recv != nil {
+			last = exchange
+		}
+	}
+
+	if last == nil {
+		return nil, fmt.Errorf("No recorded request for %v", match)
+	}
+
+	return last, nil
+}
+
+func (transport *ReplayTransport) Send(send IO) error {
+	var recv = IO{Addr: send.Addr}
+
+	if exchange, err := transport.lookup(replayMatch(send.PDUType, send.PDU)); err != nil {
+		return err
+	} else if exchange.recv == nil {
+		return nil
+	} else if pduMeta, pdu, err := recv.Packet.Decode(exchange.recv); err != nil {
+		return err
+	} else {
+		recv.Packet.Community = send.Packet.Community
+		recv.PDUMeta = pduMeta
+		recv.PDUMeta.RequestID = send.RequestID
+		recv.PDU = pdu
+
+		if genericPDU, ok := pdu.(snmp.GenericPDU); ok {
+			genericPDU.RequestID = send.RequestID
+			recv.PDU = genericPDU
+		}
+	}
+
+	// the engine is not receiving while sending
+	go func() {
+		select {
+		case transport.recvChan <- recv:
+		case <-transport.closeChan:
+		}
+	}()
+
+	return nil
+}
+
+func (transport *ReplayTransport) Recv() (IO, error) {
+	select {
+	case recv := <-transport.recvChan:
+		return recv, nil
+	case <-transport.closeChan:
+		return IO{}, EOF
+	}
+}
+
+func (transport *ReplayTransport) Close() error {
+	close(transport.closeChan)
+
+	return nil
+}
@@ -21,17 +21,31 @@ func NewRequest(options Options, send IO) *Request {
 	var request = makeRequest()
 
 	request.send = send
+	request.limits = options.Limits
 	request.timeout = DefaultTimeout
+	request.minTimeout = DefaultMinTimeout
+	request.maxTimeout = DefaultMaxTimeout
 	request.retry = DefaultRetry
 	request.startTime = time.Now()
 
 	if options.Timeout != 0 {
 		request.timeout = options.Timeout
 	}
+	if options.MinTimeout != 0 {
+		request.minTimeout = options.MinTimeout
+	}
+	if options.MaxTimeout != 0 {
+		request.maxTimeout = options.MaxTimeout
+	}
 	if options.Retry != 0 {
 		request.retry = options.Retry
 	}
 
+	// never retry with a shorter timeout than the configured initial timeout
+	if request.maxTimeout < request.timeout {
+		request.maxTimeout = request.timeout
+	}
+
 	return &request
 }
 
@@ -42,15 +56,23 @@ func makeRequest() Request {
 }
 
 type Request struct {
-	send      IO
-	id        requestID
-	timeout   time.Duration
-	retry     uint
-	startTime time.Time
-	timer     *time.Timer
-	waitChan  chan error
-	recv      IO
-	recvOK    bool
+	send       IO
+	transport  string // named engine transport, default if empty
+	id         requestID
+	limits     Limits
+	agent      *engineAgent // set once started
+	timeout    time.Duration
+	minTimeout time.Duration
+	maxTimeout time.Duration
+	retry      uint
+	retries    uint
+	startTime  time.Time
+	sendTime   time.Time
+	timer      *time.Timer
+	waitChan   chan error
+	recv       IO
+	recvOK     bool
+	rtt        time.Duration
 }
 
 func (request Request) String() string {
@@ -74,6 +96,16 @@ func (request *Request) error() error {
 	}
 }
 
+// Time between the last sent request and the received response, zero if not done.
+func (request *Request) RTT() time.Duration {
+	return request.rtt
+}
+
+// Number of retries sent after timeouts.
+func (request *Request) Retries() uint {
+	return request.retries
+}
+
 func (request *Request) Result() (IO, error) {
 	if !request.recvOK {
 		return request.recv, fmt.Errorf("Request is not done")
@@ -99,6 +131,20 @@ func (request *Request) init(id requestID) ioKey {
 	return request.send.key()
 }
 
+// Use the estimated agent timeout, if known
+func (request *Request) initTimeout(estimator *rttEstimator) {
+	if timeout, ok := estimator.timeout(); ok {
+		request.timeout = clampTimeout(timeout, request.minTimeout, request.maxTimeout)
+	}
+}
+
+// Exponential backoff, never below the previous timeout
+func (request *Request) backoffTimeout() {
+	if timeout := clampTimeout(2*request.timeout, 0, request.maxTimeout); timeout > request.timeout {
+		request.timeout = timeout
+	}
+}
+
 func (request *Request) startTimeout(timeoutChan chan ioKey, key ioKey) {
 	request.timer = time.AfterFunc(request.timeout, func() {
 		timeoutChan <- key
@@ -118,6 +164,7 @@ func (request *Request) fail(err error) {
 }
 
 func (request *Request) done(recv IO) {
+	request.rtt = time.Now().Sub(request.sendTime)
 	request.recv = recv
 	request.recvOK = true
 	request.waitChan <- nil
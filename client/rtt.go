@@ -0,0 +1,44 @@
+package client
+
+import (
+	"time"
+)
+
+// Smoothed round-trip time estimation, following the TCP RTO calculation from RFC 6298.
+type rttEstimator struct {
+	srtt    time.Duration
+	rttvar  time.Duration
+	sampled bool
+}
+
+func (estimator *rttEstimator) sample(rtt time.Duration) {
+	if !estimator.sampled {
+		estimator.srtt = rtt
+		estimator.rttvar = rtt / 2
+		estimator.sampled = true
+	} else {
+		var delta = estimator.srtt - rtt
+
+		if delta < 0 {
+			delta = -delta
+		}
+
+		estimator.rttvar = (3*estimator.rttvar + delta) / 4
+		estimator.srtt = (7*estimator.srtt + rtt) / 8
+	}
+}
+
+// Returns false if there are no samples yet
+func (estimator *rttEstimator) timeout() (time.Duration, bool) {
+	return estimator.srtt + 4*estimator.rttvar, estimator.sampled
+}
+
+func clampTimeout(timeout time.Duration, minTimeout time.Duration, maxTimeout time.Duration) time.Duration {
+	if minTimeout > 0 && timeout < minTimeout {
+		return minTimeout
+	} else if maxTimeout > 0 && timeout > maxTimeout {
+		return maxTimeout
+	} else {
+		return timeout
+	}
+}
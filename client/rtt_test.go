@@ -0,0 +1,109 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRTTEstimator(t *testing.T) {
+	var estimator rttEstimator
+
+	_, ok := estimator.timeout()
+	assert.False(t, ok)
+
+	estimator.sample(100 * time.Millisecond)
+
+	timeout, ok := estimator.timeout()
+	assert.True(t, ok)
+	assert.Equal(t, 300*time.Millisecond, timeout)
+
+	estimator.sample(20 * time.Millisecond)
+
+	timeout, _ = estimator.timeout()
+	assert.Equal(t, 90*time.Millisecond, estimator.srtt)
+	assert.Equal(t, 57500*time.Microsecond, estimator.rttvar)
+	assert.Equal(t, 320*time.Millisecond, timeout)
+}
+
+func TestClampTimeout(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, clampTimeout(2*time.Millisecond, 100*time.Millisecond, 5*time.Second))
+	assert.Equal(t, 5*time.Second, clampTimeout(8*time.Second, 100*time.Millisecond, 5*time.Second))
+	assert.Equal(t, time.Second, clampTimeout(time.Second, 100*time.Millisecond, 5*time.Second))
+	assert.Equal(t, time.Minute, clampTimeout(time.Minute, 0, 0))
+}
+
+func TestEngineTimeoutBackoff(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		var request = queueLimitsRequest(engine, "a", Limits{})
+
+		request.timeout = time.Second
+		request.maxTimeout = 3 * time.Second
+
+		engine.dispatch()
+		engine.timeoutRequest(request.send.key())
+
+		assert.Equal(t, uint(1), request.Retries())
+		assert.Equal(t, 2*time.Second, request.timeout)
+
+		engine.timeoutRequest(request.send.key())
+
+		assert.Equal(t, uint(2), request.Retries())
+		assert.Equal(t, 3*time.Second, request.timeout)
+		assert.Equal(t, []string{"a", "a", "a"}, transport.sent)
+	})
+}
+
+func TestRequestMaxTimeout(t *testing.T) {
+	var request = NewRequest(Options{Timeout: 10 * time.Second, MaxTimeout: DefaultMaxTimeout}, IO{})
+
+	assert.Equal(t, 10*time.Second, request.timeout)
+	assert.Equal(t, 10*time.Second, request.maxTimeout)
+
+	request.backoffTimeout()
+
+	assert.Equal(t, 10*time.Second, request.timeout)
+
+	request = NewRequest(Options{Timeout: 2 * time.Second}, IO{})
+
+	assert.Equal(t, DefaultMaxTimeout, request.maxTimeout)
+
+	request.backoffTimeout()
+	request.backoffTimeout()
+
+	assert.Equal(t, DefaultMaxTimeout, request.timeout)
+}
+
+func TestEngineTimeoutBackoffAboveMax(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		var request = queueLimitsRequest(engine, "a", Limits{})
+
+		request.timeout = 4 * time.Second
+		request.maxTimeout = 3 * time.Second
+
+		engine.dispatch()
+		engine.timeoutRequest(request.send.key())
+
+		assert.Equal(t, uint(1), request.Retries())
+		assert.Equal(t, 4*time.Second, request.timeout)
+	})
+}
+
+func TestEngineTimeoutEstimate(t *testing.T) {
+	withLimitsEngine(t, func(engine *Engine, transport *limitsTransport) {
+		var request = queueLimitsRequest(engine, "a", Limits{})
+
+		engine.dispatch()
+		engine.recvRequest(IO{Addr: request.send.Addr, PDUMeta: request.send.PDUMeta})
+
+		assert.Equal(t, uint(0), request.Retries())
+		assert.True(t, request.agent.rtt.sampled, "RTT sampled")
+
+		// the local RTT is below the default lower bound
+		var next = queueLimitsRequest(engine, "a", Limits{})
+
+		engine.dispatch()
+
+		assert.Equal(t, DefaultMinTimeout, next.timeout)
+	})
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"time"
+)
+
+// Upper bounds for the LatencyHistogram buckets
+var LatencyBuckets = []time.Duration{
+	1 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	5 * time.Second,
+}
+
+// Cumulative histogram: Counts[i] is the number of observations within Buckets[i].
+type LatencyHistogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+func (histogram *LatencyHistogram) observe(latency time.Duration) {
+	if histogram.Counts == nil {
+		histogram.Buckets = LatencyBuckets
+		histogram.Counts = make([]uint64, len(LatencyBuckets))
+	}
+
+	for i, bucket := range histogram.Buckets {
+		if latency <= bucket {
+			histogram.Counts[i]++
+		}
+	}
+
+	histogram.Count++
+	histogram.Sum += latency
+}
+
+func (histogram LatencyHistogram) copy() LatencyHistogram {
+	histogram.Counts = append([]uint64(nil), histogram.Counts...)
+
+	return histogram
+}
+
+// Request counters for a specific agent address.
+type AgentStats struct {
+	Requests  uint64 // sent requests, not including retries
+	Responses uint64
+	Timeouts  uint64
+	Retries   uint64
+	Pending   int // outstanding requests
+	Queued    int // requests waiting for the limits
+
+	SRTT    time.Duration // smoothed RTT, zero if unknown
+	RTTVar  time.Duration
+	Latency LatencyHistogram // RTT of each response
+}
+
+// Request counters for an Engine, see Engine.Stats().
+type EngineStats struct {
+	Requests         uint64 // sent requests, not including retries
+	Responses        uint64
+	Timeouts         uint64
+	Retries          uint64
+	SendErrors       uint64
+	UnknownResponses uint64 // unknown or duplicate responses
+	UnknownTimeouts  uint64
+	ProtocolErrors   uint64 // rejected packets
+	Pending          int    // outstanding requests
+	Queued           int    // requests waiting for the limits
+
+	Agents map[string]AgentStats
+}
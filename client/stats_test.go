@@ -0,0 +1,56 @@
+package client
+
+import (
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	var histogram LatencyHistogram
+
+	histogram.observe(2 * time.Millisecond)
+	histogram.observe(200 * time.Millisecond)
+	histogram.observe(10 * time.Second)
+
+	assert.Equal(t, LatencyBuckets, histogram.Buckets)
+	assert.Equal(t, []uint64{0, 1, 1, 1, 1, 2, 2, 2}, histogram.Counts)
+	assert.Equal(t, uint64(3), histogram.Count)
+	assert.Equal(t, 10202*time.Millisecond, histogram.Sum)
+}
+
+func TestEngineStats(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+	var timeoutOID = snmp.OID{1, 3, 6, 1, 2, 1, 1, 6, 0}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.Retry = 1
+
+		transport.mockGet("test", oid, snmp.MakeVarBind(oid, []byte("test")))
+		transport.mockGetTimeout("test", timeoutOID)
+
+		if _, err := client.Get(oid); err != nil {
+			t.Fatalf("Get(%v): %v", oid, err)
+		}
+		if _, err := client.Get(timeoutOID); err == nil {
+			t.Fatalf("Get(%v): no timeout", timeoutOID)
+		}
+
+		var stats = client.engine.Stats()
+
+		assert.Equal(t, uint64(2), stats.Requests)
+		assert.Equal(t, uint64(1), stats.Responses)
+		assert.Equal(t, uint64(1), stats.Timeouts)
+		assert.Equal(t, uint64(1), stats.Retries)
+		assert.Equal(t, 0, stats.Pending)
+		assert.Equal(t, 0, stats.Queued)
+
+		if agentStats, ok := stats.Agents["test"]; assert.True(t, ok, "Agents[test]") {
+			assert.Equal(t, uint64(2), agentStats.Requests)
+			assert.Equal(t, uint64(1), agentStats.Responses)
+			assert.Equal(t, uint64(1), agentStats.Latency.Count)
+			assert.Equal(t, uint64(1), agentStats.Timeouts)
+		}
+	})
+}
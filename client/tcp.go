@@ -0,0 +1,356 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const TCPPort = "161"
+const TCPNetwork = "tcp"   // dual-stack
+const TCPTransport = "tcp" // name of the default TCP transport for tcp+snmp:// clients
+
+const TCPDialTimeout = 5 * time.Second
+const TCPSendQueue = 100
+
+type TCPOptions struct {
+	Size        uint          // maximum message size, default UDPSize
+	Network     string        // tcp4 for IPv4, tcp6 for IPv6, default tcp for dual-stack
+	Local       string        // local [host] bind address
+	DialTimeout time.Duration // default TCPDialTimeout
+}
+
+// Default to the SNMP port, also for any bracketed or bare IPv6 address without a port
+func resolveTCP(network string, addr string) (*net.TCPAddr, error) {
+	if _, port, _ := net.SplitHostPort(addr); port == "" {
+		addr = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), TCPPort)
+	}
+
+	return net.ResolveTCPAddr(network, addr)
+}
+
+// Resolve any local [host][:port] address, defaulting to any host and port
+func resolveLocalTCP(network string, addr string) (*net.TCPAddr, error) {
+	if addr == "" {
+		return nil, nil
+	} else if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), "0")
+	}
+
+	return net.ResolveTCPAddr(network, addr)
+}
+
+// SNMP over TCP as per RFC 3430, using one connection per agent, opened on the first request.
+//
+// Each message is sent as a single BER-encoded packet on the stream, without any additional framing.
+// Any failed connection is closed, and re-opened by the following request.
+func NewTCP(options TCPOptions) (*TCP, error) {
+	var tcp = TCP{
+		network:     options.Network,
+		size:        options.Size,
+		dialTimeout: options.DialTimeout,
+		conns:       make(map[string]*tcpConn),
+		recvChan:    make(chan tcpRecv),
+		closeChan:   make(chan struct{}),
+	}
+
+	if tcp.network == "" {
+		tcp.network = TCPNetwork
+	}
+	if tcp.size == 0 {
+		tcp.size = UDPSize
+	}
+	if tcp.dialTimeout == 0 {
+		tcp.dialTimeout = TCPDialTimeout
+	}
+
+	switch tcp.network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("Invalid TCP network: %v", tcp.network)
+	}
+
+	if localAddr, err := resolveLocalTCP(tcp.network, options.Local); err != nil {
+		return nil, fmt.Errorf("Resolve local address %v: %v", options.Local, err)
+	} else {
+		tcp.local = localAddr
+	}
+
+	return &tcp, nil
+}
+
+type TCP struct {
+	network     string
+	size        uint
+	dialTimeout time.Duration
+	local       *net.TCPAddr
+
+	mutex    sync.Mutex
+	conns    map[string]*tcpConn
+	closed   bool
+	recvChan chan tcpRecv
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+type tcpRecv struct {
+	io  IO
+	err error
+}
+
+type tcpConn struct {
+	addr     net.Addr
+	sendChan chan []byte
+	doneChan chan struct{} // closed once disconnected
+
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+func (tcp *TCP) String() string {
+	if tcp.local != nil {
+		return fmt.Sprintf("%v://%v", tcp.network, tcp.local)
+	} else {
+		return fmt.Sprintf("%v://", tcp.network)
+	}
+}
+
+func (tcp *TCP) Resolve(addr string) (net.Addr, error) {
+	return resolveTCP(tcp.network, addr)
+}
+
+// Resolve using tcp4 or tcp6, which must be supported by the TCP network.
+func (tcp *TCP) ResolveNetwork(network string, addr string) (net.Addr, error) {
+	if network == TCPNetwork {
+		network = tcp.network
+	} else if tcp.network != TCPNetwork && network != tcp.network {
+		return nil, fmt.Errorf("Network %v is not supported by the %v transport", network, tcp.network)
+	}
+
+	return resolveTCP(network, addr)
+}
+
+// Queue the packet for sending on the agent connection, opening a new connection if needed.
+//
+// Does not block on connecting or writing, which happens in a separate goroutine per connection.
+func (tcp *TCP) Send(send IO) error {
+	if err := send.Packet.PackPDU(send.PDUMeta, send.PDU); err != nil {
+		return ProtocolError{err: fmt.Errorf("packet.PackPDU: %w", err)}
+	} else if buf, err := send.Packet.Marshal(); err != nil {
+		return ProtocolError{err: fmt.Errorf("packet.Marshal: %w", err)}
+	} else if conn, err := tcp.connect(send.Addr); err != nil {
+		return err
+	} else {
+		select {
+		case conn.sendChan <- buf:
+			return nil
+		default:
+			return fmt.Errorf("TCP %v: send queue full", send.Addr)
+		}
+	}
+}
+
+func (tcp *TCP) connect(addr net.Addr) (*tcpConn, error) {
+	tcp.mutex.Lock()
+	defer tcp.mutex.Unlock()
+
+	if tcp.closed {
+		return nil, fmt.Errorf("TCP transport is closed")
+	} else if conn := tcp.conns[addr.String()]; conn != nil {
+		return conn, nil
+	}
+
+	var conn = &tcpConn{
+		addr:     addr,
+		sendChan: make(chan []byte, TCPSendQueue),
+		doneChan: make(chan struct{}),
+	}
+
+	tcp.conns[addr.String()] = conn
+
+	go tcp.run(conn)
+
+	return conn, nil
+}
+
+// Forget the failed connection, for the following request to re-connect.
+func (tcp *TCP) disconnect(conn *tcpConn, err error) {
+	tcp.mutex.Lock()
+	defer tcp.mutex.Unlock()
+
+	if tcp.conns[conn.addr.String()] != conn {
+		// already disconnected
+		return
+	}
+
+	delete(tcp.conns, conn.addr.String())
+	close(conn.doneChan)
+
+	if err != nil && !tcp.closed {
+		log.Warnf("TCP<%v>: %v: %v", tcp, conn.addr, err)
+	}
+
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+
+	if conn.conn != nil {
+		conn.conn.Close()
+	}
+}
+
+// Connect and write queued packets, until the connection fails or the transport is closed.
+func (tcp *TCP) run(conn *tcpConn) {
+	var dialer = net.Dialer{Timeout: tcp.dialTimeout}
+
+	if tcp.local != nil {
+		dialer.LocalAddr = tcp.local
+	}
+
+	netConn, err := dialer.Dial(tcp.network, conn.addr.String())
+	if err != nil {
+		tcp.disconnect(conn, err)
+
+		return
+	}
+
+	conn.mutex.Lock()
+	conn.conn = netConn
+	conn.mutex.Unlock()
+
+	select {
+	case <-conn.doneChan:
+		// closed while connecting
+		netConn.Close()
+
+		return
+	default:
+	}
+
+	go tcp.read(conn, netConn)
+
+	for {
+		select {
+		case buf := <-conn.sendChan:
+			if _, err := netConn.Write(buf); err != nil {
+				tcp.disconnect(conn, err)
+
+				return
+			}
+		case <-conn.doneChan:
+			return
+		case <-tcp.closeChan:
+			tcp.disconnect(conn, nil)
+
+			return
+		}
+	}
+}
+
+// Read packets from the connection, until the connection fails or is closed.
+func (tcp *TCP) read(conn *tcpConn, netConn net.Conn) {
+	var reader = bufio.NewReader(netConn)
+
+	for {
+		var recv = IO{Addr: conn.addr}
+
+		if buf, err := readTCPMessage(reader, tcp.size); err == io.EOF {
+			tcp.disconnect(conn, nil)
+
+			return
+		} else if err != nil {
+			tcp.disconnect(conn, err)
+
+			return
+		} else if pduMeta, pdu, err := recv.Packet.Decode(buf); err != nil {
+			tcp.recv(tcpRecv{err: ProtocolError{err: fmt.Errorf("packet.Decode: %w", err), Addr: conn.addr, Bytes: buf}})
+		} else {
+			recv.PDUMeta = pduMeta
+			recv.PDU = pdu
+			recv.Bytes = buf
+
+			tcp.recv(tcpRecv{io: recv})
+		}
+	}
+}
+
+func (tcp *TCP) recv(recv tcpRecv) {
+	select {
+	case tcp.recvChan <- recv:
+	case <-tcp.closeChan:
+	}
+}
+
+// Read one BER TLV message from the stream, of at most size bytes.
+func readTCPMessage(reader *bufio.Reader, size uint) ([]byte, error) {
+	var header = make([]byte, 2, 6)
+
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	var length = uint(header[1])
+
+	if header[0]&0x1f == 0x1f {
+		return nil, fmt.Errorf("Invalid BER message: multi-byte tag")
+	} else if length == 0x80 {
+		return nil, fmt.Errorf("Invalid BER message: indefinite length")
+	} else if length > 0x80 {
+		var lengthSize = int(length & 0x7f)
+
+		if lengthSize > 4 {
+			return nil, fmt.Errorf("Invalid BER message: invalid length")
+		}
+
+		header = header[:2+lengthSize]
+
+		if _, err := io.ReadFull(reader, header[2:]); err != nil {
+			return nil, err
+		}
+
+		length = 0
+		for _, b := range header[2:] {
+			length = length<<8 | uint(b)
+		}
+	}
+
+	if uint(len(header))+length > size {
+		return nil, fmt.Errorf("Message too large (>%d bytes)", size)
+	}
+
+	var buf = make([]byte, uint(len(header))+length)
+
+	copy(buf, header)
+
+	if _, err := io.ReadFull(reader, buf[len(header):]); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+func (tcp *TCP) Recv() (IO, error) {
+	select {
+	case recv := <-tcp.recvChan:
+		return recv.io, recv.err
+	case <-tcp.closeChan:
+		return IO{}, EOF
+	}
+}
+
+// Close all connections, causing Recv() to return EOF.
+func (tcp *TCP) Close() error {
+	tcp.closeOnce.Do(func() {
+		tcp.mutex.Lock()
+		tcp.closed = true
+		tcp.mutex.Unlock()
+
+		close(tcp.closeChan)
+	})
+
+	return nil
+}
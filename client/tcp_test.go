@@ -0,0 +1,166 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/qmsk/go-logging"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+// Serve each TCP connection, closing the connection after each response if closeConn.
+func runTestTCPServer(t *testing.T, testServer *testServer, listener net.Listener, closeConn bool) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func(conn net.Conn) {
+			var reader = bufio.NewReader(conn)
+
+			defer conn.Close()
+
+			for {
+				var recv = IO{Addr: conn.RemoteAddr()}
+
+				if buf, err := readTCPMessage(reader, UDPSize); err != nil {
+					return
+				} else if pduMeta, pdu, err := recv.Packet.Decode(buf); err != nil {
+					t.Errorf("Decode: %v", err)
+					return
+				} else {
+					recv.PDUMeta = pduMeta
+					recv.PDU = pdu
+				}
+
+				send, err := testServer.handle(recv)
+				if err != nil {
+					t.Errorf("handle: %v", err)
+					return
+				}
+
+				send.RequestID = recv.RequestID
+
+				if err := send.Packet.PackPDU(send.PDUMeta, send.PDU); err != nil {
+					t.Errorf("PackPDU: %v", err)
+					return
+				} else if buf, err := send.Packet.Marshal(); err != nil {
+					t.Errorf("Marshal: %v", err)
+					return
+				} else if _, err := conn.Write(buf); err != nil {
+					return
+				} else if closeConn {
+					return
+				}
+			}
+		}(conn)
+	}
+}
+
+func withTestTCPClient(t *testing.T, closeConn bool, f func(*testServer, *Client)) {
+	SetLogging(logging.TestLogging(t))
+
+	var testServer = testServer{values: make(map[string]interface{})}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	go runTestTCPServer(t, &testServer, listener, closeConn)
+
+	tcp, err := NewTCP(TCPOptions{})
+	if err != nil {
+		t.Fatalf("NewTCP: %v", err)
+	}
+
+	var engine = NewEngine(tcp)
+
+	go engine.Run()
+	defer engine.Close()
+
+	if client, err := NewClient(engine, Config{Options: Options{Community: "public", Timeout: 1 * time.Second}, Address: listener.Addr().String()}); err != nil {
+		t.Fatalf("NewClient: %v", err)
+	} else {
+		f(&testServer, client)
+	}
+}
+
+func TestTCPGet(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+
+	withTestTCPClient(t, false, func(testServer *testServer, client *Client) {
+		testServer.MockGet(oid, []byte("qmsk-snmp test"))
+
+		for i := 0; i < 2; i++ {
+			if varBinds, err := client.Get(oid); err != nil {
+				t.Fatalf("Get(%v): %v", oid, err)
+			} else {
+				assertVarBind(t, varBinds, 0, oid, []byte("qmsk-snmp test"))
+			}
+		}
+	})
+}
+
+// the connection is re-opened by the following request after the agent closes it
+func TestTCPReconnect(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+
+	withTestTCPClient(t, true, func(testServer *testServer, client *Client) {
+		testServer.MockGet(oid, []byte("qmsk-snmp test"))
+
+		for i := 0; i < 3; i++ {
+			if varBinds, err := client.Get(oid); err != nil {
+				t.Fatalf("Get(%v): %v", oid, err)
+			} else {
+				assertVarBind(t, varBinds, 0, oid, []byte("qmsk-snmp test"))
+			}
+		}
+	})
+}
+
+func TestTCPReadMessage(t *testing.T) {
+	var long = append([]byte{0x30, 0x81, 0x80}, make([]byte, 0x80)...)
+	var stream = append(append([]byte{0x30, 0x02, 0x05, 0x00}, long...), 0x30, 0x80)
+	var reader = bufio.NewReader(bytes.NewReader(stream))
+
+	if buf, err := readTCPMessage(reader, UDPSize); assert.NoError(t, err) {
+		assert.Equal(t, []byte{0x30, 0x02, 0x05, 0x00}, buf)
+	}
+	if buf, err := readTCPMessage(reader, UDPSize); assert.NoError(t, err) {
+		assert.Equal(t, long, buf)
+	}
+
+	_, err := readTCPMessage(reader, UDPSize)
+
+	assert.EqualError(t, err, "Invalid BER message: indefinite length")
+
+	_, err = readTCPMessage(bufio.NewReader(bytes.NewReader(long)), 100)
+
+	assert.EqualError(t, err, "Message too large (>100 bytes)")
+}
+
+func TestTCPNetwork(t *testing.T) {
+	tcp, err := NewTCP(TCPOptions{Network: "tcp4"})
+	if err != nil {
+		t.Fatalf("NewTCP: %v", err)
+	}
+	defer tcp.Close()
+
+	if addr, err := tcp.Resolve("127.0.0.1"); assert.NoError(t, err) {
+		assert.Equal(t, "127.0.0.1:161", addr.String())
+	}
+
+	_, err = tcp.ResolveNetwork("tcp6", "::1")
+
+	assert.EqualError(t, err, "Network tcp6 is not supported by the tcp4 transport")
+
+	_, err = NewTCP(TCPOptions{Network: "udp"})
+
+	assert.EqualError(t, err, "Invalid TCP network: udp")
+}
@@ -25,6 +25,8 @@ type IO struct {
 	Packet snmp.Packet
 	snmp.PDUMeta
 	PDU snmp.PDU
+
+	Bytes []byte // raw received packet, if known by the transport
 }
 
 func (io IO) key() ioKey {
@@ -46,11 +48,28 @@ type Transport interface {
 	Close() error
 }
 
+// Optional Transport interface for resolving addresses using a specific network, e.g. "udp6".
+type NetworkResolver interface {
+	ResolveNetwork(network string, addr string) (net.Addr, error)
+}
+
 // soft application-layer errors, transport itself is still working
 type ProtocolError struct {
 	err error
+
+	Addr  net.Addr // remote address of any rejected packet
+	Bytes []byte   // raw bytes of any rejected packet
 }
 
 func (err ProtocolError) Error() string {
-	return err.err.Error()
+	if err.Addr != nil {
+		return fmt.Sprintf("%v: %v", err.Addr, err.err)
+	} else {
+		return err.err.Error()
+	}
+}
+
+// Returns any snmp.DecodeError for rejected packets
+func (err ProtocolError) Unwrap() error {
+	return err.err
 }
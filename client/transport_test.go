@@ -29,6 +29,57 @@ type testTransport struct {
 	recvErrorChan chan error
 
 	passRequestID bool
+
+	// respond to any GetNext and GetBulk requests from the agent VarBinds in OID order, instead of the mock
+	agent         []snmp.VarBind
+	agentRequests []IO
+}
+
+func (transport *testTransport) agentNext(oid snmp.OID) snmp.VarBind {
+	for _, varBind := range transport.agent {
+		if varBind.OID().Compare(oid) > 0 {
+			return varBind
+		}
+	}
+
+	return snmp.MakeVarBind(oid, snmp.EndOfMibViewValue)
+}
+
+func (transport *testTransport) agentResponse(send IO) IO {
+	var recv = IO{
+		Addr:    send.Addr,
+		Packet:  send.Packet,
+		PDUMeta: snmp.PDUMeta{PDUType: snmp.GetResponseType, RequestID: send.RequestID},
+	}
+	var varBinds []snmp.VarBind
+
+	switch pdu := send.PDU.(type) {
+	case snmp.GenericPDU:
+		for _, varBind := range pdu.VarBinds {
+			varBinds = append(varBinds, transport.agentNext(varBind.OID()))
+		}
+	case snmp.BulkPDU:
+		var repeaters = pdu.VarBinds[pdu.NonRepeaters:]
+
+		for _, varBind := range pdu.VarBinds[:pdu.NonRepeaters] {
+			varBinds = append(varBinds, transport.agentNext(varBind.OID()))
+		}
+
+		for r := 0; r < pdu.MaxRepetitions && len(repeaters) > 0; r++ {
+			var next = make([]snmp.VarBind, len(repeaters))
+
+			for i, varBind := range repeaters {
+				next[i] = transport.agentNext(varBind.OID())
+			}
+
+			varBinds = append(varBinds, next...)
+			repeaters = next
+		}
+	}
+
+	recv.PDU = snmp.GenericPDU{VarBinds: varBinds}
+
+	return recv
 }
 
 func (transport *testTransport) String() string {
@@ -40,6 +91,13 @@ func (transport *testTransport) Resolve(addr string) (net.Addr, error) {
 }
 
 func (transport *testTransport) Send(io IO) error {
+	if transport.agent != nil {
+		transport.agentRequests = append(transport.agentRequests, io)
+		transport.recvChan <- transport.agentResponse(io)
+
+		return nil
+	}
+
 	var requestID = io.RequestID
 
 	if !transport.passRequestID {
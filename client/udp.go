@@ -1,41 +1,96 @@
 package client
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 const UDPPort = "161"
 const UDPSize uint = 64 * 1024
 
+const UDPNetwork = "udp" // dual-stack
+
 type UDPOptions struct {
-	Size uint
+	Size      uint
+	RejectLog string // append any rejected packets to the file
+	Network   string // udp4 for IPv4, udp6 for IPv6, default udp for dual-stack
+	Local     string // local [host][:port] bind address for NewUDP
 }
 
-func makeUDP(options UDPOptions) UDP {
+func makeUDP(options UDPOptions) (UDP, error) {
 	if options.Size == 0 {
 		options.Size = UDPSize
 	}
+	if options.Network == "" {
+		options.Network = UDPNetwork
+	}
+
+	switch options.Network {
+	case "udp", "udp4", "udp6":
+	default:
+		return UDP{}, fmt.Errorf("Invalid UDP network: %v", options.Network)
+	}
 
-	return UDP{
-		size: options.Size,
+	var size = options.Size
+	var udp = UDP{
+		network: options.Network,
+		size:    size,
+		pool: &sync.Pool{
+			New: func() interface{} {
+				var buf = make([]byte, size)
+
+				return &buf
+			},
+		},
 	}
+
+	if options.RejectLog != "" {
+		if file, err := os.OpenFile(options.RejectLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+			return udp, fmt.Errorf("Open reject log: %v", err)
+		} else {
+			udp.rejectLog = file
+		}
+	}
+
+	return udp, nil
 }
 
-func resolveUDP(addr string) (*net.UDPAddr, error) {
+// Default to the SNMP port, also for any bracketed or bare IPv6 address without a port
+func resolveUDP(network string, addr string) (*net.UDPAddr, error) {
 	if _, port, _ := net.SplitHostPort(addr); port == "" {
-		addr = net.JoinHostPort(addr, UDPPort)
+		addr = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), UDPPort)
 	}
 
-	return net.ResolveUDPAddr("udp", addr)
+	return net.ResolveUDPAddr(network, addr)
+}
+
+// Resolve any local [host][:port] address, defaulting to any host and port
+func resolveLocalUDP(network string, addr string) (*net.UDPAddr, error) {
+	if addr == "" {
+		return &net.UDPAddr{}, nil
+	} else if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), "0")
+	}
+
+	return net.ResolveUDPAddr(network, addr)
 }
 
 func NewUDP(options UDPOptions) (*UDP, error) {
-	var udp = makeUDP(options)
+	udp, err := makeUDP(options)
+	if err != nil {
+		return nil, err
+	}
 
-	if udpConn, err := net.ListenUDP("udp", &net.UDPAddr{}); err != nil {
+	if localAddr, err := resolveLocalUDP(udp.network, options.Local); err != nil {
+		return nil, fmt.Errorf("Resolve local address %v: %v", options.Local, err)
+	} else if udpConn, err := net.ListenUDP(udp.network, localAddr); err != nil {
 		return nil, err
 	} else {
 		udp.conn = udpConn
@@ -51,11 +106,14 @@ func NewUDP(options UDPOptions) (*UDP, error) {
 }
 
 func ListenUDP(addr string, options UDPOptions) (*UDP, error) {
-	var udp = makeUDP(options)
+	udp, err := makeUDP(options)
+	if err != nil {
+		return nil, err
+	}
 
-	if udpAddr, err := resolveUDP(addr); err != nil {
+	if udpAddr, err := resolveUDP(udp.network, addr); err != nil {
 		return nil, err
-	} else if udpConn, err := net.ListenUDP("udp", udpAddr); err != nil {
+	} else if udpConn, err := net.ListenUDP(udp.network, udpAddr); err != nil {
 		return nil, err
 	} else {
 		udp.addr = udpAddr
@@ -66,11 +124,14 @@ func ListenUDP(addr string, options UDPOptions) (*UDP, error) {
 }
 
 func DialUDP(addr string, options UDPOptions) (*UDP, error) {
-	var udp = makeUDP(options)
+	udp, err := makeUDP(options)
+	if err != nil {
+		return nil, err
+	}
 
-	if udpAddr, err := resolveUDP(addr); err != nil {
+	if udpAddr, err := resolveUDP(udp.network, addr); err != nil {
 		return nil, err
-	} else if udpConn, err := net.DialUDP("udp", nil, udpAddr); err != nil {
+	} else if udpConn, err := net.DialUDP(udp.network, nil, udpAddr); err != nil {
 		return nil, err
 	} else {
 		udp.addr = udpAddr
@@ -81,9 +142,13 @@ func DialUDP(addr string, options UDPOptions) (*UDP, error) {
 }
 
 type UDP struct {
-	size uint
-	addr *net.UDPAddr
-	conn *net.UDPConn
+	network string
+	size    uint
+	pool    *sync.Pool // *[]byte buffers of size, re-used for send/recv
+	addr    *net.UDPAddr
+	conn    *net.UDPConn
+
+	rejectLog *os.File
 }
 
 func (udp *UDP) String() string {
@@ -100,7 +165,18 @@ func (udp *UDP) LocalAddr() (*net.UDPAddr, error) {
 }
 
 func (udp *UDP) Resolve(addr string) (net.Addr, error) {
-	return resolveUDP(addr)
+	return resolveUDP(udp.network, addr)
+}
+
+// Resolve using udp4 or udp6, which must be supported by the UDP network.
+func (udp *UDP) ResolveNetwork(network string, addr string) (net.Addr, error) {
+	if network == UDPNetwork {
+		network = udp.network
+	} else if udp.network != UDPNetwork && network != udp.network {
+		return nil, fmt.Errorf("Network %v is not supported by the %v transport", network, udp.network)
+	}
+
+	return resolveUDP(network, addr)
 }
 
 func (udp *UDP) send(buf []byte, addr net.Addr) error {
@@ -123,10 +199,13 @@ func (udp *UDP) send(buf []byte, addr net.Addr) error {
 }
 
 func (udp *UDP) Send(send IO) error {
+	var bufp = udp.pool.Get().(*[]byte)
+	defer udp.pool.Put(bufp)
+
 	if err := send.Packet.PackPDU(send.PDUMeta, send.PDU); err != nil {
-		return ProtocolError{fmt.Errorf("packet.PackPDU: %v", err)}
-	} else if buf, err := send.Packet.Marshal(); err != nil {
-		return ProtocolError{fmt.Errorf("packet.Marshal: %v", err)}
+		return ProtocolError{err: fmt.Errorf("packet.PackPDU: %w", err)}
+	} else if buf, err := send.Packet.MarshalAppend((*bufp)[:0]); err != nil {
+		return ProtocolError{err: fmt.Errorf("packet.Marshal: %w", err)}
 	} else if err := udp.send(buf, send.Addr); err != nil {
 		return err
 	}
@@ -134,27 +213,29 @@ func (udp *UDP) Send(send IO) error {
 	return nil
 }
 
+// The packet is received into a pooled buffer, and copied out before decoding, as the decoded packet references
+// the buffer.
 func (udp *UDP) Recv() (recv IO, err error) {
-	var buf = make([]byte, udp.size)
+	var bufp = udp.pool.Get().(*[]byte)
+	var buf []byte
+
+	defer udp.pool.Put(bufp)
 
 	// recv
-	if size, _, flags, addr, err := udp.conn.ReadMsgUDP(buf, nil); err != nil {
+	if size, _, flags, addr, err := udp.conn.ReadMsgUDP(*bufp, nil); err != nil {
 		return recv, err
 	} else if size == 0 {
 		return recv, io.EOF
 	} else if flags&syscall.MSG_TRUNC != 0 {
-		return recv, ProtocolError{fmt.Errorf("Packet truncated (>%d bytes)", udp.size)}
+		return recv, udp.reject(addr, append([]byte(nil), (*bufp)[:size]...), fmt.Errorf("Packet truncated (>%d bytes)", udp.size))
 	} else {
 		recv.Addr = addr
-		buf = buf[:size]
+		buf = append([]byte(nil), (*bufp)[:size]...)
+		recv.Bytes = buf
 	}
 
-	if err := recv.Packet.Unmarshal(buf); err != nil {
-		return recv, ProtocolError{fmt.Errorf("packet.Unmarshal: %v", err)}
-	}
-
-	if pduMeta, pdu, err := recv.Packet.UnpackPDU(); err != nil {
-		return recv, ProtocolError{fmt.Errorf("packet.UnpackPDU: %v", err)}
+	if pduMeta, pdu, err := recv.Packet.Decode(buf); err != nil {
+		return recv, udp.reject(recv.Addr, buf, fmt.Errorf("packet.Decode: %w", err))
 	} else {
 		recv.PDUMeta = pduMeta
 		recv.PDU = pdu
@@ -163,6 +244,28 @@ func (udp *UDP) Recv() (recv IO, err error) {
 	return recv, nil
 }
 
+// Return a ProtocolError for the rejected packet, recording it to any reject log.
+func (udp *UDP) reject(addr net.Addr, buf []byte, err error) ProtocolError {
+	var protocolErr = ProtocolError{err: err, Addr: addr, Bytes: buf}
+
+	if udp.rejectLog != nil {
+		var record = fmt.Sprintf("%v %v: %v\n%v\n", time.Now().Format(time.RFC3339), addr, err, hex.Dump(buf))
+
+		// single write, the file is opened for appending
+		if _, err := udp.rejectLog.WriteString(record); err != nil {
+			log.Warnf("UDP<%v>: write reject log: %v", udp, err)
+		}
+	}
+
+	return protocolErr
+}
+
 func (udp *UDP) Close() error {
+	if udp.rejectLog != nil {
+		if err := udp.rejectLog.Close(); err != nil {
+			log.Warnf("UDP<%v>: close reject log: %v", udp, err)
+		}
+	}
+
 	return udp.conn.Close()
 }
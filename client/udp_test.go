@@ -1,9 +1,15 @@
 package client
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"net"
+	"path/filepath"
+	"testing"
 )
 
 func makeTestServer() *testServer {
@@ -101,3 +107,102 @@ func (testServer *testServer) run() {
 func (testServer *testServer) stop() {
 	testServer.udp.conn.Close()
 }
+
+func TestUDPRecvReject(t *testing.T) {
+	var rejectLog = filepath.Join(t.TempDir(), "reject.log")
+	var packet = []byte{0x30, 0x80, 0x02, 0x01, 0x01}
+
+	udp, err := ListenUDP("127.0.0.1:0", UDPOptions{RejectLog: rejectLog})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer udp.Close()
+
+	if udpAddr, err := udp.LocalAddr(); err != nil {
+		t.Fatalf("LocalAddr: %v", err)
+	} else if conn, err := net.DialUDP("udp", nil, udpAddr); err != nil {
+		t.Fatalf("DialUDP: %v", err)
+	} else if _, err := conn.Write(packet); err != nil {
+		t.Fatalf("Write: %v", err)
+	} else {
+		defer conn.Close()
+	}
+
+	_, err = udp.Recv()
+
+	var protocolErr ProtocolError
+	var decodeErr snmp.DecodeError
+
+	if !errors.As(err, &protocolErr) {
+		t.Fatalf("Recv: expected ProtocolError, got %#v", err)
+	} else if !errors.As(err, &decodeErr) {
+		t.Fatalf("Recv: expected DecodeError, got %#v", err)
+	}
+
+	assert.Equal(t, packet, protocolErr.Bytes)
+	assert.Equal(t, 0, decodeErr.Offset)
+	assert.True(t, errors.Is(err, snmp.ErrIndefiniteLength))
+
+	if buf, err := ioutil.ReadFile(rejectLog); err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	} else {
+		assert.Contains(t, string(buf), "packet.Decode: Invalid packet at offset 0: indefinite length\n")
+		assert.Contains(t, string(buf), hex.Dump(packet))
+	}
+}
+
+func TestUDPNetwork(t *testing.T) {
+	udp, err := NewUDP(UDPOptions{Network: "udp4", Local: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("NewUDP: %v", err)
+	}
+	defer udp.Close()
+
+	if localAddr, err := udp.LocalAddr(); assert.NoError(t, err) {
+		assert.Equal(t, "127.0.0.1", localAddr.IP.String())
+		assert.NotEqual(t, 0, localAddr.Port)
+	}
+
+	if addr, err := udp.Resolve("127.0.0.1"); assert.NoError(t, err) {
+		assert.Equal(t, "127.0.0.1:161", addr.String())
+	}
+
+	_, err = udp.ResolveNetwork("udp6", "::1")
+
+	assert.EqualError(t, err, "Network udp6 is not supported by the udp4 transport")
+}
+
+func TestUDPResolveIPv6(t *testing.T) {
+	udp, err := makeUDP(UDPOptions{})
+	if err != nil {
+		t.Fatalf("makeUDP: %v", err)
+	}
+
+	for _, addr := range []string{"2001:db8::1", "[2001:db8::1]", "[2001:db8::1]:161"} {
+		if udpAddr, err := udp.Resolve(addr); assert.NoError(t, err, "Resolve %v", addr) {
+			assert.Equal(t, "[2001:db8::1]:161", udpAddr.String())
+		}
+	}
+
+	if udpAddr, err := udp.ResolveNetwork("udp6", "2001:db8::1"); assert.NoError(t, err) {
+		assert.Equal(t, "[2001:db8::1]:161", udpAddr.String())
+	}
+}
+
+func TestUDPInvalidNetwork(t *testing.T) {
+	_, err := NewUDP(UDPOptions{Network: "tcp"})
+
+	assert.EqualError(t, err, "Invalid UDP network: tcp")
+}
+
+func TestUDPLocalIPv6(t *testing.T) {
+	udp, err := NewUDP(UDPOptions{Network: "udp6", Local: "[::1]:0"})
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	defer udp.Close()
+
+	if localAddr, err := udp.LocalAddr(); assert.NoError(t, err) {
+		assert.Equal(t, "::1", localAddr.IP.String())
+	}
+}
@@ -1,10 +1,60 @@
 package client
 
 import (
+	"errors"
+	"fmt"
 	"github.com/qmsk/snmpbot/snmp"
 )
 
-func walkScalarVars(oids []snmp.OID, varBinds []snmp.VarBind) bool {
+// Walk step returned an OID that is not lexicographically after the previous OID, e.g. an agent loop.
+var ErrNonIncreasing = errors.New("non-increasing OID")
+
+// Walk exceeded the WalkOptions MaxRows or MaxRequests.
+var ErrWalkLimit = errors.New("walk limit exceeded")
+
+type NonIncreasingError struct {
+	Previous snmp.OID
+	OID      snmp.OID
+}
+
+func (err NonIncreasingError) Error() string {
+	return fmt.Sprintf("Walk returned non-increasing OID %v after %v", err.OID, err.Previous)
+}
+
+func (err NonIncreasingError) Unwrap() error {
+	return ErrNonIncreasing
+}
+
+// Walk consistency checks for each walk step
+type walkCheck struct {
+	skipNonIncreasing bool
+	skipped           bool // any OIDs were skipped
+}
+
+func (check *walkCheck) nonIncreasing(prev snmp.OID, oid snmp.OID) error {
+	if !check.skipNonIncreasing {
+		return NonIncreasingError{Previous: prev.Copy(), OID: oid.Copy()}
+	}
+
+	check.skipped = true
+
+	return nil
+}
+
+// Skip ahead to the next sibling OID, skipping anything underneath the given OID.
+//
+// The sibling OID itself is also skipped by the following GetNext request.
+func skipOID(oid snmp.OID) snmp.OID {
+	var skip = oid.Copy()
+
+	if len(skip) > 0 {
+		skip[len(skip)-1]++
+	}
+
+	return skip
+}
+
+func walkScalarVars(oids []snmp.OID, varBinds []snmp.VarBind, check *walkCheck) (bool, error) {
 	var ok = false
 
 	for i, varBind := range varBinds {
@@ -12,18 +62,25 @@ func walkScalarVars(oids []snmp.OID, varBinds []snmp.VarBind) bool {
 
 		if errorValue := varBind.ErrorValue(); errorValue == snmp.EndOfMibViewValue {
 			// explicit SNMPv2 break
-		} else if oid.Equals(oids[i]) || oids[i].Index(oid) == nil {
-			// not making progress, or walked out of tree
+		} else if oid.Compare(oids[i]) <= 0 {
+			// not making progress
+			if err := check.nonIncreasing(oids[i], oid); err != nil {
+				return false, err
+			}
+
+			varBinds[i] = snmp.MakeVarBind(oids[i], snmp.EndOfMibViewValue)
+		} else if oids[i].Index(oid) == nil {
+			// walked out of tree
 			varBinds[i] = snmp.MakeVarBind(oids[i], snmp.EndOfMibViewValue)
 		} else {
 			ok = true
 		}
 	}
 
-	return ok
+	return ok, nil
 }
 
-func walkObjectVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.VarBind) bool {
+func walkObjectVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.VarBind, check *walkCheck) (bool, error) {
 	var ok = false
 
 	for i, varBind := range varBinds {
@@ -32,8 +89,19 @@ func walkObjectVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.Va
 
 		if errorValue := varBind.ErrorValue(); errorValue == snmp.EndOfMibViewValue {
 			// explicit SNMPv2 break
-		} else if oid.Equals(walkOIDs[i]) || rootOID.Index(oid) == nil {
-			// not making progress, or walked out of tree
+		} else if oid.Compare(walkOIDs[i]) <= 0 {
+			// not making progress
+			if err := check.nonIncreasing(walkOIDs[i], oid); err != nil {
+				return false, err
+			} else if skip := skipOID(walkOIDs[i]); rootOID.Index(skip) == nil {
+				varBinds[i] = snmp.MakeVarBind(rootOID, snmp.EndOfMibViewValue)
+			} else {
+				varBinds[i] = snmp.MakeVarBind(skip, snmp.NoSuchInstanceValue)
+				walkOIDs[i] = skip
+				ok = true
+			}
+		} else if rootOID.Index(oid) == nil {
+			// walked out of tree
 			varBinds[i] = snmp.MakeVarBind(rootOID, snmp.EndOfMibViewValue)
 		} else {
 			walkOIDs[i] = oid
@@ -41,30 +109,12 @@ func walkObjectVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.Va
 		}
 	}
 
-	return ok
-}
-
-func indexCmp(a []int, b []int) int {
-	if len(a) < len(b) {
-		return -1
-	} else if len(a) > len(b) {
-		return +1
-	}
-
-	for i, _ := range a {
-		if a[i] < b[i] {
-			return -1
-		} else if a[i] > b[i] {
-			return +1
-		}
-	}
-
-	return 0 // equal
+	return ok, nil
 }
 
-func walkEntryVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.VarBind) bool {
+func walkEntryVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.VarBind, check *walkCheck) (bool, error) {
 	var ok = false
-	var entryIndex []int
+	var entryIndex snmp.OID
 
 	// select minimum index
 	for i, varBind := range varBinds {
@@ -74,16 +124,26 @@ func walkEntryVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.Var
 		if errorValue := varBind.ErrorValue(); errorValue == snmp.EndOfMibViewValue {
 			// explicit SNMPv2 break
 			continue
-		} else if oid.Equals(walkOIDs[i]) {
+
+		} else if oid.Compare(walkOIDs[i]) <= 0 {
 			// not making progress
-			continue
+			if err := check.nonIncreasing(walkOIDs[i], oid); err != nil {
+				return false, err
+			} else if skip := skipOID(walkOIDs[i]); rootOID.Index(skip) == nil {
+				varBinds[i] = snmp.MakeVarBind(rootOID, snmp.EndOfMibViewValue)
+				continue
+			} else {
+				varBinds[i] = snmp.MakeVarBind(skip, snmp.NoSuchInstanceValue)
+				oid = skip
+			}
+		}
 
-		} else if index := rootOID.Index(oid); index == nil {
+		if index := rootOID.Index(oid); index == nil {
 			// walked out of tree
 			varBinds[i] = snmp.MakeVarBind(rootOID, snmp.EndOfMibViewValue)
 			continue
 
-		} else if entryIndex == nil || indexCmp(entryIndex, index) > 0 {
+		} else if entryIndex == nil || entryIndex.Compare(index) > 0 {
 			entryIndex = index
 		}
 
@@ -99,7 +159,7 @@ func walkEntryVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.Var
 
 		if index := rootOID.Index(oid); index == nil {
 			// error, leave as-is
-		} else if indexCmp(entryIndex, index) != 0 {
+		} else if entryIndex.Compare(index) != 0 {
 			// hole, replace with snmp.NoSuchInstanceValue
 			varBinds[i] = snmp.MakeVarBind(entryOID, snmp.NoSuchInstanceValue)
 		} else {
@@ -109,7 +169,58 @@ func walkEntryVars(rootOIDs []snmp.OID, walkOIDs []snmp.OID, varBinds []snmp.Var
 		walkOIDs[i] = entryOID
 	}
 
-	return ok
+	return ok, nil
+}
+
+// How the WalkOptions TableEntries are walked.
+type TableWalkMode int
+
+const (
+	// walk by rows, switching to columns if the first rows are sparse
+	TableWalkAuto TableWalkMode = iota
+
+	// walk all columns in lock-step, one row per walk step
+	TableWalkRows
+
+	// walk each column independently until it leaves its subtree, joining the rows by index
+	TableWalkColumns
+)
+
+func (mode TableWalkMode) String() string {
+	switch mode {
+	case TableWalkAuto:
+		return "auto"
+	case TableWalkRows:
+		return "rows"
+	case TableWalkColumns:
+		return "columns"
+	default:
+		return fmt.Sprintf("TableWalkMode(%d)", int(mode))
+	}
+}
+
+func (mode TableWalkMode) MarshalText() ([]byte, error) {
+	return []byte(mode.String()), nil
+}
+
+// Implements flag.Value
+func (mode *TableWalkMode) Set(value string) error {
+	return mode.UnmarshalText([]byte(value))
+}
+
+func (mode *TableWalkMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "", "auto":
+		*mode = TableWalkAuto
+	case "rows":
+		*mode = TableWalkRows
+	case "columns":
+		*mode = TableWalkColumns
+	default:
+		return fmt.Errorf("Invalid TableWalkMode: %v", string(text))
+	}
+
+	return nil
 }
 
 type WalkOptions struct {
@@ -121,6 +232,16 @@ type WalkOptions struct {
 
 	// table entry objects, each walk step returns objects with the same index
 	TableEntries []snmp.OID
+
+	// only used if walking TableEntries without any Objects
+	TableMode TableWalkMode
+
+	// fail with ErrWalkLimit after this many walk steps or requests, default Options.MaxWalkRows/MaxWalkRequests
+	MaxRows     uint
+	MaxRequests uint
+
+	// skip ahead past any non-increasing OIDs returned by the agent, instead of failing with ErrNonIncreasing
+	SkipNonIncreasing bool
 }
 
 type WalkFunc func(vars []snmp.VarBind) error
@@ -137,128 +258,26 @@ type WalkFunc func(vars []snmp.VarBind) error
 //
 // Splits into multiple requests if the number of OIDs exceeds options.MaxVars.
 func (client *Client) WalkWithOptions(options WalkOptions, walkFunc WalkFunc) error {
-	if client.options.NoBulk {
-		return client.walkGetNext(options, walkFunc)
-	} else {
-		return client.walkGetBulk(options, walkFunc)
-	}
+	return client.walk(client.Walker(options), walkFunc)
 }
 
-func (client *Client) walkGetNext(options WalkOptions, walkFunc WalkFunc) error {
-	var walkOIDs = make([]snmp.OID, len(options.Scalars)+len(options.Objects)+len(options.TableEntries))
-	var objectsOffset = len(options.Scalars)
-	var entriesOffset = len(options.Scalars) + len(options.Objects)
-
-	for i, oid := range options.Scalars {
-		walkOIDs[i] = oid
-	}
-	for i, oid := range options.Objects {
-		walkOIDs[objectsOffset+i] = oid
-	}
-	for i, oid := range options.TableEntries {
-		walkOIDs[entriesOffset+i] = oid
-	}
-
-	if len(walkOIDs) == 0 {
-		return nil
-	}
-
+func (client *Client) walk(walker *Walker, walkFunc WalkFunc) error {
 	for {
-		// request splitting
-		varBinds, err := client.GetNextSplit(walkOIDs)
-		if err != nil {
-			return err
-		}
-
-		if !walkScalarVars(options.Scalars, varBinds[0:len(options.Scalars)]) {
-			// no scalar vars matched !?
-		}
-
-		if len(options.Objects) > 0 && !walkObjectVars(options.Objects, walkOIDs[objectsOffset:objectsOffset+len(options.Objects)], varBinds[objectsOffset:objectsOffset+len(options.Objects)]) {
-			// did not make progress
-			return nil
-		}
-
-		if len(options.TableEntries) > 0 && !walkEntryVars(options.TableEntries, walkOIDs[entriesOffset:entriesOffset+len(options.TableEntries)], varBinds[entriesOffset:entriesOffset+len(options.TableEntries)]) {
-			// did not make progress
+		if varBinds, err := walker.Next(); err == EOF {
 			return nil
-		}
-
-		if err := walkFunc(varBinds); err != nil {
+		} else if err != nil {
 			return err
-		}
-
-		if len(options.Objects) == 0 && len(options.TableEntries) == 0 {
-			return nil
-		}
-	}
-}
-
-func (client *Client) walkGetBulk(options WalkOptions, walkFunc WalkFunc) error {
-	var walkOIDs = make([]snmp.OID, len(options.Objects)+len(options.TableEntries))
-	var entriesOffset = len(options.Objects)
-
-	for i, oid := range options.Objects {
-		walkOIDs[i] = oid
-	}
-	for i, oid := range options.TableEntries {
-		walkOIDs[entriesOffset+i] = oid
-	}
-
-	for {
-		// TODO: request splitting
-		scalarVars, entryList, err := client.GetBulk(options.Scalars, walkOIDs)
-		if err != nil {
+		} else if err := walkFunc(varBinds); err != nil {
 			return err
 		}
-
-		if !walkScalarVars(options.Scalars, scalarVars) {
-			// no scalar vars matched !?
-		}
-
-		var ok = false
-
-		for _, entryVars := range entryList {
-			if len(options.Objects) > 0 && !walkObjectVars(options.Objects, walkOIDs[0:len(options.Objects)], entryVars[0:len(options.Objects)]) {
-				// no vars made progress, ignore the remainder
-				ok = false
-				break
-			}
-
-			if len(options.TableEntries) > 0 && !walkEntryVars(options.TableEntries, walkOIDs[entriesOffset:entriesOffset+len(options.TableEntries)], entryVars[entriesOffset:entriesOffset+len(options.TableEntries)]) {
-				// no vars made progress, ignore the remainder
-				ok = false
-				break
-			}
-
-			var vars = make([]snmp.VarBind, len(options.Scalars)+len(options.Objects)+len(options.TableEntries))
-
-			for i, v := range scalarVars {
-				vars[i] = v
-			}
-			for i, v := range entryVars {
-				vars[len(scalarVars)+i] = v
-			}
-
-			if err := walkFunc(vars); err != nil {
-				return err
-			} else {
-				ok = true
-			}
-		}
-
-		if !ok {
-			return nil
-		}
 	}
 }
 
-// Perform a single GetNext walk step, returning either objects underneath given oid, or EndOfMibViewValue
+// Perform a single walk step, returning either objects underneath given oid, or EndOfMibViewValue
 func (client *Client) GetScalars(oids []snmp.OID) ([]snmp.VarBind, error) {
 	var retVars []snmp.VarBind
 
-	// walkGetBulk is useless, and doesn't support scalars-only
-	return retVars, client.walkGetNext(WalkOptions{Scalars: oids}, func(vars []snmp.VarBind) error {
+	return retVars, client.walk(client.Walker(WalkOptions{Scalars: oids}), func(vars []snmp.VarBind) error {
 		retVars = vars
 
 		return nil
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"encoding/asn1"
+	"errors"
 	"github.com/qmsk/snmpbot/snmp"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -137,6 +139,55 @@ func TestWalkV2(t *testing.T) {
 	})
 }
 
+// agents returning noSuchInstance instead of endOfMibView for the last row
+func TestWalkNoSuchEndOfMibView(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 31, 1, 1, 1, 1} // IF-MIB::ifName
+	var varBinds = []snmp.VarBind{
+		snmp.MakeVarBind(snmp.OID{1, 3, 6, 1, 2, 1, 31, 1, 1, 1, 1, 1}, []byte("if1")),
+		snmp.MakeVarBind(snmp.OID{1, 3, 6, 1, 2, 1, 31, 1, 1, 1, 1, 1}, snmp.NoSuchInstanceValue),
+	}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		transport.mockGetNext("test", oid, varBinds[0])
+		transport.mockGetNext("test", varBinds[0].OID(), varBinds[1])
+
+		client.options.NoSuchEndOfMibView = true
+
+		testWalk(t, client, walkTest{
+			options: WalkOptions{Objects: []snmp.OID{oid}},
+			results: [][]snmp.VarBind{
+				[]snmp.VarBind{varBinds[0]},
+			},
+		})
+	})
+}
+
+func TestWalkTruncateCounter64(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 31, 1, 1, 1, 6} // IF-MIB::ifHCInOctets
+	var varBind = snmp.VarBind{
+		Name: asn1.ObjectIdentifier(oid.Extend(1)),
+		RawValue: asn1.RawValue{
+			Class:     asn1.ClassApplication,
+			Tag:       int(snmp.Counter64Type),
+			FullBytes: []byte{0x46, 0x09, 0xff, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		},
+	}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		transport.mockGetNext("test", oid, varBind)
+		transport.mockGetNext("test", oid.Extend(1), snmp.MakeVarBind(oid.Extend(1), snmp.EndOfMibViewValue))
+
+		client.options.TruncateCounter64 = true
+
+		testWalk(t, client, walkTest{
+			options: WalkOptions{Objects: []snmp.OID{oid}},
+			results: [][]snmp.VarBind{
+				[]snmp.VarBind{snmp.MakeVarBind(oid.Extend(1), snmp.Counter64(1<<63+1))},
+			},
+		})
+	})
+}
+
 // test walk of missing column
 func TestWalkTablePartial(t *testing.T) {
 	var oid1 = snmp.OID{1, 3, 6, 1, 2, 1, 17, 7, 1, 2, 2, 1, 1} // Q-BRIDGE-MIB::dot1qTpFdbAddress (not-accessible)
@@ -314,10 +365,9 @@ func TestWalkBulk(t *testing.T) {
 			},
 			PDUMeta: snmp.PDUMeta{PDUType: snmp.GetBulkRequestType},
 			PDU: snmp.BulkPDU{
-				NonRepeaters:   1,
+				NonRepeaters:   0,
 				MaxRepetitions: 5,
 				VarBinds: []snmp.VarBind{
-					snmp.MakeVarBind(ifNumber, nil),
 					snmp.MakeVarBind(ifIndex.Extend(2), nil),
 					snmp.MakeVarBind(ifName.Extend(2), nil),
 				},
@@ -331,7 +381,6 @@ func TestWalkBulk(t *testing.T) {
 			PDUMeta: snmp.PDUMeta{PDUType: snmp.GetResponseType},
 			PDU: snmp.GenericPDU{
 				VarBinds: []snmp.VarBind{
-					numberVar,
 					indexVars[2],
 					nameVars[2],
 				},
@@ -366,3 +415,369 @@ func TestWalkBulkEmpty(t *testing.T) {
 		})
 	})
 }
+
+func TestWalkNonIncreasing(t *testing.T) {
+	var hrPrinterStatus = snmp.MustParseOID(".1.3.6.1.2.1.25.3.5.1.1") // HOST-RESOURCES-MIB::hrPrinterStatus
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.NoBulk = true
+
+		transport.mockGetNext("test", hrPrinterStatus, snmp.MakeVarBind(hrPrinterStatus.Extend(2), 3))
+		transport.mockGetNext("test", hrPrinterStatus.Extend(2), snmp.MakeVarBind(hrPrinterStatus.Extend(1), 3))
+
+		var results [][]snmp.VarBind
+		var err = client.WalkObjects([]snmp.OID{hrPrinterStatus}, func(vars []snmp.VarBind) error {
+			results = append(results, vars)
+
+			return nil
+		})
+
+		assert.EqualError(t, err, "Walk returned non-increasing OID .1.3.6.1.2.1.25.3.5.1.1.1 after .1.3.6.1.2.1.25.3.5.1.1.2")
+		assert.True(t, errors.Is(err, ErrNonIncreasing))
+		assert.Len(t, results, 1)
+	})
+}
+
+func TestWalkNonIncreasingSkip(t *testing.T) {
+	var hrPrinterStatus = snmp.MustParseOID(".1.3.6.1.2.1.25.3.5.1.1") // HOST-RESOURCES-MIB::hrPrinterStatus
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		transport.mockGetNext("test", hrPrinterStatus, snmp.MakeVarBind(hrPrinterStatus.Extend(2), 3))
+		transport.mockGetNext("test", hrPrinterStatus.Extend(2), snmp.MakeVarBind(hrPrinterStatus.Extend(1), 3))
+		transport.mockGetNext("test", hrPrinterStatus.Extend(3), snmp.MakeVarBind(hrPrinterStatus.Extend(4), 5))
+		transport.mockGetNext("test", hrPrinterStatus.Extend(4), snmp.MakeVarBind(snmp.MustParseOID(".1.3.6.1.2.1.25.3.5.1.2.1"), []byte{0}))
+
+		testWalk(t, client, walkTest{
+			options: WalkOptions{Objects: []snmp.OID{hrPrinterStatus}, SkipNonIncreasing: true},
+			results: [][]snmp.VarBind{
+				[]snmp.VarBind{snmp.MakeVarBind(hrPrinterStatus.Extend(2), 3)},
+				[]snmp.VarBind{snmp.MakeVarBind(hrPrinterStatus.Extend(3), snmp.NoSuchInstanceValue)},
+				[]snmp.VarBind{snmp.MakeVarBind(hrPrinterStatus.Extend(4), 5)},
+			},
+		})
+	})
+}
+
+func TestWalkTableNonIncreasing(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1")            // IF-MIB::ifName
+	var ifInMulticastPkts = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.2") // IF-MIB::ifInMulticastPkts
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.NoBulk = true
+
+		transport.mockGetNextMulti("test", []snmp.OID{ifName, ifInMulticastPkts}, []snmp.VarBind{
+			snmp.MakeVarBind(ifName.Extend(1), []byte("if1")),
+			snmp.MakeVarBind(ifInMulticastPkts.Extend(1), snmp.Counter32(0)),
+		})
+		transport.mockGetNextMulti("test", []snmp.OID{ifName.Extend(1), ifInMulticastPkts.Extend(1)}, []snmp.VarBind{
+			snmp.MakeVarBind(ifName.Extend(2), []byte("if2")),
+			snmp.MakeVarBind(ifInMulticastPkts.Extend(1), snmp.Counter32(0)),
+		})
+
+		var err = client.WalkTable([]snmp.OID{ifName, ifInMulticastPkts}, func(vars []snmp.VarBind) error {
+			return nil
+		})
+
+		assert.EqualError(t, err, "Walk returned non-increasing OID .1.3.6.1.2.1.31.1.1.1.2.1 after .1.3.6.1.2.1.31.1.1.1.2.1")
+		assert.True(t, errors.Is(err, ErrNonIncreasing))
+	})
+}
+
+func TestWalkMaxRows(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1") // IF-MIB::ifName
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.NoBulk = true
+
+		transport.mockGetNext("test", ifName, snmp.MakeVarBind(ifName.Extend(1), []byte("if1")))
+
+		var rows = 0
+		var err = client.WalkWithOptions(WalkOptions{Objects: []snmp.OID{ifName}, MaxRows: 1}, func(vars []snmp.VarBind) error {
+			rows++
+
+			return nil
+		})
+
+		assert.EqualError(t, err, "walk limit exceeded: MaxRows=1")
+		assert.True(t, errors.Is(err, ErrWalkLimit))
+		assert.Equal(t, 1, rows)
+	})
+}
+
+func TestWalkMaxRequests(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1") // IF-MIB::ifName
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.NoBulk = true
+		client.options.MaxWalkRequests = 2
+
+		transport.mockGetNext("test", ifName, snmp.MakeVarBind(ifName.Extend(1), []byte("if1")))
+		transport.mockGetNext("test", ifName.Extend(1), snmp.MakeVarBind(ifName.Extend(2), []byte("if2")))
+
+		var err = client.WalkObjects([]snmp.OID{ifName}, func(vars []snmp.VarBind) error {
+			return nil
+		})
+
+		assert.EqualError(t, err, "walk limit exceeded: MaxRequests=2")
+	})
+}
+
+func TestWalkBulkColumnGroups(t *testing.T) {
+	var ifNumber = snmp.MustParseOID(".1.3.6.1.2.1.2.1")  // IF-MIB::ifNumber
+	var ifEntry = snmp.MustParseOID(".1.3.6.1.2.1.2.2.1") // IF-MIB::ifEntry
+	var columns = []snmp.OID{ifEntry.Extend(1), ifEntry.Extend(2), ifEntry.Extend(3), ifEntry.Extend(4), ifEntry.Extend(5)}
+	var numberVar = snmp.MakeVarBind(ifNumber.Extend(0), int(2))
+	var rows [][]snmp.VarBind
+
+	for index := 1; index <= 2; index++ {
+		var row = []snmp.VarBind{numberVar}
+
+		for c, column := range columns {
+			row = append(row, snmp.MakeVarBind(column.Extend(index), int(c*10+index)))
+		}
+
+		rows = append(rows, row)
+	}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.MaxVars = 4
+
+		transport.agent = append(transport.agent, numberVar)
+		for c := range columns {
+			transport.agent = append(transport.agent, rows[0][1+c], rows[1][1+c])
+		}
+		transport.agent = append(transport.agent, snmp.MakeVarBind(ifEntry.Extend(6, 1), int(0)))
+
+		testWalk(t, client, walkTest{
+			useBulk: true,
+			options: WalkOptions{Scalars: []snmp.OID{ifNumber}, TableEntries: columns},
+			results: rows,
+		})
+
+		for i, request := range transport.agentRequests {
+			var pdu = request.PDU.(snmp.BulkPDU)
+
+			assert.Equal(t, snmp.GetBulkRequestType, request.PDUType)
+			assert.LessOrEqual(t, len(pdu.VarBinds)*pdu.MaxRepetitions, 4, "request %d MaxVars", i)
+
+			if i == 0 {
+				assert.Equal(t, 1, pdu.NonRepeaters, "scalars in first request")
+			} else {
+				assert.Equal(t, 0, pdu.NonRepeaters, "scalars fetched once")
+			}
+		}
+	})
+}
+
+// test of GetBulk for a column with sparse values
+func TestWalkBulkSparse(t *testing.T) {
+	var oid1 = snmp.MustParseOID(".1.3.6.1.2.1.2.2.1.2") // IF-MIB::ifDescr
+	var oid2 = snmp.MustParseOID(".1.3.6.1.2.1.2.2.1.4") // IF-MIB::ifMtu
+
+	var errBind = snmp.MakeVarBind(oid2.Extend(2), snmp.NoSuchInstanceValue)
+	var varBinds1 = []snmp.VarBind{
+		snmp.MakeVarBind(oid1.Extend(1), string("test1")),
+		snmp.MakeVarBind(oid1.Extend(2), string("test2")),
+		snmp.MakeVarBind(oid1.Extend(3), string("test3")),
+	}
+	var varBinds2 = []snmp.VarBind{
+		snmp.MakeVarBind(oid2.Extend(1), int(1500)),
+		snmp.MakeVarBind(oid2.Extend(3), int(1500)),
+	}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		transport.agent = append(append([]snmp.VarBind(nil), varBinds1...), varBinds2...)
+
+		testWalk(t, client, walkTest{
+			useBulk: true,
+			options: WalkOptions{TableEntries: []snmp.OID{oid1, oid2}},
+			results: [][]snmp.VarBind{
+				[]snmp.VarBind{varBinds1[0], varBinds2[0]},
+				[]snmp.VarBind{varBinds1[1], errBind},
+				[]snmp.VarBind{varBinds1[2], varBinds2[1]},
+			},
+		})
+
+		assert.Len(t, transport.agentRequests, 1)
+	})
+}
+
+func TestWalkBulkMaxRows(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1") // IF-MIB::ifName
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		for index := 1; index <= 10; index++ {
+			transport.agent = append(transport.agent, snmp.MakeVarBind(ifName.Extend(index), []byte("if")))
+		}
+
+		var rows = 0
+		var err = client.WalkWithOptions(WalkOptions{Objects: []snmp.OID{ifName}, MaxRows: 3}, func(vars []snmp.VarBind) error {
+			rows++
+
+			return nil
+		})
+
+		assert.True(t, errors.Is(err, ErrWalkLimit))
+		assert.Equal(t, 3, rows)
+		if assert.Len(t, transport.agentRequests, 1) {
+			assert.Equal(t, 3, transport.agentRequests[0].PDU.(snmp.BulkPDU).MaxRepetitions)
+		}
+	})
+}
+
+func makeTestSparseTable(rootOIDs []snmp.OID, rows int, columns ...int) ([]snmp.VarBind, [][]snmp.VarBind) {
+	var agent []snmp.VarBind
+	var results [][]snmp.VarBind
+
+	for index := 1; index <= rows; index++ {
+		results = append(results, make([]snmp.VarBind, len(rootOIDs)))
+	}
+
+	for i, rootOID := range rootOIDs {
+		for index := 1; index <= rows; index++ {
+			if index <= columns[i] {
+				var varBind = snmp.MakeVarBind(rootOID.Extend(index), int(index))
+
+				agent = append(agent, varBind)
+				results[index-1][i] = varBind
+			} else {
+				results[index-1][i] = snmp.MakeVarBind(rootOID.Extend(index), snmp.NoSuchInstanceValue)
+			}
+		}
+	}
+
+	return agent, results
+}
+
+func TestWalkTableColumns(t *testing.T) {
+	var ipNetToMediaEntry = snmp.MustParseOID(".1.3.6.1.2.1.4.22.1") // IP-MIB::ipNetToMediaEntry
+	var rootOIDs = []snmp.OID{ipNetToMediaEntry.Extend(1), ipNetToMediaEntry.Extend(2), ipNetToMediaEntry.Extend(3)}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		var agent, results = makeTestSparseTable(rootOIDs, 5, 5, 1, 0)
+
+		transport.agent = agent
+
+		testWalk(t, client, walkTest{
+			options: WalkOptions{TableEntries: rootOIDs, TableMode: TableWalkColumns},
+			results: results,
+		})
+
+		var requestVars []int
+
+		for _, request := range transport.agentRequests {
+			requestVars = append(requestVars, len(request.PDU.(snmp.GenericPDU).VarBinds))
+		}
+
+		assert.Equal(t, []int{3, 2, 1, 1, 1, 1}, requestVars, "ended columns are not requested")
+	})
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		var agent, results = makeTestSparseTable(rootOIDs, 5, 5, 1, 0)
+
+		transport.agent = agent
+
+		testWalk(t, client, walkTest{
+			useBulk: true,
+			options: WalkOptions{TableEntries: rootOIDs, TableMode: TableWalkColumns},
+			results: results,
+		})
+
+		assert.Len(t, transport.agentRequests, 1)
+	})
+}
+
+func TestWalkTableColumnsResume(t *testing.T) {
+	var ipNetToMediaEntry = snmp.MustParseOID(".1.3.6.1.2.1.4.22.1") // IP-MIB::ipNetToMediaEntry
+	var rootOIDs = []snmp.OID{ipNetToMediaEntry.Extend(1), ipNetToMediaEntry.Extend(2)}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		var agent, results = makeTestSparseTable(rootOIDs, 4, 4, 2)
+
+		transport.agent = agent
+
+		var walker = client.Walker(WalkOptions{TableEntries: rootOIDs, TableMode: TableWalkColumns, MaxRows: 2})
+
+		for _, result := range results[:2] {
+			varBinds, err := walker.Next()
+
+			assert.NoError(t, err)
+			assert.Equal(t, result, varBinds)
+		}
+
+		var resumed = client.Walker(WalkOptions{TableEntries: rootOIDs, TableMode: TableWalkColumns})
+
+		assert.NoError(t, resumed.Resume(walker.Cursor()))
+
+		for _, result := range results[2:] {
+			varBinds, err := resumed.Next()
+
+			assert.NoError(t, err)
+			assert.Equal(t, result, varBinds)
+		}
+
+		_, err := resumed.Next()
+
+		assert.Equal(t, EOF, err)
+	})
+}
+
+func TestWalkTableAuto(t *testing.T) {
+	var ifEntry = snmp.MustParseOID(".1.3.6.1.2.1.2.2.1") // IF-MIB::ifEntry
+	var rootOIDs = []snmp.OID{ifEntry.Extend(1), ifEntry.Extend(2), ifEntry.Extend(3), ifEntry.Extend(4)}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		var agent, results = makeTestSparseTable(rootOIDs, 20, 20, 0, 0, 0)
+
+		transport.agent = agent
+
+		testWalk(t, client, walkTest{
+			options: WalkOptions{TableEntries: rootOIDs},
+			results: results,
+		})
+
+		// lock-step for the sampled rows, then only the remaining column
+		assert.Len(t, transport.agentRequests, sparseSampleRows+1+20-sparseSampleRows)
+
+		for i, request := range transport.agentRequests[sparseSampleRows+1:] {
+			assert.Len(t, request.PDU.(snmp.GenericPDU).VarBinds, 1, "request %d", sparseSampleRows+1+i)
+		}
+	})
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		var agent, results = makeTestSparseTable(rootOIDs, 20, 20, 0, 0, 0)
+
+		transport.agent = agent
+
+		testWalk(t, client, walkTest{
+			options: WalkOptions{TableEntries: rootOIDs, TableMode: TableWalkRows},
+			results: results,
+		})
+
+		assert.Len(t, transport.agentRequests, 21)
+	})
+}
+
+func TestTableWalkModeText(t *testing.T) {
+	for _, mode := range []TableWalkMode{TableWalkAuto, TableWalkRows, TableWalkColumns} {
+		var parsed TableWalkMode
+
+		text, _ := mode.MarshalText()
+
+		assert.NoError(t, parsed.UnmarshalText(text))
+		assert.Equal(t, mode, parsed)
+	}
+
+	var parsed TableWalkMode
+
+	assert.EqualError(t, parsed.UnmarshalText([]byte("test")), "Invalid TableWalkMode: test")
+}
+
+func TestWalkTableModeOption(t *testing.T) {
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.TableWalkMode = TableWalkColumns
+
+		assert.True(t, client.Walker(WalkOptions{TableEntries: []snmp.OID{snmp.OID{1, 3, 6, 1}}}).columnWise)
+		assert.False(t, client.Walker(WalkOptions{TableEntries: []snmp.OID{snmp.OID{1, 3, 6, 1}}, TableMode: TableWalkRows}).columnWise)
+	})
+}
@@ -0,0 +1,608 @@
+package client
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+	"strings"
+)
+
+// Position of a Walker after the last returned walk step: the walked Objects and TableEntries OIDs, in order.
+type WalkCursor []snmp.OID
+
+func (cursor WalkCursor) String() string {
+	var strs = make([]string, len(cursor))
+
+	for i, oid := range cursor {
+		strs[i] = oid.String()
+	}
+
+	return strings.Join(strs, ",")
+}
+
+// Parse a comma-separated WalkCursor, as returned by WalkCursor.String().
+func ParseWalkCursor(str string) (WalkCursor, error) {
+	var cursor WalkCursor
+
+	for _, s := range strings.Split(str, ",") {
+		if oid, err := snmp.ParseOID(s); err != nil {
+			return nil, fmt.Errorf("Invalid cursor OID %v: %v", s, err)
+		} else {
+			cursor = append(cursor, oid)
+		}
+	}
+
+	return cursor, nil
+}
+
+// Pull-based walk, with the same semantics as WalkWithOptions.
+//
+// Any GetBulk responses are buffered per object and table column, and returned by Next() one walk step at a time.
+// Using GetBulk, the scalars are only fetched once, and wide tables are split into groups of columns.
+//
+// Sparse tables are walked column-wise, see TableWalkMode.
+type Walker struct {
+	client  *Client
+	options WalkOptions
+	bulk    bool
+
+	walkOIDs   []snmp.OID       // scalars, objects, entries
+	scalarVars []snmp.VarBind   // bulk or column-wise scalars, fetched once
+	columns    [][]snmp.VarBind // bulk or column-wise objects and entries, buffered per column
+	ended      []bool           // bulk or column-wise columns walked out of tree
+	done       bool
+
+	columnWise bool
+	columnOIDs []snmp.OID // column-wise position of each column, following the buffered VarBinds
+	holes      uint       // entry VarBinds without any value, for TableWalkAuto
+
+	check    walkCheck
+	rows     uint
+	requests uint
+}
+
+// Start a new walk from the root OIDs.
+func (client *Client) Walker(options WalkOptions) *Walker {
+	return client.walker(options, !client.options.NoBulk)
+}
+
+func (client *Client) walker(options WalkOptions, bulk bool) *Walker {
+	if options.MaxRows == 0 {
+		options.MaxRows = client.options.MaxWalkRows
+	}
+	if options.MaxRequests == 0 {
+		options.MaxRequests = client.options.MaxWalkRequests
+	}
+	if client.options.SkipNonIncreasing {
+		options.SkipNonIncreasing = true
+	}
+	if options.TableMode == TableWalkAuto {
+		options.TableMode = client.options.TableWalkMode
+	}
+
+	var walker = Walker{
+		client:  client,
+		options: options,
+		bulk:    bulk,
+		check:   walkCheck{skipNonIncreasing: options.SkipNonIncreasing},
+	}
+
+	walker.columns = make([][]snmp.VarBind, len(options.Objects)+len(options.TableEntries))
+	walker.ended = make([]bool, len(walker.columns))
+
+	walker.walkOIDs = append(walker.walkOIDs, options.Scalars...)
+	walker.walkOIDs = append(walker.walkOIDs, options.Objects...)
+	walker.walkOIDs = append(walker.walkOIDs, options.TableEntries...)
+
+	if options.TableMode == TableWalkColumns && walker.tableOnly() {
+		walker.columnWise = true
+	}
+
+	if len(walker.walkOIDs) == 0 {
+		walker.done = true
+	}
+
+	return &walker
+}
+
+// TableWalkMode only applies to walks of table entries without any objects.
+func (walker *Walker) tableOnly() bool {
+	return len(walker.options.Objects) == 0 && len(walker.options.TableEntries) > 0
+}
+
+func (walker *Walker) objectsOffset() int {
+	return len(walker.options.Scalars)
+}
+
+func (walker *Walker) entriesOffset() int {
+	return len(walker.options.Scalars) + len(walker.options.Objects)
+}
+
+// Returns the position after the last walk step returned by Next().
+func (walker *Walker) Cursor() WalkCursor {
+	var cursor = make(WalkCursor, len(walker.walkOIDs)-walker.objectsOffset())
+
+	for i, oid := range walker.walkOIDs[walker.objectsOffset():] {
+		cursor[i] = oid.Copy()
+	}
+
+	return cursor
+}
+
+// Continue the walk from a Cursor() returned by an earlier Walker with the same WalkOptions.
+func (walker *Walker) Resume(cursor WalkCursor) error {
+	var rootOIDs = append(append([]snmp.OID(nil), walker.options.Objects...), walker.options.TableEntries...)
+
+	if len(cursor) != len(rootOIDs) {
+		return fmt.Errorf("Invalid cursor for %d objects: %d OIDs", len(rootOIDs), len(cursor))
+	}
+
+	for i, oid := range cursor {
+		if rootOIDs[i].Index(oid) == nil {
+			return fmt.Errorf("Invalid cursor OID %v: not within %v", oid, rootOIDs[i])
+		}
+	}
+
+	copy(walker.walkOIDs[walker.objectsOffset():], cursor)
+
+	walker.scalarVars = nil
+	walker.columns = make([][]snmp.VarBind, len(rootOIDs))
+	walker.ended = make([]bool, len(rootOIDs))
+	walker.columnOIDs = nil
+	walker.done = len(walker.walkOIDs) == 0
+
+	return nil
+}
+
+// Apply the walk step to the objects and entries, returning false if the walk did not make progress.
+//
+// Returns a NonIncreasingError unless skipping.
+func (walker *Walker) step(varBinds []snmp.VarBind) (bool, error) {
+	var objects, entries = walker.options.Objects, walker.options.TableEntries
+	var objectsOffset, entriesOffset = walker.objectsOffset(), walker.entriesOffset()
+
+	if len(objects) > 0 {
+		if ok, err := walkObjectVars(objects, walker.walkOIDs[objectsOffset:entriesOffset], varBinds[objectsOffset:entriesOffset], &walker.check); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if len(entries) > 0 {
+		if ok, err := walkEntryVars(entries, walker.walkOIDs[entriesOffset:], varBinds[entriesOffset:], &walker.check); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Count the requests for the next walk step, failing with ErrWalkLimit if exceeding MaxRequests.
+func (walker *Walker) request(count uint) error {
+	if walker.options.MaxRequests > 0 && walker.requests+count > walker.options.MaxRequests {
+		walker.done = true
+
+		return fmt.Errorf("%w: MaxRequests=%d", ErrWalkLimit, walker.options.MaxRequests)
+	}
+
+	walker.requests += count
+
+	return nil
+}
+
+// Return the VarBinds for the next walk step, or EOF once the walk is complete.
+//
+// Returns a NonIncreasingError if the agent returns OIDs out of order, unless using SkipNonIncreasing.
+func (walker *Walker) Next() ([]snmp.VarBind, error) {
+	var varBinds []snmp.VarBind
+	var err error
+
+	if walker.done {
+		return nil, EOF
+	} else if walker.options.MaxRows > 0 && walker.rows >= walker.options.MaxRows {
+		walker.done = true
+
+		return nil, fmt.Errorf("%w: MaxRows=%d", ErrWalkLimit, walker.options.MaxRows)
+	} else if walker.columnWise {
+		varBinds, err = walker.nextColumns()
+	} else if walker.bulk {
+		varBinds, err = walker.nextBulk()
+	} else {
+		varBinds, err = walker.nextGetNext()
+	}
+
+	if err == nil {
+		walker.rows++
+		walker.observeSparse(varBinds)
+	} else if err != EOF {
+		walker.done = true
+	}
+
+	return varBinds, err
+}
+
+func (walker *Walker) nextGetNext() ([]snmp.VarBind, error) {
+	if err := walker.request(walker.client.splitCount(len(walker.walkOIDs))); err != nil {
+		return nil, err
+	}
+
+	// request splitting
+	varBinds, err := walker.client.GetNextSplit(walker.walkOIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, err := walkScalarVars(walker.options.Scalars, varBinds[0:walker.objectsOffset()], &walker.check); err != nil {
+		return nil, err
+	} else if !ok {
+		// no scalar vars matched !?
+	}
+
+	if ok, err := walker.step(varBinds); err != nil {
+		return nil, err
+	} else if !ok {
+		// did not make progress
+		walker.done = true
+
+		return nil, EOF
+	}
+
+	if len(walker.options.Objects) == 0 && len(walker.options.TableEntries) == 0 {
+		walker.done = true
+	}
+
+	return varBinds, nil
+}
+
+func (walker *Walker) rootOIDs() []snmp.OID {
+	return append(append([]snmp.OID(nil), walker.options.Objects...), walker.options.TableEntries...)
+}
+
+// Repetitions for the remaining MaxVars, limited to the remaining MaxRows.
+func (walker *Walker) bulkRepetitions(scalarsLen int, entriesLen int) uint {
+	var repetitions = walker.client.getBulkMaxRepetitions(uint(scalarsLen), uint(entriesLen))
+
+	if walker.options.MaxRows > 0 && walker.options.MaxRows-walker.rows < repetitions {
+		repetitions = walker.options.MaxRows - walker.rows
+	}
+
+	return repetitions
+}
+
+// Fetch the scalars once, and any columns without any buffered VarBinds, using GetBulk requests of at most MaxVars.
+func (walker *Walker) fillBulk() error {
+	var maxVars = int(walker.client.maxVars())
+	var offset = walker.objectsOffset()
+	var scalars []snmp.OID
+	var fill []int
+
+	if walker.scalarVars == nil && len(walker.options.Scalars) >= maxVars {
+		// scalars do not fit into a single GetBulk request
+		if err := walker.request(walker.client.splitCount(len(walker.options.Scalars))); err != nil {
+			return err
+		} else if scalarVars, err := walker.client.GetNextSplit(walker.options.Scalars); err != nil {
+			return err
+		} else if _, err := walkScalarVars(walker.options.Scalars, scalarVars, &walker.check); err != nil {
+			return err
+		} else {
+			walker.scalarVars = scalarVars
+		}
+	} else if walker.scalarVars == nil {
+		scalars = walker.options.Scalars
+	}
+
+	for i, column := range walker.columns {
+		if !walker.ended[i] && len(column) == 0 {
+			fill = append(fill, i)
+		}
+	}
+
+	for len(scalars) > 0 || len(fill) > 0 {
+		var group = fill
+		var oids []snmp.OID
+		var repetitions uint
+
+		if len(group) > maxVars-len(scalars) {
+			group = group[:maxVars-len(scalars)]
+		}
+
+		fill = fill[len(group):]
+
+		for _, i := range group {
+			oids = append(oids, walker.walkOIDs[offset+i])
+		}
+
+		if len(group) > 0 {
+			repetitions = walker.bulkRepetitions(len(scalars), len(group))
+		}
+
+		if err := walker.request(1); err != nil {
+			return err
+		}
+
+		scalarVars, entryList, err := walker.client.getBulk(scalars, oids, repetitions)
+		if err != nil {
+			return err
+		}
+
+		if len(scalars) > 0 {
+			if _, err := walkScalarVars(scalars, scalarVars, &walker.check); err != nil {
+				return err
+			}
+
+			walker.scalarVars = scalarVars
+			scalars = nil
+		}
+
+		if len(entryList) == 0 {
+			// empty response
+			for _, i := range group {
+				walker.ended[i] = true
+			}
+		}
+
+		for _, entryVars := range entryList {
+			for j, i := range group {
+				walker.columns[i] = append(walker.columns[i], entryVars[j])
+			}
+		}
+	}
+
+	if walker.scalarVars == nil {
+		walker.scalarVars = []snmp.VarBind{}
+	}
+
+	return nil
+}
+
+// Consume the buffered column VarBinds used for the walk step.
+//
+// Any table entry column VarBinds following a hole are kept for the following walk step.
+func (walker *Walker) consumeBulk(rootOIDs []snmp.OID, prevOIDs []snmp.OID, heads []snmp.VarBind) {
+	var offset = walker.objectsOffset()
+	var entriesOffset = len(walker.options.Objects)
+
+	for i, head := range heads {
+		var rootOID = rootOIDs[i]
+		var oid = head.OID()
+
+		if walker.ended[i] {
+			continue
+		} else if head.ErrorValue() == snmp.EndOfMibViewValue || rootOID.Index(oid) == nil {
+			walker.ended[i] = true
+		} else if oid.Compare(prevOIDs[i]) <= 0 && rootOID.Index(skipOID(prevOIDs[i])) == nil {
+			// skipped out of tree
+			walker.ended[i] = true
+		} else if i >= entriesOffset && oid.Compare(walker.walkOIDs[offset+i]) > 0 {
+			// table hole, keep for the following entry
+			continue
+		}
+
+		walker.columns[i] = walker.columns[i][1:]
+	}
+
+	if walker.check.skipped {
+		// the buffered VarBinds follow the skipped OIDs, continue with new requests
+		for i := range walker.columns {
+			walker.columns[i] = nil
+		}
+	}
+}
+
+func (walker *Walker) nextBulk() ([]snmp.VarBind, error) {
+	if err := walker.fillBulk(); err != nil {
+		return nil, err
+	}
+
+	var offset = walker.objectsOffset()
+	var rootOIDs = walker.rootOIDs()
+	var prevOIDs = append([]snmp.OID(nil), walker.walkOIDs[offset:]...)
+	var heads = make([]snmp.VarBind, len(walker.columns))
+	var vars = make([]snmp.VarBind, len(walker.walkOIDs))
+
+	for i, column := range walker.columns {
+		if walker.ended[i] || len(column) == 0 {
+			heads[i] = snmp.MakeVarBind(rootOIDs[i], snmp.EndOfMibViewValue)
+		} else {
+			heads[i] = column[0]
+		}
+	}
+
+	copy(vars, walker.scalarVars)
+	copy(vars[offset:], heads)
+
+	if len(heads) == 0 {
+		// scalars only
+		walker.done = true
+
+		return vars, nil
+	}
+
+	walker.check.skipped = false
+
+	if ok, err := walker.step(vars); err != nil {
+		return nil, err
+	} else if !ok {
+		// no vars made progress
+		walker.done = true
+
+		return nil, EOF
+	}
+
+	walker.consumeBulk(rootOIDs, prevOIDs, heads)
+
+	return vars, nil
+}
+
+// Number of rows and ratio of entry VarBinds without any value for TableWalkAuto to switch to column-wise walking.
+const (
+	sparseSampleRows  = 10
+	sparseSampleRatio = 0.5
+)
+
+// Switch to column-wise walking once the sampled rows of a TableWalkAuto walk are sparse.
+func (walker *Walker) observeSparse(varBinds []snmp.VarBind) {
+	if walker.columnWise || walker.options.TableMode != TableWalkAuto || !walker.tableOnly() || walker.rows > sparseSampleRows {
+		return
+	}
+
+	for _, varBind := range varBinds[walker.entriesOffset():] {
+		if varBind.ErrorValue() != nil {
+			walker.holes++
+		}
+	}
+
+	if walker.rows < sparseSampleRows {
+		return
+	} else if float64(walker.holes) < sparseSampleRatio*float64(walker.rows)*float64(len(walker.options.TableEntries)) {
+		return
+	}
+
+	walker.client.log.Debugf("Walk sparse table with %d/%d holes, continuing column-wise", walker.holes, walker.rows*uint(len(walker.options.TableEntries)))
+
+	walker.columnWise = true
+	walker.columns = make([][]snmp.VarBind, len(walker.options.TableEntries))
+	walker.ended = make([]bool, len(walker.options.TableEntries))
+	walker.columnOIDs = nil
+}
+
+// Fetch the scalars once, and the remaining VarBinds for each column, until the columns leave their subtree.
+//
+// With MaxRows, each column is only filled up to the remaining rows.
+func (walker *Walker) fillColumns() error {
+	var offset = walker.entriesOffset()
+	var rootOIDs = walker.options.TableEntries
+	var maxVars = int(walker.client.maxVars())
+
+	if walker.scalarVars != nil {
+
+	} else if len(walker.options.Scalars) == 0 {
+		walker.scalarVars = []snmp.VarBind{}
+	} else if err := walker.request(walker.client.splitCount(len(walker.options.Scalars))); err != nil {
+		return err
+	} else if scalarVars, err := walker.client.GetNextSplit(walker.options.Scalars); err != nil {
+		return err
+	} else if _, err := walkScalarVars(walker.options.Scalars, scalarVars, &walker.check); err != nil {
+		return err
+	} else {
+		walker.scalarVars = scalarVars
+	}
+
+	if walker.columnOIDs == nil {
+		walker.columnOIDs = append([]snmp.OID(nil), walker.walkOIDs[offset:]...)
+	}
+
+	for {
+		var fill []int
+		var oids []snmp.OID
+		var entryList [][]snmp.VarBind
+
+		for i, column := range walker.columns {
+			if walker.ended[i] {
+				continue
+			} else if walker.options.MaxRows > 0 && uint(len(column)) >= walker.options.MaxRows-walker.rows {
+				continue
+			} else if len(fill) < maxVars {
+				fill = append(fill, i)
+				oids = append(oids, walker.columnOIDs[i])
+			}
+		}
+
+		if len(fill) == 0 {
+			return nil
+		} else if err := walker.request(1); err != nil {
+			return err
+		} else if walker.bulk {
+			if _, bulkList, err := walker.client.getBulk(nil, oids, walker.bulkRepetitions(0, len(oids))); err != nil {
+				return err
+			} else {
+				entryList = bulkList
+			}
+		} else if varBinds, err := walker.client.GetNext(oids...); err != nil {
+			return err
+		} else {
+			entryList = [][]snmp.VarBind{varBinds}
+		}
+
+		if len(entryList) == 0 {
+			// empty response
+			for _, i := range fill {
+				walker.ended[i] = true
+			}
+		}
+
+		for _, entryVars := range entryList {
+			for j, i := range fill {
+				if err := walker.fillColumn(i, rootOIDs[i], entryVars[j]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (walker *Walker) fillColumn(i int, rootOID snmp.OID, varBind snmp.VarBind) error {
+	var prev = walker.columnOIDs[i]
+	var oid = varBind.OID()
+
+	if walker.ended[i] {
+		// ignore remaining bulk repetitions
+	} else if varBind.ErrorValue() == snmp.EndOfMibViewValue || rootOID.Index(oid) == nil {
+		walker.ended[i] = true
+	} else if oid.Compare(prev) <= 0 {
+		// not making progress
+		if err := walker.check.nonIncreasing(prev, oid); err != nil {
+			return err
+		} else if skip := skipOID(prev); rootOID.Index(skip) == nil {
+			walker.ended[i] = true
+		} else {
+			walker.columnOIDs[i] = skip
+		}
+	} else {
+		walker.columns[i] = append(walker.columns[i], varBind)
+		walker.columnOIDs[i] = oid
+	}
+
+	return nil
+}
+
+// Join the buffered column VarBinds with the minimum index, masking any holes or ended columns with NoSuchInstance VarBinds, as for TableWalkRows.
+func (walker *Walker) nextColumns() ([]snmp.VarBind, error) {
+	if err := walker.fillColumns(); err != nil {
+		return nil, err
+	}
+
+	var offset = walker.entriesOffset()
+	var rootOIDs = walker.options.TableEntries
+	var entryIndex snmp.OID
+	var vars = make([]snmp.VarBind, len(walker.walkOIDs))
+
+	for i, column := range walker.columns {
+		if len(column) == 0 {
+			continue
+		} else if index := rootOIDs[i].Index(column[0].OID()); entryIndex == nil || entryIndex.Compare(index) > 0 {
+			entryIndex = index
+		}
+	}
+
+	if entryIndex == nil {
+		walker.done = true
+
+		return nil, EOF
+	}
+
+	copy(vars, walker.scalarVars)
+
+	for i, column := range walker.columns {
+		var entryOID = rootOIDs[i].Extend(entryIndex...)
+
+		if len(column) > 0 && column[0].OID().Equals(entryOID) {
+			vars[offset+i] = column[0]
+			walker.columns[i] = column[1:]
+		} else {
+			vars[offset+i] = snmp.MakeVarBind(entryOID, snmp.NoSuchInstanceValue)
+		}
+
+		walker.walkOIDs[offset+i] = entryOID
+	}
+
+	return vars, nil
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWalkerResume(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1")            // IF-MIB::ifName
+	var ifInMulticastPkts = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.2") // IF-MIB::ifInMulticastPkts
+
+	var varBinds = []snmp.VarBind{
+		snmp.MakeVarBind(ifName.Extend(1), []byte("if1")),
+		snmp.MakeVarBind(ifName.Extend(2), []byte("if2")),
+		snmp.MakeVarBind(ifInMulticastPkts.Extend(1), snmp.Counter32(0)),
+	}
+	var options = WalkOptions{TableEntries: []snmp.OID{ifName}}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.NoBulk = true
+
+		transport.mockGetNext("test", ifName, varBinds[0])
+		transport.mockGetNext("test", ifName.Extend(1), varBinds[1])
+		transport.mockGetNext("test", ifName.Extend(2), varBinds[2])
+
+		var walker = client.Walker(options)
+
+		if vars, err := walker.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		} else {
+			assert.Equal(t, []snmp.VarBind{varBinds[0]}, vars)
+		}
+
+		var cursor = walker.Cursor()
+
+		assert.Equal(t, WalkCursor{ifName.Extend(1)}, cursor)
+		assert.Equal(t, ".1.3.6.1.2.1.31.1.1.1.1.1", cursor.String())
+
+		// continue using a new walker
+		walker = client.Walker(options)
+
+		if err := walker.Resume(cursor); err != nil {
+			t.Fatalf("Resume: %v", err)
+		}
+
+		if vars, err := walker.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		} else {
+			assert.Equal(t, []snmp.VarBind{varBinds[1]}, vars)
+		}
+
+		_, err := walker.Next()
+
+		assert.Equal(t, EOF, err)
+
+		_, err = walker.Next()
+
+		assert.Equal(t, EOF, err)
+	})
+}
+
+func TestWalkerResumeInvalid(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1") // IF-MIB::ifName
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		var walker = client.Walker(WalkOptions{TableEntries: []snmp.OID{ifName}})
+
+		assert.EqualError(t, walker.Resume(WalkCursor{}), "Invalid cursor for 1 objects: 0 OIDs")
+		assert.EqualError(t, walker.Resume(WalkCursor{snmp.OID{1, 3, 6}}), "Invalid cursor OID .1.3.6: not within .1.3.6.1.2.1.31.1.1.1.1")
+	})
+}
+
+func TestWalkerBulkCursor(t *testing.T) {
+	var ifName = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.1")            // IF-MIB::ifName
+	var ifInMulticastPkts = snmp.MustParseOID(".1.3.6.1.2.1.31.1.1.1.2") // IF-MIB::ifInMulticastPkts
+
+	var varBinds = []snmp.VarBind{
+		snmp.MakeVarBind(ifName.Extend(1), []byte("if1")),
+		snmp.MakeVarBind(ifName.Extend(2), []byte("if2")),
+		snmp.MakeVarBind(ifInMulticastPkts.Extend(1), snmp.Counter32(0)),
+	}
+
+	withTestClient(t, "test", func(transport *testTransport, client *Client) {
+		client.options.MaxRepetitions = 3
+
+		transport.On("GetBulkRequest", IO{
+			Addr: testAddr("test"),
+			Packet: snmp.Packet{
+				Version:   snmp.SNMPv2c,
+				Community: []byte("public"),
+			},
+			PDUMeta: snmp.PDUMeta{PDUType: snmp.GetBulkRequestType},
+			PDU: snmp.BulkPDU{
+				NonRepeaters:   0,
+				MaxRepetitions: 3,
+				VarBinds: []snmp.VarBind{
+					snmp.MakeVarBind(ifName, nil),
+				},
+			},
+		}).Return(error(nil), IO{
+			Addr: testAddr("test"),
+			Packet: snmp.Packet{
+				Version:   snmp.SNMPv2c,
+				Community: []byte("public"),
+			},
+			PDUMeta: snmp.PDUMeta{PDUType: snmp.GetResponseType},
+			PDU: snmp.GenericPDU{
+				VarBinds: varBinds,
+			},
+		})
+
+		var walker = client.Walker(WalkOptions{TableEntries: []snmp.OID{ifName}})
+
+		if vars, err := walker.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		} else {
+			assert.Equal(t, []snmp.VarBind{varBinds[0]}, vars)
+		}
+
+		// the buffered entries are not included in the cursor
+		assert.Equal(t, WalkCursor{ifName.Extend(1)}, walker.Cursor())
+
+		if vars, err := walker.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		} else {
+			assert.Equal(t, []snmp.VarBind{varBinds[1]}, vars)
+		}
+
+		assert.Equal(t, WalkCursor{ifName.Extend(2)}, walker.Cursor())
+
+		_, err := walker.Next()
+
+		assert.Equal(t, EOF, err)
+	})
+}
+
+func TestParseWalkCursor(t *testing.T) {
+	cursor, err := ParseWalkCursor(".1.3.6.1,.1.3.6.2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, WalkCursor{snmp.OID{1, 3, 6, 1}, snmp.OID{1, 3, 6, 2}}, cursor)
+
+	_, err = ParseWalkCursor(".1.3.x")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/mibs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Bash programmable completion of MIB names, using:
+//
+//	complete -C snmpget snmpget
+//
+// Bash runs the command with $COMP_LINE set, and the word being completed as the second argument.
+// The MIBs are loaded using the default -snmp-mibs=$SNMPBOT_MIBS.
+func (options *Options) completeMain() bool {
+	var line = os.Getenv("COMP_LINE")
+
+	if line == "" || len(os.Args) < 3 {
+		return false
+	}
+
+	if point, err := strconv.Atoi(os.Getenv("COMP_POINT")); err == nil && point <= len(line) {
+		line = line[:point]
+	}
+
+	if err := options.MIBs.LoadMIBs(); err != nil {
+		return true
+	}
+
+	for _, completion := range CompleteWord(line, os.Args[2]) {
+		fmt.Println(completion)
+	}
+
+	return true
+}
+
+// Complete MIB names for the given word.
+//
+// Returns MIB:: names for qualified words, and both MIB names and unqualified names otherwise.
+func CompleteID(word string) []string {
+	var completions []string
+	var seen = make(map[string]bool)
+	var qualified = strings.Contains(word, "::")
+
+	for _, result := range mibs.Search(word) {
+		var completion string
+
+		if result.Match > mibs.SearchPrefix {
+			break
+		} else if qualified {
+			completion = result.ID.String()
+		} else if result.ID.IsMIB() {
+			completion = result.ID.MIB.Name + "::"
+		} else {
+			completion = result.ID.Name
+		}
+
+		if !seen[completion] {
+			completions = append(completions, completion)
+			seen[completion] = true
+		}
+	}
+
+	return completions
+}
+
+// Complete the word at the end of the command line.
+//
+// Bash splits the words in the command line at any : characters, so the word being completed may only be the
+// trailing part of the MIB::name in the command line. The returned completions are trimmed to match.
+func CompleteWord(line string, word string) []string {
+	var full = line
+
+	if i := strings.LastIndexAny(line, " \t"); i >= 0 {
+		full = line[i+1:]
+	}
+
+	if !strings.HasSuffix(full, word) || strings.HasPrefix(full, "-") {
+		return nil
+	}
+
+	var prefix = full[:len(full)-len(word)]
+	var completions []string
+
+	for _, completion := range CompleteID(full) {
+		if len(completion) < len(prefix) || !strings.EqualFold(completion[:len(prefix)], prefix) {
+			continue
+		}
+
+		completions = append(completions, completion[len(prefix):])
+	}
+
+	return completions
+}
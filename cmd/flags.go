@@ -48,7 +48,7 @@ func (options *Options) Parse() []string {
 }
 
 func (options Options) ClientEngine() (*client.Engine, error) {
-	return client.NewUDPEngine(options.Client.UDP)
+	return options.Client.Engine()
 }
 
 func (options Options) ClientConfig(url string) (client.Config, error) {
@@ -149,6 +149,10 @@ func (options Options) WithClientID(args []string, f func(mibs.Client, mibs.ID)
 }
 
 func (options *Options) Main(f func(args []string) error) {
+	if options.completeMain() {
+		os.Exit(0)
+	}
+
 	args := options.Parse()
 
 	if err := options.MIBs.LoadMIBs(); err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/qmsk/snmpbot/mibs"
 	_ "github.com/qmsk/snmpbot/mibs/bridge_mib"
+	_ "github.com/qmsk/snmpbot/mibs/bundle"
 	"github.com/qmsk/snmpbot/snmp"
 	"log"
 )
@@ -48,12 +48,39 @@ func init() {
 	options.InitFlags()
 }
 
-func run(serverEngine server.Engine) error {
-	// XXX: this is not a good API, it just returns immediately if there is no -http-listen?
-	options.Web.Server(
-		options.Web.RouteAPI("/api/", server.WebAPI(serverEngine)),
-		options.Web.RouteStatic("/"),
-	)
+// Run the web server and the optional proxy, until either one fails.
+func run(serverEngine server.Engine, proxy *server.Proxy) error {
+	var webChan = make(chan error, 1)
+	var proxyChan = make(chan error, 1)
+
+	if proxy != nil {
+		defer proxy.Close()
+
+		go func() {
+			proxyChan <- proxy.Run()
+		}()
+	}
+
+	go func() {
+		// XXX: this is not a good API, it just returns immediately if there is no -http-listen?
+		webChan <- options.Web.Server(
+			options.Web.RouteAPI("/api/", server.WebAPI(serverEngine)),
+			options.Web.RouteStatic("/"),
+		)
+	}()
+
+	select {
+	case err := <-webChan:
+		if err != nil || proxy == nil {
+			return err
+		} else if err := <-proxyChan; err != nil {
+			return fmt.Errorf("Proxy: %v", err)
+		}
+	case err := <-proxyChan:
+		if err != nil {
+			return fmt.Errorf("Proxy: %v", err)
+		}
+	}
 
 	return nil
 }
@@ -67,8 +94,10 @@ func main() {
 				return fmt.Errorf("Failed to load server config: %v", err)
 			} else if serverEngine, err := options.Server.Engine(engine, config); err != nil {
 				return fmt.Errorf("Failed to load server: %v", err)
+			} else if proxy, err := options.Server.Proxy(serverEngine, config); err != nil {
+				return fmt.Errorf("Failed to start proxy: %v", err)
 			} else {
-				return run(serverEngine)
+				return run(serverEngine, proxy)
 			}
 		})
 	})
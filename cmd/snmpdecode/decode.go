@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
+	"net"
+	"sort"
+	"time"
+)
+
+// A single decoded SNMP packet.
+type Message struct {
+	Time           time.Time
+	Src            string
+	Dst            string
+	Version        string `json:",omitempty"`
+	Community      string `json:",omitempty"`
+	PDUType        string `json:",omitempty"`
+	RequestID      int
+	ErrorStatus    string    `json:",omitempty"`
+	ErrorIndex     int       `json:",omitempty"`
+	NonRepeaters   int       `json:",omitempty"`
+	MaxRepetitions int       `json:",omitempty"`
+	Enterprise     string    `json:",omitempty"`
+	AgentAddr      string    `json:",omitempty"`
+	GenericTrap    string    `json:",omitempty"`
+	SpecificTrap   int       `json:",omitempty"`
+	VarBinds       []VarBind `json:",omitempty"`
+	Error          string    `json:",omitempty"`
+}
+
+type VarBind struct {
+	OID   string
+	Name  string      `json:",omitempty"`
+	Value interface{} `json:",omitempty"`
+	Error string      `json:",omitempty"`
+}
+
+// A request matched with any response, or any other unmatched message.
+type Exchange struct {
+	Request     *Message      `json:",omitempty"`
+	Response    *Message      `json:",omitempty"`
+	Retransmits int           `json:",omitempty"` // number of duplicate requests before the response
+	Latency     time.Duration `json:",omitempty"` // nanoseconds from the first request to the response
+	NoResponse  bool          `json:",omitempty"` // request without any response in the capture
+}
+
+type exchangeKey struct {
+	client    string
+	agent     string
+	requestID int
+}
+
+// Decode SNMP packets from UDP datagrams, matching requests and responses by request-id.
+type Decoder struct {
+	Ports map[int]bool // UDP ports to decode, matching either the source or destination port
+
+	pending map[exchangeKey]*Exchange
+}
+
+func MakeDecoder(ports ...int) Decoder {
+	var decoder = Decoder{
+		Ports:   make(map[int]bool),
+		pending: make(map[exchangeKey]*Exchange),
+	}
+
+	for _, port := range ports {
+		decoder.Ports[port] = true
+	}
+
+	return decoder
+}
+
+func (decoder Decoder) match(datagram Datagram) bool {
+	return decoder.Ports[datagram.Src.Port] || decoder.Ports[datagram.Dst.Port]
+}
+
+// Decode the datagram, returning any completed exchange.
+//
+// Requests are held until the matching response, see Flush.
+func (decoder Decoder) Decode(datagram Datagram) *Exchange {
+	if !decoder.match(datagram) {
+		return nil
+	}
+
+	var message = decodeMessage(datagram)
+
+	switch message.pduType {
+	case snmp.GetRequestType, snmp.GetNextRequestType, snmp.SetRequestType, snmp.GetBulkRequestType, snmp.InformRequestType:
+		var key = exchangeKey{message.Src, message.Dst, message.RequestID}
+
+		if exchange := decoder.pending[key]; exchange != nil {
+			exchange.Retransmits++
+		} else {
+			decoder.pending[key] = &Exchange{Request: &message.Message}
+		}
+
+		return nil
+
+	case snmp.GetResponseType, snmp.ReportType:
+		var key = exchangeKey{message.Dst, message.Src, message.RequestID}
+
+		if exchange := decoder.pending[key]; exchange == nil {
+			return &Exchange{Response: &message.Message}
+		} else {
+			delete(decoder.pending, key)
+
+			exchange.Response = &message.Message
+			exchange.Latency = message.Time.Sub(exchange.Request.Time)
+
+			return exchange
+		}
+
+	default:
+		// traps or invalid packets
+		return &Exchange{Request: &message.Message}
+	}
+}
+
+// Return any remaining requests without any response, in capture order.
+func (decoder Decoder) Flush() []*Exchange {
+	var exchanges = make([]*Exchange, 0, len(decoder.pending))
+
+	for key, exchange := range decoder.pending {
+		exchange.NoResponse = true
+		exchanges = append(exchanges, exchange)
+
+		delete(decoder.pending, key)
+	}
+
+	sort.SliceStable(exchanges, func(i, j int) bool {
+		return exchanges[i].Request.Time.Before(exchanges[j].Request.Time)
+	})
+
+	return exchanges
+}
+
+type decodedMessage struct {
+	Message
+
+	pduType snmp.PDUType
+}
+
+func formatVersion(version snmp.Version) string {
+	switch version {
+	case snmp.SNMPv1:
+		return "SNMPv1"
+	case snmp.SNMPv2c:
+		return "SNMPv2c"
+	default:
+		return fmt.Sprintf("Version(%d)", version)
+	}
+}
+
+func decodeMessage(datagram Datagram) decodedMessage {
+	var message = decodedMessage{
+		Message: Message{
+			Time: datagram.Time,
+			Src:  datagram.Src.String(),
+			Dst:  datagram.Dst.String(),
+		},
+		pduType: -1,
+	}
+	var packet snmp.Packet
+
+	meta, pdu, err := packet.Decode(datagram.Payload)
+	if err != nil {
+		message.Error = err.Error()
+
+		return message
+	}
+
+	message.Version = formatVersion(packet.Version)
+	message.Community = string(packet.Community)
+	message.PDUType = meta.PDUType.String()
+	message.RequestID = meta.RequestID
+	message.pduType = meta.PDUType
+
+	switch pdu := pdu.(type) {
+	case snmp.GenericPDU:
+		if pdu.ErrorStatus != snmp.Success {
+			message.ErrorStatus = pdu.ErrorStatus.String()
+			message.ErrorIndex = pdu.ErrorIndex
+		}
+
+		// request values are all NULL, except for SetRequest
+		message.VarBinds = formatVarBinds(pdu.VarBinds, meta.PDUType != snmp.GetRequestType && meta.PDUType != snmp.GetNextRequestType)
+
+	case snmp.BulkPDU:
+		message.NonRepeaters = pdu.NonRepeaters
+		message.MaxRepetitions = pdu.MaxRepetitions
+		message.VarBinds = formatVarBinds(pdu.VarBinds, false)
+
+	case snmp.TrapPDU:
+		message.Enterprise = mibs.FormatOID(snmp.OID(pdu.Enterprise))
+		message.AgentAddr = net.IP(pdu.AgentAddr).String()
+		message.GenericTrap = pdu.GenericTrap.String()
+		message.SpecificTrap = pdu.SpecificTrap
+		message.VarBinds = formatVarBinds(pdu.VarBinds, true)
+	}
+
+	return message
+}
+
+func formatVarBinds(varBinds []snmp.VarBind, values bool) []VarBind {
+	var formatted = make([]VarBind, len(varBinds))
+
+	for i, varBind := range varBinds {
+		formatted[i] = formatVarBind(varBind, values)
+	}
+
+	return formatted
+}
+
+// Resolve the OID and value using any known MIB object.
+func formatVarBind(varBind snmp.VarBind, values bool) VarBind {
+	var oid = varBind.OID()
+	var formatted = VarBind{OID: oid.String()}
+	var object = mibs.LookupObject(oid)
+
+	if object == nil {
+		formatted.Name = mibs.FormatOID(oid)
+	} else if name, err := object.FormatIndex(oid); err != nil {
+		formatted.Name = mibs.FormatOID(oid)
+		formatted.Error = err.Error()
+	} else {
+		formatted.Name = name
+	}
+
+	if !values {
+		return formatted
+	}
+
+	if errorValue, ok := varBind.ErrorValue().(snmp.ErrorValue); ok {
+		formatted.Error = errorValue.String()
+	} else if object == nil {
+		if value, err := varBind.Value(); err != nil {
+			formatted.Error = err.Error()
+		} else {
+			formatted.Value = value
+		}
+	} else if _, value, err := object.Format(varBind); err != nil {
+		formatted.Error = err.Error()
+	} else {
+		formatted.Value = value
+	}
+
+	return formatted
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/mibs/bundle"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	if err := mibs.LoadSources(mibs.FSSource("bundle", bundle.FS)); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func testDecodeCapture(t *testing.T, path string) []*Exchange {
+	var exchanges []*Exchange
+	var decoder = MakeDecoder(161, 162)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open %v: %v", path, err)
+	}
+	defer file.Close()
+
+	reader, err := OpenCapture(file)
+	if err != nil {
+		t.Fatalf("OpenCapture %v: %v", path, err)
+	}
+
+	if err := decodeCapture(decoder, reader, func(exchange *Exchange) error {
+		exchanges = append(exchanges, exchange)
+		return nil
+	}); err != nil {
+		t.Fatalf("decodeCapture %v: %v", path, err)
+	}
+
+	return append(exchanges, decoder.Flush()...)
+}
+
+func TestDecodePcap(t *testing.T) {
+	var exchanges = testDecodeCapture(t, "testdata/get.pcap")
+
+	if !assert.Equal(t, 3, len(exchanges)) {
+		return
+	}
+
+	assert.Equal(t, &Message{
+		Time:      time.Unix(1760000000, 0),
+		Src:       "192.0.2.1:40000",
+		Dst:       "192.0.2.2:161",
+		Version:   "SNMPv2c",
+		Community: "public",
+		PDUType:   "GetRequest",
+		RequestID: 1,
+		VarBinds: []VarBind{
+			{OID: ".1.3.6.1.2.1.1.1.0", Name: "SNMPv2-MIB::sysDescr"},
+			{OID: ".1.3.6.1.2.1.1.5.0", Name: "SNMPv2-MIB::sysName"},
+		},
+	}, exchanges[0].Request)
+	assert.Equal(t, "GetResponse", exchanges[0].Response.PDUType)
+	assert.Equal(t, 1500*time.Microsecond, exchanges[0].Latency)
+	assert.Equal(t, mibs.DisplayString("Linux test"), exchanges[0].Response.VarBinds[0].Value)
+	assert.Equal(t, mibs.DisplayString("test"), exchanges[0].Response.VarBinds[1].Value)
+
+	assert.Equal(t, "GetNextRequest", exchanges[1].Request.PDUType)
+	assert.Equal(t, 1, exchanges[1].Retransmits)
+	assert.Equal(t, 502*time.Millisecond, exchanges[1].Latency)
+	assert.Equal(t, "IF-MIB::ifDescr[1]", exchanges[1].Response.VarBinds[0].Name)
+	assert.Equal(t, mibs.DisplayString("eth0"), exchanges[1].Response.VarBinds[0].Value)
+
+	assert.Equal(t, "SNMPv1", exchanges[2].Request.Version)
+	assert.Equal(t, 3, exchanges[2].Request.RequestID)
+	assert.Nil(t, exchanges[2].Response)
+	assert.True(t, exchanges[2].NoResponse)
+}
+
+func TestDecodePcapng(t *testing.T) {
+	var exchanges = testDecodeCapture(t, "testdata/trap.pcapng")
+
+	if !assert.Equal(t, 3, len(exchanges)) {
+		return
+	}
+
+	assert.Equal(t, "[2001:db8::2]:50000", exchanges[0].Request.Src)
+	assert.Equal(t, "TrapV2", exchanges[0].Request.PDUType)
+	assert.Nil(t, exchanges[0].Response)
+	assert.Equal(t, VarBind{OID: ".1.3.6.1.2.1.2.2.1.1.1", Name: "IF-MIB::ifIndex[1]", Value: mibs.Integer(1)}, exchanges[0].Request.VarBinds[2])
+
+	assert.Equal(t, time.Unix(1760000001, 250000), exchanges[1].Response.Time)
+	assert.Equal(t, 250*time.Microsecond, exchanges[1].Latency)
+	assert.Equal(t, "NoSuchObject", exchanges[1].Response.VarBinds[0].Error)
+
+	assert.Equal(t, "", exchanges[2].Request.PDUType)
+	assert.Equal(t, "Invalid version at offset 2: invalid value: 5", exchanges[2].Request.Error)
+}
+
+func TestDecodePorts(t *testing.T) {
+	var decoder = MakeDecoder(1161)
+	var datagram = Datagram{
+		Src:     &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 40000},
+		Dst:     &net.UDPAddr{IP: net.IPv4(192, 0, 2, 2), Port: 161},
+		Payload: []byte{0x30, 0x00},
+	}
+
+	assert.Nil(t, decoder.Decode(datagram))
+	assert.Empty(t, decoder.Flush())
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	var exchanges = testDecodeCapture(t, "testdata/get.pcap")
+
+	for _, exchange := range exchanges {
+		if err := writeText(&buf, exchange); err != nil {
+			t.Fatalf("writeText: %v", err)
+		}
+	}
+
+	assert.Equal(t, strings.Join([]string{
+		`2025-10-09T08:53:20.000000Z 192.0.2.1:40000 -> 192.0.2.2:161 SNMPv2c "public" GetRequest[1]`,
+		"\tSNMPv2-MIB::sysDescr",
+		"\tSNMPv2-MIB::sysName",
+		`2025-10-09T08:53:20.001500Z 192.0.2.2:161 -> 192.0.2.1:40000 SNMPv2c "public" GetResponse[1] (1.5ms)`,
+		"\tSNMPv2-MIB::sysDescr = Linux test",
+		"\tSNMPv2-MIB::sysName = test",
+		`2025-10-09T08:53:21.000000Z 192.0.2.1:40000 -> 192.0.2.2:161 SNMPv2c "public" GetNextRequest[2] (1 retransmits)`,
+		"\tIF-MIB::ifDescr",
+		`2025-10-09T08:53:21.502000Z 192.0.2.2:161 -> 192.0.2.1:40000 SNMPv2c "public" GetResponse[2] (502ms)`,
+		"\tIF-MIB::ifDescr[1] = eth0",
+		`2025-10-09T08:53:22.000000Z 192.0.2.1:40000 -> 192.0.2.2:161 SNMPv1 "private" GetRequest[3] (no response)`,
+		"\tSNMPv2-MIB::sysName",
+		"",
+	}, "\n"), buf.String())
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/qmsk/snmpbot/cmd"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Options struct {
+	cmd.Options
+
+	JSON  bool
+	Ports string
+}
+
+func (options *Options) InitFlags() {
+	options.Options.InitFlags()
+
+	flag.BoolVar(&options.JSON, "json", false, "Output JSON objects, one per line")
+	flag.StringVar(&options.Ports, "ports", "161,162", "Decode UDP ports: PORT[,PORT...]")
+}
+
+func (options Options) ParsePorts() ([]int, error) {
+	var ports []int
+
+	for _, arg := range strings.Split(options.Ports, ",") {
+		if port, err := strconv.ParseUint(arg, 10, 16); err != nil {
+			return nil, fmt.Errorf("Invalid port %v: %v", arg, err)
+		} else {
+			ports = append(ports, int(port))
+		}
+	}
+
+	return ports, nil
+}
+
+var options Options
+
+func init() {
+	options.InitFlags()
+}
+
+type output func(*Exchange) error
+
+func jsonOutput(w io.Writer) output {
+	var encoder = json.NewEncoder(w)
+
+	return func(exchange *Exchange) error {
+		return encoder.Encode(exchange)
+	}
+}
+
+func textOutput(w io.Writer) output {
+	return func(exchange *Exchange) error {
+		return writeText(w, exchange)
+	}
+}
+
+// Decode all frames from the capture, returning on the first read error.
+func decodeCapture(decoder Decoder, reader CaptureReader, output output) error {
+	for {
+		frame, err := reader.ReadFrame()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		datagram, err := DecodeFrame(frame)
+		if _, skip := err.(skipError); skip {
+			continue
+		} else if err != nil {
+			cmd.Log.Warnf("Invalid frame at %v: %v", frame.Time, err)
+			continue
+		}
+
+		if exchange := decoder.Decode(datagram); exchange == nil {
+			continue
+		} else if err := output(exchange); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeFile(decoder Decoder, path string, output output) error {
+	var file io.Reader
+
+	if path == "-" {
+		file = os.Stdin
+	} else if f, err := os.Open(path); err != nil {
+		return err
+	} else {
+		defer f.Close()
+
+		file = f
+	}
+
+	if reader, err := OpenCapture(file); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	} else if err := decodeCapture(decoder, reader, output); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+
+	return nil
+}
+
+func snmpdecode(args []string) error {
+	var output output
+
+	if len(args) < 1 {
+		return fmt.Errorf("Usage: [options] <file.pcap|file.pcapng|-...>")
+	}
+
+	if options.JSON {
+		output = jsonOutput(os.Stdout)
+	} else {
+		output = textOutput(os.Stdout)
+	}
+
+	ports, err := options.ParsePorts()
+	if err != nil {
+		return err
+	}
+
+	var decoder = MakeDecoder(ports...)
+
+	for _, arg := range args {
+		if err := decodeFile(decoder, arg, output); err != nil {
+			return err
+		}
+	}
+
+	for _, exchange := range decoder.Flush() {
+		if err := output(exchange); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	options.Main(snmpdecode)
+}
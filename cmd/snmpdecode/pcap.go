@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Link-layer header types, see https://www.tcpdump.org/linktypes.html
+type LinkType uint32
+
+const (
+	LinkTypeNull      LinkType = 0
+	LinkTypeEthernet  LinkType = 1
+	LinkTypeRaw       LinkType = 101
+	LinkTypeLoop      LinkType = 108
+	LinkTypeLinuxSLL  LinkType = 113
+	LinkTypeIPv4      LinkType = 228
+	LinkTypeIPv6      LinkType = 229
+	LinkTypeLinuxSLL2 LinkType = 276
+)
+
+// A single captured link-layer frame.
+type Frame struct {
+	Time     time.Time
+	LinkType LinkType
+	Data     []byte
+}
+
+// Reader for captured frames, returning io.EOF at the end of the capture.
+type CaptureReader interface {
+	ReadFrame() (Frame, error)
+}
+
+const (
+	pcapMagicMicros      = 0xa1b2c3d4
+	pcapMagicNanos       = 0xa1b23c4d
+	pcapngBlockSHB       = 0x0a0d0d0a
+	pcapngBlockIDB       = 0x00000001
+	pcapngBlockSPB       = 0x00000003
+	pcapngBlockEPB       = 0x00000006
+	pcapngByteOrderMagic = 0x1a2b3c4d
+	pcapngOptionEnd      = 0
+	pcapngOptionTSResol  = 9
+
+	maxCaptureLength = 1 << 24 // sanity limit for block and record lengths
+)
+
+// Open a pcap or pcapng capture, detected from the leading magic number.
+func OpenCapture(r io.Reader) (CaptureReader, error) {
+	var reader = bufio.NewReader(r)
+
+	magic, err := reader.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid capture header: %v", err)
+	}
+
+	if binary.BigEndian.Uint32(magic) == pcapngBlockSHB {
+		return openPcapng(reader)
+	} else {
+		return openPcap(reader)
+	}
+}
+
+// Classic libpcap file format.
+type pcapReader struct {
+	reader    io.Reader
+	byteOrder binary.ByteOrder
+	nanos     bool
+	linkType  LinkType
+	header    [16]byte
+}
+
+func openPcap(r io.Reader) (*pcapReader, error) {
+	var reader = pcapReader{reader: r}
+	var header [24]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("Invalid pcap header: %v", err)
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(header[0:4]) == pcapMagicMicros:
+		reader.byteOrder = binary.LittleEndian
+	case binary.LittleEndian.Uint32(header[0:4]) == pcapMagicNanos:
+		reader.byteOrder = binary.LittleEndian
+		reader.nanos = true
+	case binary.BigEndian.Uint32(header[0:4]) == pcapMagicMicros:
+		reader.byteOrder = binary.BigEndian
+	case binary.BigEndian.Uint32(header[0:4]) == pcapMagicNanos:
+		reader.byteOrder = binary.BigEndian
+		reader.nanos = true
+	default:
+		return nil, fmt.Errorf("Invalid pcap header: unknown magic %x", header[0:4])
+	}
+
+	if major := reader.byteOrder.Uint16(header[4:6]); major != 2 {
+		return nil, fmt.Errorf("Unsupported pcap version: %d", major)
+	}
+
+	// the upper bits may contain FCS information
+	reader.linkType = LinkType(reader.byteOrder.Uint32(header[20:24]) & 0x0fffffff)
+
+	return &reader, nil
+}
+
+func (reader *pcapReader) ReadFrame() (Frame, error) {
+	var frame = Frame{LinkType: reader.linkType}
+
+	if _, err := io.ReadFull(reader.reader, reader.header[:]); err == io.EOF {
+		return frame, io.EOF
+	} else if err != nil {
+		return frame, fmt.Errorf("Invalid pcap record header: %v", err)
+	}
+
+	var sec = int64(reader.byteOrder.Uint32(reader.header[0:4]))
+	var frac = int64(reader.byteOrder.Uint32(reader.header[4:8]))
+	var length = reader.byteOrder.Uint32(reader.header[8:12])
+
+	if length > maxCaptureLength {
+		return frame, fmt.Errorf("Invalid pcap record length: %d", length)
+	}
+
+	if reader.nanos {
+		frame.Time = time.Unix(sec, frac)
+	} else {
+		frame.Time = time.Unix(sec, frac*1000)
+	}
+
+	frame.Data = make([]byte, length)
+
+	if _, err := io.ReadFull(reader.reader, frame.Data); err != nil {
+		return frame, fmt.Errorf("Invalid pcap record: %v", err)
+	}
+
+	return frame, nil
+}
+
+// The pcapng file format, consisting of one or more sections with any number of interfaces.
+type pcapngReader struct {
+	reader     io.Reader
+	byteOrder  binary.ByteOrder
+	interfaces []pcapngInterface
+}
+
+type pcapngInterface struct {
+	linkType LinkType
+	tsUnits  uint64 // timestamp units per second
+}
+
+func openPcapng(r io.Reader) (*pcapngReader, error) {
+	var reader = pcapngReader{reader: r}
+
+	if blockType, body, err := reader.readBlock(); err != nil {
+		return nil, err
+	} else if blockType != pcapngBlockSHB {
+		return nil, fmt.Errorf("Invalid pcapng header: block type %08x", blockType)
+	} else if err := reader.readSectionHeader(body); err != nil {
+		return nil, err
+	}
+
+	return &reader, nil
+}
+
+// Read the next block, returning the block body.
+//
+// The section header block sets the byte order used for the block length.
+func (reader *pcapngReader) readBlock() (uint32, []byte, error) {
+	var header [12]byte
+
+	if _, err := io.ReadFull(reader.reader, header[0:8]); err == io.EOF {
+		return 0, nil, io.EOF
+	} else if err != nil {
+		return 0, nil, fmt.Errorf("Invalid pcapng block header: %v", err)
+	}
+
+	var blockType = binary.BigEndian.Uint32(header[0:4])
+
+	if blockType == pcapngBlockSHB {
+		// the byte-order magic follows the block length
+		if _, err := io.ReadFull(reader.reader, header[8:12]); err != nil {
+			return 0, nil, fmt.Errorf("Invalid pcapng section header: %v", err)
+		} else if binary.BigEndian.Uint32(header[8:12]) == pcapngByteOrderMagic {
+			reader.byteOrder = binary.BigEndian
+		} else if binary.LittleEndian.Uint32(header[8:12]) == pcapngByteOrderMagic {
+			reader.byteOrder = binary.LittleEndian
+		} else {
+			return 0, nil, fmt.Errorf("Invalid pcapng section header: unknown byte-order magic %x", header[8:12])
+		}
+	} else {
+		blockType = reader.byteOrder.Uint32(header[0:4])
+	}
+
+	var length = reader.byteOrder.Uint32(header[4:8])
+
+	if length < 12 || length%4 != 0 || length > maxCaptureLength {
+		return blockType, nil, fmt.Errorf("Invalid pcapng block length: %d", length)
+	}
+
+	var body = make([]byte, length-8)
+
+	if blockType == pcapngBlockSHB {
+		copy(body, header[8:12])
+
+		if _, err := io.ReadFull(reader.reader, body[4:]); err != nil {
+			return blockType, nil, fmt.Errorf("Invalid pcapng block: %v", err)
+		}
+	} else if _, err := io.ReadFull(reader.reader, body); err != nil {
+		return blockType, nil, fmt.Errorf("Invalid pcapng block: %v", err)
+	}
+
+	if trailer := reader.byteOrder.Uint32(body[len(body)-4:]); trailer != length {
+		return blockType, nil, fmt.Errorf("Invalid pcapng block: trailing length %d != %d", trailer, length)
+	}
+
+	return blockType, body[:len(body)-4], nil
+}
+
+func (reader *pcapngReader) readSectionHeader(body []byte) error {
+	if len(body) < 16 {
+		return fmt.Errorf("Invalid pcapng section header: truncated")
+	} else if major := reader.byteOrder.Uint16(body[4:6]); major != 1 {
+		return fmt.Errorf("Unsupported pcapng version: %d", major)
+	}
+
+	// interface IDs are local to each section
+	reader.interfaces = nil
+
+	return nil
+}
+
+func (reader *pcapngReader) readInterface(body []byte) error {
+	var iface = pcapngInterface{tsUnits: 1000000}
+
+	if len(body) < 8 {
+		return fmt.Errorf("Invalid pcapng interface description: truncated")
+	}
+
+	iface.linkType = LinkType(reader.byteOrder.Uint16(body[0:2]))
+
+	for options := body[8:]; len(options) >= 4; {
+		var code = reader.byteOrder.Uint16(options[0:2])
+		var length = int(reader.byteOrder.Uint16(options[2:4]))
+		var padded = (length + 3) &^ 3
+
+		if code == pcapngOptionEnd {
+			break
+		} else if 4+padded > len(options) {
+			return fmt.Errorf("Invalid pcapng interface description: truncated option %d", code)
+		}
+
+		if code == pcapngOptionTSResol && length == 1 {
+			if units, err := parseTSResol(options[4]); err != nil {
+				return err
+			} else {
+				iface.tsUnits = units
+			}
+		}
+
+		options = options[4+padded:]
+	}
+
+	reader.interfaces = append(reader.interfaces, iface)
+
+	return nil
+}
+
+// Decode the if_tsresol option as a number of timestamp units per second.
+func parseTSResol(tsresol byte) (uint64, error) {
+	var exp = uint(tsresol & 0x7f)
+
+	if tsresol&0x80 != 0 {
+		if exp > 63 {
+			return 0, fmt.Errorf("Invalid pcapng if_tsresol: 2^-%d", exp)
+		}
+
+		return uint64(1) << exp, nil
+	}
+
+	if exp > 19 {
+		return 0, fmt.Errorf("Invalid pcapng if_tsresol: 10^-%d", exp)
+	}
+
+	return uint64(math.Pow10(int(exp))), nil
+}
+
+// Convert a timestamp in units per second.
+func timestamp(ts uint64, units uint64) time.Time {
+	var sec = ts / units
+	var frac = ts % units
+
+	if units <= uint64(time.Second) {
+		return time.Unix(int64(sec), int64(frac*(uint64(time.Second)/units)))
+	} else {
+		return time.Unix(int64(sec), int64(frac/(units/uint64(time.Second))))
+	}
+}
+
+func (reader *pcapngReader) interfaceFrame(id uint32) (Frame, error) {
+	if int(id) >= len(reader.interfaces) {
+		return Frame{}, fmt.Errorf("Invalid pcapng packet: unknown interface %d", id)
+	}
+
+	return Frame{LinkType: reader.interfaces[id].linkType}, nil
+}
+
+func (reader *pcapngReader) ReadFrame() (Frame, error) {
+	for {
+		blockType, body, err := reader.readBlock()
+		if err != nil {
+			return Frame{}, err
+		}
+
+		switch blockType {
+		case pcapngBlockSHB:
+			if err := reader.readSectionHeader(body); err != nil {
+				return Frame{}, err
+			}
+
+		case pcapngBlockIDB:
+			if err := reader.readInterface(body); err != nil {
+				return Frame{}, err
+			}
+
+		case pcapngBlockEPB:
+			if len(body) < 20 {
+				return Frame{}, fmt.Errorf("Invalid pcapng enhanced packet: truncated")
+			}
+
+			var id = reader.byteOrder.Uint32(body[0:4])
+			var ts = uint64(reader.byteOrder.Uint32(body[4:8]))<<32 | uint64(reader.byteOrder.Uint32(body[8:12]))
+			var length = int(reader.byteOrder.Uint32(body[12:16]))
+
+			frame, err := reader.interfaceFrame(id)
+			if err != nil {
+				return frame, err
+			} else if length > len(body)-20 {
+				return frame, fmt.Errorf("Invalid pcapng enhanced packet: length %d", length)
+			}
+
+			frame.Time = timestamp(ts, reader.interfaces[id].tsUnits)
+			frame.Data = body[20 : 20+length]
+
+			return frame, nil
+
+		case pcapngBlockSPB:
+			// simple packets do not have any timestamp, and are always from the first interface
+			if len(body) < 4 {
+				return Frame{}, fmt.Errorf("Invalid pcapng simple packet: truncated")
+			}
+
+			frame, err := reader.interfaceFrame(0)
+			if err != nil {
+				return frame, err
+			}
+
+			var length = int(reader.byteOrder.Uint32(body[0:4]))
+
+			if length > len(body)-4 {
+				length = len(body) - 4 // snapped
+			}
+
+			frame.Data = body[4 : 4+length]
+
+			return frame, nil
+
+		default:
+			// skip any unknown blocks
+		}
+	}
+}
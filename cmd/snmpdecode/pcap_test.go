@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestOpenCaptureInvalid(t *testing.T) {
+	for _, test := range []struct {
+		data []byte
+		err  string
+	}{
+		{[]byte{}, "Invalid capture header: EOF"},
+		{[]byte("not a capture file"), "Invalid pcap header: unexpected EOF"},
+		{[]byte("not a capture file, but long enough"), "Invalid pcap header: unknown magic 6e6f7420"},
+		{[]byte{0x0a, 0x0d, 0x0d, 0x0a, 0x1c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, "Invalid pcapng section header: unknown byte-order magic 00000000"},
+	} {
+		_, err := OpenCapture(bytes.NewReader(test.data))
+
+		assert.EqualError(t, err, test.err)
+	}
+}
+
+func TestPcapNanos(t *testing.T) {
+	var data = []byte{
+		0x4d, 0x3c, 0xb2, 0xa1, 0x02, 0x00, 0x04, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0x00, 0x00, 0x65, 0x00, 0x00, 0x00,
+		// record
+		0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		0x45,
+	}
+
+	reader, err := OpenCapture(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("OpenCapture: %v", err)
+	}
+
+	frame, err := reader.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+
+	assert.Equal(t, Frame{Time: time.Unix(1, 2), LinkType: LinkTypeRaw, Data: []byte{0x45}}, frame)
+
+	_, err = reader.ReadFrame()
+
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestPcapTruncated(t *testing.T) {
+	var data = []byte{
+		0xd4, 0xc3, 0xb2, 0xa1, 0x02, 0x00, 0x04, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		// record
+		0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+		0x10, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00,
+		0x00,
+	}
+
+	reader, err := OpenCapture(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("OpenCapture: %v", err)
+	}
+
+	_, err = reader.ReadFrame()
+
+	assert.EqualError(t, err, "Invalid pcap record: unexpected EOF")
+}
+
+func TestTimestamp(t *testing.T) {
+	assert.Equal(t, time.Unix(1, 500000000), timestamp(1500000, 1000000))
+	assert.Equal(t, time.Unix(1, 5), timestamp(1000000005, 1000000000))
+	assert.Equal(t, time.Unix(1, 500000000), timestamp(3, 2))
+	assert.Equal(t, time.Unix(1, 1), timestamp(1000000001000, 1000000000000))
+}
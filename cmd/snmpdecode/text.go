@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const textTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+func writeTextMessage(w io.Writer, message *Message, suffix string) error {
+	var fields = []string{
+		message.Time.UTC().Format(textTimeFormat),
+		message.Src,
+		"->",
+		message.Dst,
+	}
+
+	if message.Error != "" && message.PDUType == "" {
+		fields = append(fields, "!"+message.Error)
+	} else {
+		fields = append(fields, message.Version, fmt.Sprintf("%q", message.Community), fmt.Sprintf("%v[%d]", message.PDUType, message.RequestID))
+	}
+
+	if message.ErrorStatus != "" {
+		fields = append(fields, fmt.Sprintf("!%v@%d", message.ErrorStatus, message.ErrorIndex))
+	}
+	if message.MaxRepetitions != 0 || message.NonRepeaters != 0 {
+		fields = append(fields, fmt.Sprintf("non-repeaters=%d max-repetitions=%d", message.NonRepeaters, message.MaxRepetitions))
+	}
+	if message.GenericTrap != "" {
+		fields = append(fields, message.Enterprise, message.AgentAddr, fmt.Sprintf("%v/%d", message.GenericTrap, message.SpecificTrap))
+	}
+	if suffix != "" {
+		fields = append(fields, suffix)
+	}
+
+	if _, err := fmt.Fprintln(w, strings.Join(fields, " ")); err != nil {
+		return err
+	}
+
+	for _, varBind := range message.VarBinds {
+		var line string
+
+		if varBind.Error != "" {
+			line = fmt.Sprintf("\t%v = !%v", varBind.Name, varBind.Error)
+		} else if varBind.Value != nil {
+			line = fmt.Sprintf("\t%v = %v", varBind.Name, varBind.Value)
+		} else {
+			line = fmt.Sprintf("\t%v", varBind.Name)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write the request and response, with the response latency.
+func writeText(w io.Writer, exchange *Exchange) error {
+	if exchange.Request != nil {
+		var suffix string
+
+		if exchange.Retransmits > 0 {
+			suffix = fmt.Sprintf("(%d retransmits)", exchange.Retransmits)
+		}
+		if exchange.NoResponse {
+			suffix = strings.TrimSpace(suffix + " (no response)")
+		}
+
+		if err := writeTextMessage(w, exchange.Request, suffix); err != nil {
+			return err
+		}
+	}
+
+	if exchange.Response != nil {
+		var suffix = "(no request)"
+
+		if exchange.Request != nil {
+			suffix = fmt.Sprintf("(%v)", exchange.Latency)
+		}
+
+		if err := writeTextMessage(w, exchange.Response, suffix); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
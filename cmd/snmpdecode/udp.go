@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	etherTypeIPv4  = 0x0800
+	etherTypeIPv6  = 0x86dd
+	etherTypeVLAN  = 0x8100
+	etherTypeQinQ  = 0x88a8
+	ipProtocolUDP  = 17
+	ipv6HopByHop   = 0
+	ipv6Routing    = 43
+	ipv6Fragment   = 44
+	ipv6DestOpts   = 60
+	ipv4Fragmented = 0x3fff // MF flag and fragment offset
+)
+
+// Errors for frames that do not contain any UDP datagram.
+//
+// These are silently skipped, unlike any other errors for truncated or invalid frames.
+type skipError string
+
+func (err skipError) Error() string {
+	return string(err)
+}
+
+// A single UDP datagram from a captured frame.
+type Datagram struct {
+	Time    time.Time
+	Src     *net.UDPAddr
+	Dst     *net.UDPAddr
+	Payload []byte
+}
+
+// Decode the link-layer, IPv4/IPv6 and UDP headers of the captured frame.
+func DecodeFrame(frame Frame) (Datagram, error) {
+	var datagram = Datagram{Time: frame.Time}
+	var buf = frame.Data
+	var etherType uint16
+
+	switch frame.LinkType {
+	case LinkTypeEthernet:
+		if len(buf) < 14 {
+			return datagram, fmt.Errorf("Invalid ethernet frame: truncated")
+		}
+
+		etherType, buf = binary.BigEndian.Uint16(buf[12:14]), buf[14:]
+
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(buf) < 4 {
+				return datagram, fmt.Errorf("Invalid VLAN tag: truncated")
+			}
+
+			etherType, buf = binary.BigEndian.Uint16(buf[2:4]), buf[4:]
+		}
+
+	case LinkTypeLinuxSLL:
+		if len(buf) < 16 {
+			return datagram, fmt.Errorf("Invalid Linux SLL header: truncated")
+		}
+
+		etherType, buf = binary.BigEndian.Uint16(buf[14:16]), buf[16:]
+
+	case LinkTypeLinuxSLL2:
+		if len(buf) < 20 {
+			return datagram, fmt.Errorf("Invalid Linux SLL2 header: truncated")
+		}
+
+		etherType, buf = binary.BigEndian.Uint16(buf[0:2]), buf[20:]
+
+	case LinkTypeNull, LinkTypeLoop:
+		// the address family is in host byte order, but the IP version can be used instead
+		if len(buf) < 4 {
+			return datagram, fmt.Errorf("Invalid loopback header: truncated")
+		}
+
+		buf = buf[4:]
+		etherType = ipEtherType(buf)
+
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		etherType = ipEtherType(buf)
+
+	default:
+		return datagram, skipError(fmt.Sprintf("Unsupported link type %d", frame.LinkType))
+	}
+
+	var protocol byte
+	var err error
+
+	switch etherType {
+	case etherTypeIPv4:
+		protocol, buf, err = decodeIPv4(&datagram, buf)
+	case etherTypeIPv6:
+		protocol, buf, err = decodeIPv6(&datagram, buf)
+	default:
+		return datagram, skipError(fmt.Sprintf("Unsupported ethertype %04x", etherType))
+	}
+
+	if err != nil {
+		return datagram, err
+	} else if protocol != ipProtocolUDP {
+		return datagram, skipError(fmt.Sprintf("Unsupported IP protocol %d", protocol))
+	}
+
+	return datagram, decodeUDP(&datagram, buf)
+}
+
+func ipEtherType(buf []byte) uint16 {
+	if len(buf) == 0 {
+		return 0
+	}
+
+	switch buf[0] >> 4 {
+	case 4:
+		return etherTypeIPv4
+	case 6:
+		return etherTypeIPv6
+	default:
+		return 0
+	}
+}
+
+func decodeIPv4(datagram *Datagram, buf []byte) (byte, []byte, error) {
+	if len(buf) < 20 {
+		return 0, nil, fmt.Errorf("Invalid IPv4 header: truncated")
+	}
+
+	var headerLength = int(buf[0]&0x0f) * 4
+	var totalLength = int(binary.BigEndian.Uint16(buf[2:4]))
+
+	if headerLength < 20 || headerLength > len(buf) || totalLength < headerLength {
+		return 0, nil, fmt.Errorf("Invalid IPv4 header: length %d/%d", headerLength, totalLength)
+	} else if binary.BigEndian.Uint16(buf[6:8])&ipv4Fragmented != 0 {
+		return 0, nil, skipError("Unsupported IPv4 fragment")
+	}
+
+	datagram.Src = &net.UDPAddr{IP: net.IP(buf[12:16])}
+	datagram.Dst = &net.UDPAddr{IP: net.IP(buf[16:20])}
+
+	if totalLength < len(buf) {
+		// ethernet padding
+		buf = buf[:totalLength]
+	}
+
+	return buf[9], buf[headerLength:], nil
+}
+
+func decodeIPv6(datagram *Datagram, buf []byte) (byte, []byte, error) {
+	if len(buf) < 40 {
+		return 0, nil, fmt.Errorf("Invalid IPv6 header: truncated")
+	}
+
+	var payloadLength = int(binary.BigEndian.Uint16(buf[4:6]))
+	var nextHeader = buf[6]
+
+	datagram.Src = &net.UDPAddr{IP: net.IP(buf[8:24])}
+	datagram.Dst = &net.UDPAddr{IP: net.IP(buf[24:40])}
+
+	if buf = buf[40:]; payloadLength < len(buf) {
+		buf = buf[:payloadLength]
+	}
+
+	for {
+		switch nextHeader {
+		case ipv6HopByHop, ipv6Routing, ipv6DestOpts:
+			if len(buf) < 8 || len(buf) < (int(buf[1])+1)*8 {
+				return 0, nil, fmt.Errorf("Invalid IPv6 extension header: truncated")
+			}
+
+			nextHeader, buf = buf[0], buf[(int(buf[1])+1)*8:]
+
+		case ipv6Fragment:
+			return 0, nil, skipError("Unsupported IPv6 fragment")
+
+		default:
+			return nextHeader, buf, nil
+		}
+	}
+}
+
+func decodeUDP(datagram *Datagram, buf []byte) error {
+	if len(buf) < 8 {
+		return fmt.Errorf("Invalid UDP header: truncated")
+	}
+
+	var length = int(binary.BigEndian.Uint16(buf[4:6]))
+
+	if length < 8 || length > len(buf) {
+		return fmt.Errorf("Invalid UDP header: length %d with %d bytes", length, len(buf))
+	}
+
+	datagram.Src.Port = int(binary.BigEndian.Uint16(buf[0:2]))
+	datagram.Dst.Port = int(binary.BigEndian.Uint16(buf[2:4]))
+	datagram.Payload = buf[8:length]
+
+	return nil
+}
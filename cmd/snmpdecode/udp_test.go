@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+var testUDPIPv4 = []byte{
+	0x45, 0x00, 0x00, 0x1e, 0x00, 0x01, 0x40, 0x00, 0x40, 0x11, 0x00, 0x00,
+	192, 0, 2, 1,
+	192, 0, 2, 2,
+	// UDP
+	0x9c, 0x40, 0x00, 0xa1, 0x00, 0x0a, 0x00, 0x00,
+	0x30, 0x00,
+}
+
+func TestDecodeFrameVLAN(t *testing.T) {
+	var data = append([]byte{
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x02, 0x02, 0x00, 0x00, 0x00, 0x00, 0x01,
+		0x81, 0x00, 0x00, 0x64, 0x08, 0x00,
+	}, testUDPIPv4...)
+
+	// ethernet padding
+	data = append(data, 0x00, 0x00, 0x00, 0x00)
+
+	datagram, err := DecodeFrame(Frame{LinkType: LinkTypeEthernet, Data: data})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &net.UDPAddr{IP: net.IP{192, 0, 2, 1}, Port: 40000}, datagram.Src)
+	assert.Equal(t, &net.UDPAddr{IP: net.IP{192, 0, 2, 2}, Port: 161}, datagram.Dst)
+	assert.Equal(t, []byte{0x30, 0x00}, datagram.Payload)
+}
+
+func TestDecodeFrameLinuxSLL(t *testing.T) {
+	var data = append([]byte{
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0x02, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+		0x08, 0x00,
+	}, testUDPIPv4...)
+
+	datagram, err := DecodeFrame(Frame{LinkType: LinkTypeLinuxSLL, Data: data})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 161, datagram.Dst.Port)
+	assert.Equal(t, []byte{0x30, 0x00}, datagram.Payload)
+}
+
+func TestDecodeFrameSkip(t *testing.T) {
+	var fragment = append([]byte(nil), testUDPIPv4...)
+
+	fragment[6] = 0x20 // MF
+
+	for _, frame := range []Frame{
+		{LinkType: 147, Data: testUDPIPv4},
+		{LinkType: LinkTypeRaw, Data: []byte{0x00}},
+		{LinkType: LinkTypeRaw, Data: fragment},
+	} {
+		_, err := DecodeFrame(frame)
+
+		assert.IsType(t, skipError(""), err)
+	}
+}
+
+func TestDecodeFrameInvalid(t *testing.T) {
+	for _, test := range []struct {
+		frame Frame
+		err   string
+	}{
+		{Frame{LinkType: LinkTypeEthernet, Data: []byte{0x00}}, "Invalid ethernet frame: truncated"},
+		{Frame{LinkType: LinkTypeRaw, Data: testUDPIPv4[:16]}, "Invalid IPv4 header: truncated"},
+		{Frame{LinkType: LinkTypeRaw, Data: testUDPIPv4[:24]}, "Invalid UDP header: truncated"},
+		{Frame{LinkType: LinkTypeRaw, Data: testUDPIPv4[:29]}, "Invalid UDP header: length 10 with 9 bytes"},
+		{Frame{LinkType: LinkTypeIPv6, Data: []byte{0x60, 0x00}}, "Invalid IPv6 header: truncated"},
+	} {
+		_, err := DecodeFrame(test.frame)
+
+		assert.EqualError(t, err, test.err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/cmd"
+	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
+	"strings"
+)
+
+type Options struct {
+	cmd.Options
+}
+
+var options Options
+
+func init() {
+	options.InitFlags()
+}
+
+func printTree(node *mibs.TreeNode, depth int) {
+	fmt.Printf("%s%v %v\n", strings.Repeat("  ", depth), node.ID, node.ID.OID)
+
+	for _, child := range node.Children {
+		printTree(child, depth+1)
+	}
+}
+
+// Usage: snmpmib tree [ROOT]
+func snmpmibTree(args []string) error {
+	var root snmp.OID
+
+	if len(args) > 1 {
+		return fmt.Errorf("Usage: tree [root]")
+	} else if len(args) == 0 {
+
+	} else if oid, err := cmd.ParseOID(args[0]); err != nil {
+		return fmt.Errorf("Invalid root %v: %v", args[0], err)
+	} else {
+		root = oid
+	}
+
+	var tree = mibs.Tree(root)
+
+	if root == nil {
+		// top-level node without any ID
+		for _, node := range tree.Children {
+			printTree(node, 0)
+		}
+	} else {
+		printTree(tree, 0)
+	}
+
+	return nil
+}
+
+// Usage: snmpmib search QUERY
+func snmpmibSearch(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: search <query>")
+	}
+
+	for _, result := range mibs.Search(args[0]) {
+		fmt.Printf("%v %v (%v)\n", result.ID, result.ID.OID, result.Match)
+	}
+
+	return nil
+}
+
+func snmpmib(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("Usage: [options] tree [root] | search <query>")
+	}
+
+	switch args[0] {
+	case "tree":
+		return snmpmibTree(args[1:])
+	case "search":
+		return snmpmibSearch(args[1:])
+	default:
+		return fmt.Errorf("Unknown command: %v", args[0])
+	}
+}
+
+func main() {
+	options.Main(snmpmib)
+}
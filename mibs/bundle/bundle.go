@@ -0,0 +1,21 @@
+// Package bundle embeds a curated set of the most common MIBs.
+//
+// Importing this package registers the bundle for loading by mibs.Options.LoadMIBs:
+//
+//	import _ "github.com/qmsk/snmpbot/mibs/bundle"
+//
+// The MIB JSON files are in the same format as generated by scripts/mib-import.py.
+package bundle
+
+import (
+	"embed"
+	"github.com/qmsk/snmpbot/mibs"
+	_ "github.com/qmsk/snmpbot/mibs/bridge_mib"
+)
+
+//go:embed *.json
+var FS embed.FS
+
+func init() {
+	mibs.RegisterBundle("bundle", FS)
+}
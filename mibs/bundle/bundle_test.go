@@ -0,0 +1,94 @@
+package bundle
+
+import (
+	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	var registry = mibs.NewRegistry()
+
+	if err := registry.LoadSources(mibs.FSSource("bundle", FS)); err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+
+	for _, name := range []string{
+		"SNMPv2-MIB",
+		"IF-MIB",
+		"IP-MIB",
+		"BRIDGE-MIB",
+		"Q-BRIDGE-MIB",
+		"ENTITY-MIB",
+		"HOST-RESOURCES-MIB",
+		"LLDP-MIB",
+	} {
+		if _, err := registry.ResolveMIB(name); err != nil {
+			t.Errorf("ResolveMIB %v: %v", name, err)
+		}
+	}
+}
+
+func TestBundleResolve(t *testing.T) {
+	var registry = mibs.NewRegistry()
+
+	if err := registry.LoadSources(mibs.FSSource("bundle", FS)); err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+
+	if object, err := registry.ResolveObject("SNMPv2-MIB::sysDescr"); err != nil {
+		t.Errorf("ResolveObject: %v", err)
+	} else {
+		assert.Equal(t, ".1.3.6.1.2.1.1.1", object.OID.String())
+	}
+
+	if table, err := registry.ResolveTable("IF-MIB::ifXTable"); err != nil {
+		t.Errorf("ResolveTable: %v", err)
+	} else {
+		assert.Equal(t, "IF-MIB::ifIndex", table.IndexSyntax[0].String())
+	}
+
+	if table, err := registry.ResolveTable("Q-BRIDGE-MIB::dot1qPortVlanTable"); err != nil {
+		t.Errorf("ResolveTable: %v", err)
+	} else {
+		assert.Equal(t, "BRIDGE-MIB::dot1dBasePort", table.IndexSyntax[0].String())
+	}
+
+	if notification, err := registry.ResolveNotification("IF-MIB::linkDown"); err != nil {
+		t.Errorf("ResolveNotification: %v", err)
+	} else {
+		assert.Equal(t, 3, len(notification.Objects))
+	}
+
+	if id := registry.Lookup(snmp.MustParseOID(".1.3.6.1.2.1.2.2.1.2.1")); id.MIB == nil {
+		t.Errorf("Lookup: not found")
+	} else {
+		assert.Equal(t, "IF-MIB::ifDescr", id.String())
+	}
+}
+
+func TestBundleSearchDescription(t *testing.T) {
+	var registry = mibs.NewRegistry()
+
+	if err := registry.LoadSources(mibs.FSSource("bundle", FS)); err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+
+	var names []string
+
+	for _, result := range registry.Search("filtering database") {
+		assert.Equal(t, mibs.SearchDescription, result.Match, "%v match", result)
+
+		names = append(names, result.String())
+	}
+
+	assert.Contains(t, names, "Q-BRIDGE-MIB::dot1qFdbTable")
+	assert.Contains(t, names, "Q-BRIDGE-MIB::dot1qFdbDynamicCount")
+
+	if object, err := registry.ResolveObject("IF-MIB::ifAlias"); err != nil {
+		t.Errorf("ResolveObject: %v", err)
+	} else {
+		assert.NotEmpty(t, object.Description, "IF-MIB::ifAlias description")
+	}
+}
@@ -3,6 +3,7 @@ package mibs
 import (
 	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/snmp"
+	"io"
 )
 
 func MakeClient(c *client.Client) Client {
@@ -69,9 +70,63 @@ func (client Client) WalkObjects(objects []*Object, f func(*Object, IndexValues,
 }
 
 func (client Client) WalkTable(table *Table, f func(IndexValues, EntryValues, error) error) error {
-	return client.Client.WalkTable(table.EntryOIDs(), func(varBinds []snmp.VarBind) error {
+	return client.Client.WalkWithOptions(tableWalkOptions(table), func(varBinds []snmp.VarBind) error {
 		indexValues, entryValues, err := table.Unpack(varBinds)
 
 		return f(indexValues, entryValues, err)
 	})
 }
+
+func tableWalkOptions(table *Table) client.WalkOptions {
+	return client.WalkOptions{TableEntries: table.EntryOIDs(), TableMode: table.WalkMode}
+}
+
+// Walk at most limit table entries following the after index, or all entries if limit is zero.
+//
+// Returns the index of the last entry if the limit was reached and further entries follow, for use as the after index of the next page.
+// Returns a nil index once the walk is complete.
+func (client Client) WalkTablePage(table *Table, after snmp.OID, limit int, f func(IndexValues, EntryValues, error) error) (snmp.OID, error) {
+	var options = tableWalkOptions(table)
+
+	// size any GetBulk repetitions to the page, including the look-ahead entry
+	if limit > 0 {
+		options.MaxRows = uint(limit) + 1
+	}
+
+	var walker = client.Client.Walker(options)
+
+	if after != nil {
+		var cursor = table.EntryOIDs()
+
+		for i, oid := range cursor {
+			cursor[i] = oid.Extend(after...)
+		}
+
+		if err := walker.Resume(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	for count := 0; limit == 0 || count < limit; count++ {
+		if varBinds, err := walker.Next(); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		} else if err := f(table.Unpack(varBinds)); err != nil {
+			return nil, err
+		}
+	}
+
+	var cursor = walker.Cursor()
+
+	// look ahead for any following entry, to not return a next page for a table ending at the limit
+	if _, err := walker.Next(); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if len(cursor) == 0 {
+		return nil, nil
+	} else {
+		return snmp.OID(table.EntryOIDs()[0].Index(cursor[0])), nil
+	}
+}
@@ -0,0 +1,67 @@
+package mibs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClientWalkTablePage(t *testing.T, rows int, after snmp.OID, limit int) ([]string, snmp.OID) {
+	var table, _ = ResolveTable("TEST2-MIB::testTable")
+	var testName, _ = ResolveObject("TEST2-MIB::testName")
+	var varBinds []snmp.VarBind
+	var results []string
+	var next snmp.OID
+
+	for i := 1; i <= rows; i++ {
+		varBinds = append(varBinds, snmp.MakeVarBind(testName.OID.Extend(i), []byte(fmt.Sprintf("test%d", i))))
+	}
+
+	withTestContextClient(t, map[string][]snmp.VarBind{"public": varBinds}, func(client Client) {
+		var err error
+
+		next, err = client.WalkTablePage(table, after, limit, func(indexValues IndexValues, entryValues EntryValues, err error) error {
+			results = append(results, fmt.Sprintf("%v %v", indexValues, entryValues))
+
+			return err
+		})
+
+		assert.NoError(t, err)
+	})
+
+	return results, next
+}
+
+func TestClientWalkTablePage(t *testing.T) {
+	results, next := testClientWalkTablePage(t, 3, nil, 2)
+
+	assert.Equal(t, []string{"[1] [test1]", "[2] [test2]"}, results)
+	assert.Equal(t, snmp.OID{2}, next)
+
+	results, next = testClientWalkTablePage(t, 3, next, 2)
+
+	assert.Equal(t, []string{"[3] [test3]"}, results)
+	assert.Nil(t, next)
+}
+
+// a table ending at the limit does not have a next page
+func TestClientWalkTablePageLimit(t *testing.T) {
+	results, next := testClientWalkTablePage(t, 2, nil, 2)
+
+	assert.Equal(t, []string{"[1] [test1]", "[2] [test2]"}, results)
+	assert.Nil(t, next)
+
+	results, next = testClientWalkTablePage(t, 4, snmp.OID{2}, 2)
+
+	assert.Equal(t, []string{"[3] [test3]", "[4] [test4]"}, results)
+	assert.Nil(t, next)
+}
+
+func TestClientWalkTablePageUnlimited(t *testing.T) {
+	results, next := testClientWalkTablePage(t, 3, nil, 0)
+
+	assert.Equal(t, []string{"[1] [test1]", "[2] [test2]", "[3] [test3]"}, results)
+	assert.Nil(t, next)
+}
@@ -3,10 +3,9 @@ package mibs
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/snmp"
 	"io"
-	"os"
-	"path/filepath"
 )
 
 type ConfigID struct {
@@ -23,29 +22,21 @@ func (config ConfigID) resolve(mib *MIB) (ID, error) {
 }
 
 type MIBConfig struct {
-	OID     string
-	Name    string
-	Objects []ObjectConfig
-	Tables  []TableConfig
+	OID           string
+	Name          string
+	Objects       []ObjectConfig
+	Tables        []TableConfig
+	Notifications []NotificationConfig
 
 	oid snmp.OID
 }
 
-func (config MIBConfig) build() (MIB, error) {
+func (config MIBConfig) build() (*MIB, error) {
 	if oid, err := snmp.ParseOID(config.OID); err != nil {
-		return MIB{}, fmt.Errorf("Invalid OID for MIB %v: %v", config.Name, err)
+		return nil, fmt.Errorf("Invalid OID for MIB %v: %v", config.Name, err)
 	} else {
 		return makeMIB(config.Name, oid), nil
 	}
-
-}
-
-func (config MIBConfig) loadMIB() (*MIB, error) {
-	if buildMIB, err := config.build(); err != nil {
-		return nil, err
-	} else {
-		return registerMIB(buildMIB), nil
-	}
 }
 
 func (config MIBConfig) loadObjects(mib *MIB) error {
@@ -60,25 +51,20 @@ func (config MIBConfig) loadObjects(mib *MIB) error {
 	return nil
 }
 
-type loadContext struct {
-	entryMap    map[string]*Table // EntryName => *Table
-	augmentsMap map[string]string // EntryName => AugmentsEntry
-}
-
-func (config MIBConfig) loadTables(mib *MIB, loadContext loadContext) error {
+func (config MIBConfig) loadTables(mib *MIB, loader *loader) error {
 	for _, tableConfig := range config.Tables {
-		if table, err := tableConfig.build(mib); err != nil {
+		if table, err := tableConfig.build(mib, loader); err != nil {
 			return fmt.Errorf("Invalid Table %v: %v", tableConfig.Name, err)
 		} else {
-			loadContext.entryMap[mib.Name+"::"+tableConfig.EntryName] = mib.registerTable(table)
-			loadContext.augmentsMap[mib.Name+"::"+tableConfig.EntryName] = tableConfig.AugmentsEntry
+			loader.entryMap[mib.Name+"::"+tableConfig.EntryName] = mib.registerTable(table)
+			loader.augmentsMap[mib.Name+"::"+tableConfig.EntryName] = tableConfig.AugmentsEntry
 		}
 	}
 
 	return nil
 }
 
-func (config MIBConfig) loadTablesIndex(mib *MIB, loadContext loadContext) error {
+func (config MIBConfig) loadTablesIndex(mib *MIB, loader *loader) error {
 	for _, tableConfig := range config.Tables {
 		table := mib.ResolveTable(tableConfig.Name)
 
@@ -88,14 +74,14 @@ func (config MIBConfig) loadTablesIndex(mib *MIB, loadContext loadContext) error
 
 			// chase any chained augments
 			for {
-				if nextEntry := loadContext.augmentsMap[augmentsEntry]; nextEntry == "" {
+				if nextEntry := loader.augmentsMap[augmentsEntry]; nextEntry == "" {
 					break
 				} else {
 					augmentsEntry = nextEntry
 				}
 			}
 
-			if augmentsTable, ok := loadContext.entryMap[augmentsEntry]; !ok {
+			if augmentsTable, ok := loader.entryMap[augmentsEntry]; !ok {
 				return fmt.Errorf("Invalid table %v::%v AugmentsEntry=%v: not found", mib.Name, tableConfig.Name, tableConfig.AugmentsEntry)
 			} else if augmentsTable.IndexSyntax == nil {
 				return fmt.Errorf("Invalid table %v::%v AugmentsEntry=%v: no index syntax", mib.Name, tableConfig.Name, tableConfig.AugmentsEntry)
@@ -113,16 +99,30 @@ func (config MIBConfig) loadTablesIndex(mib *MIB, loadContext loadContext) error
 	return nil
 }
 
+func (config MIBConfig) loadNotifications(mib *MIB, loader *loader) error {
+	for _, notificationConfig := range config.Notifications {
+		if notification, err := notificationConfig.build(mib, loader); err != nil {
+			return fmt.Errorf("Invalid Notification %v: %v", notificationConfig.Name, err)
+		} else {
+			mib.registerNotification(notification)
+		}
+	}
+
+	return nil
+}
+
 type ObjectConfig struct {
 	ConfigID
 	Syntax        string
 	SyntaxOptions json.RawMessage // TODO
 	NotAccessible bool
+	Description   string
 }
 
 func (config ObjectConfig) build(mib *MIB) (Object, error) {
 	var object = Object{
 		NotAccessible: config.NotAccessible,
+		Description:   config.Description,
 	}
 
 	if id, err := config.resolve(mib); err != nil {
@@ -155,11 +155,15 @@ type TableConfig struct {
 	EntryObjects  []string
 	EntryName     string
 	AugmentsEntry string // map IndexObjects from table with EntryName
+	Description   string
+	WalkMode      client.TableWalkMode // "auto", "rows" or "columns"
 }
 
-func (config TableConfig) build(mib *MIB) (Table, error) {
+func (config TableConfig) build(mib *MIB, loader *loader) (Table, error) {
 	var table = Table{
 		EntrySyntax: make(EntrySyntax, 0),
+		Description: config.Description,
+		WalkMode:    config.WalkMode,
 	}
 
 	if id, err := config.resolve(mib); err != nil {
@@ -172,7 +176,7 @@ func (config TableConfig) build(mib *MIB) (Table, error) {
 		table.IndexSyntax = make(IndexSyntax, len(config.IndexObjects))
 
 		for i, indexName := range config.IndexObjects {
-			if indexObject, err := ResolveObject(indexName); err != nil {
+			if indexObject, err := loader.resolveObject(indexName); err != nil {
 				return table, fmt.Errorf("Invalid IndexObject %v: %v", indexName, err)
 			} else {
 				table.IndexSyntax[i] = indexObject
@@ -181,7 +185,7 @@ func (config TableConfig) build(mib *MIB) (Table, error) {
 	}
 
 	for _, entryName := range config.EntryObjects {
-		if entryObject, err := ResolveObject(entryName); err != nil {
+		if entryObject, err := loader.resolveObject(entryName); err != nil {
 			return table, fmt.Errorf("Unknown EntryObject %v: %v", entryName, err)
 		} else if entryObject.NotAccessible {
 			continue
@@ -193,134 +197,41 @@ func (config TableConfig) build(mib *MIB) (Table, error) {
 	return table, nil
 }
 
-type configWalkFunc func(config MIBConfig, path string) error
-
-func walkJSON(r io.Reader, handler configWalkFunc, path string) error {
-	var config MIBConfig
-
-	if err := json.NewDecoder(r).Decode(&config); err != nil {
-		return err
-	}
-
-	return handler(config, path)
+type NotificationConfig struct {
+	ConfigID
+	Objects []string
 }
 
-func walkFile(file *os.File, handler configWalkFunc) error {
-	//log.Printf("Load MIB from file: %v", file.Name())
-
-	switch ext := filepath.Ext(file.Name()); ext {
-	case ".json":
-		return walkJSON(file, handler, file.Name())
-	default:
-		return fmt.Errorf("Unknown MIB file extension: %v", ext)
+func (config NotificationConfig) build(mib *MIB, loader *loader) (Notification, error) {
+	var notification = Notification{
+		Objects: make([]*Object, len(config.Objects)),
 	}
-}
-
-func walkPath(path string, handler configWalkFunc) error {
-	if file, err := os.Open(path); err != nil {
-		return err
-	} else if fileInfo, err := file.Stat(); err != nil {
-		return err
-	} else if fileInfo.IsDir() {
-		log.Infof("Load MIBs from directory: %v", path)
-
-		if names, err := file.Readdirnames(0); err != nil {
-			return err
-		} else {
-			for _, name := range names {
-				if name[0] == '.' {
-					continue
-				}
 
-				if err := walkPath(filepath.Join(path, name), handler); err != nil {
-					return err
-				}
-			}
-		}
+	if id, err := config.resolve(mib); err != nil {
+		return notification, err
 	} else {
-		return walkFile(file, handler)
+		notification.ID = id
 	}
 
-	return nil
-}
-
-func walkPathMulti(path string, handlers ...configWalkFunc) error {
-	for _, handler := range handlers {
-		if err := walkPath(path, handler); err != nil {
-			return err
+	for i, objectName := range config.Objects {
+		if object, err := loader.resolveObject(objectName); err != nil {
+			return notification, fmt.Errorf("Unknown Object %v: %v", objectName, err)
+		} else {
+			notification.Objects[i] = object
 		}
 	}
 
-	return nil
+	return notification, nil
 }
 
-// Load and register multiple MIBs recursively from the given filesystem path.
-//
-// References to other MIBs are resolved via multiple passes, MIB ordering does not matter.
-func Load(path string) error {
-	var loadContext = loadContext{
-		entryMap:    make(map[string]*Table),
-		augmentsMap: make(map[string]string),
-	}
-
-	return walkPathMulti(path,
-		func(mibConfig MIBConfig, path string) error {
-			if mib, err := mibConfig.loadMIB(); err != nil {
-				return fmt.Errorf("Failed to load MIB from %v: %v", path, err)
-			} else if err := mibConfig.loadObjects(mib); err != nil {
-				return fmt.Errorf("Failed to load MIB %v objects from %v: %v", mib, path, err)
-			} else {
-				log.Infof("Load MIB %v from %v with %d objects", mib, path, len(mib.objects))
-
-				return nil
-			}
-		},
-		func(mibConfig MIBConfig, path string) error {
-			if mib, err := ResolveMIB(mibConfig.Name); err != nil {
-				return fmt.Errorf("Failed to resolve MIB from %v: %v", path, err)
-			} else if err := mibConfig.loadTables(mib, loadContext); err != nil {
-				return fmt.Errorf("Failed to load MIB %v tables from %v: %v", mib, path, err)
-			} else {
-				log.Infof("Load MIB %v from %v with %d tables", mib, path, len(mib.tables))
-
-				return nil
-			}
-		},
-		func(mibConfig MIBConfig, path string) error {
-			if mib, err := ResolveMIB(mibConfig.Name); err != nil {
-				return fmt.Errorf("Failed to resolve MIB from %v: %v", path, err)
-			} else if err := mibConfig.loadTablesIndex(mib, loadContext); err != nil {
-				return fmt.Errorf("Failed to load MIB %v tables from %v: %v", mib, path, err)
-			} else {
-				return nil
-			}
-		},
-	)
-}
+type configWalkFunc func(config MIBConfig, path string) error
 
-// Load a single MIB, and return it.
-//
-// Any other MIBs referred to by this MIB must already have been loaded.
-func LoadMIB(r io.Reader) (*MIB, error) {
-	var mibConfig MIBConfig
-	var loadContext = loadContext{
-		entryMap:    make(map[string]*Table),
-		augmentsMap: make(map[string]string),
-	}
+func walkJSON(r io.Reader, handler configWalkFunc, path string) error {
+	var config MIBConfig
 
-	if err := json.NewDecoder(r).Decode(&mibConfig); err != nil {
-		return nil, err
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return err
 	}
 
-	if mib, err := mibConfig.loadMIB(); err != nil {
-		return mib, fmt.Errorf("Failed to load MIB: %v", err)
-	} else if err := mibConfig.loadObjects(mib); err != nil {
-		return mib, fmt.Errorf("Failed to load MIB %v objects: %v", mib, err)
-	} else if err := mibConfig.loadTables(mib, loadContext); err != nil {
-		return mib, fmt.Errorf("Failed to load MIB %v tables: %v", mib, err)
-	} else if err := mibConfig.loadTablesIndex(mib, loadContext); err != nil {
-		return mib, fmt.Errorf("Failed to load MIB %v tables: %v", mib, err)
-	} else {
-		return mib, nil
-	}
+	return handler(config, path)
 }
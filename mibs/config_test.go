@@ -2,6 +2,7 @@ package mibs
 
 import (
 	"fmt"
+	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/snmp"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -63,6 +64,16 @@ func TestConfigResolveTable(t *testing.T) {
 		assert.Equal(t, "TEST2-MIB::testTable", table.String())
 		assert.Equal(t, IndexSyntax{mib.ResolveObject("testID")}, table.IndexSyntax)
 		assert.Equal(t, EntrySyntax{mib.ResolveObject("testName")}, table.EntrySyntax)
+		assert.Equal(t, client.TableWalkAuto, table.WalkMode)
+	}
+}
+
+func TestConfigResolveTableWalkMode(t *testing.T) {
+	if table, err := ResolveTable("TEST2-MIB::testTable2"); err != nil {
+		t.Errorf("ResolveTable TEST2-MIB::testTable2: %v", err)
+	} else {
+		assert.Equal(t, client.TableWalkColumns, table.WalkMode)
+		assert.Equal(t, client.TableWalkColumns, tableWalkOptions(table).TableMode)
 	}
 }
 
@@ -182,3 +193,21 @@ func TestConfigObjectAugmentsIndexSyntax(t *testing.T) {
 		assert.Equal(t, IndexSyntax{mib.ResolveObject("testID")}, object.IndexSyntax)
 	}
 }
+
+func TestConfigResolveNotification(t *testing.T) {
+	mib := LookupMIB(snmp.OID{1, 0, 2})
+
+	if notification, err := ResolveNotification("TEST2-MIB::testNotification"); err != nil {
+		t.Errorf("ResolveNotification TEST2-MIB::testNotification: %v", err)
+	} else {
+		assert.Equal(t, "TEST2-MIB::testNotification", notification.String())
+		assert.Equal(t, []*Object{mib.ResolveObject("testName"), mib.ResolveObject("testEnum")}, notification.Objects)
+		assert.Equal(t, notification, LookupNotification(snmp.OID{1, 0, 2, 0, 1}))
+	}
+}
+
+func TestConfigResolveNotificationError(t *testing.T) {
+	_, err := ResolveNotification("TEST2-MIB::testTable")
+
+	assert.EqualError(t, err, "Not a notification: TEST2-MIB::testTable")
+}
@@ -0,0 +1,83 @@
+package mibs
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+	"strings"
+)
+
+// SNMP context for walking per-context tables, e.g. the per-VLAN BRIDGE-MIB::dot1dTpFdbTable using community@vlan.
+//
+// Contexts are identified by the index of an entry in a context table, e.g. Q-BRIDGE-MIB::dot1qVlanStaticTable.
+type Context struct {
+	Name        string // community@context suffix
+	Table       *Table // context table
+	IndexValues IndexValues
+}
+
+func (context Context) String() string {
+	return context.Name
+}
+
+func makeContextName(index []int) string {
+	var strs = make([]string, len(index))
+
+	for i, id := range index {
+		strs[i] = fmt.Sprintf("%d", id)
+	}
+
+	return strings.Join(strs, ".")
+}
+
+// Walk the contexts from the index of each context table entry, using only the first table column.
+func (client Client) WalkContexts(table *Table) ([]Context, error) {
+	var contexts []Context
+
+	if len(table.EntrySyntax) == 0 {
+		return nil, fmt.Errorf("Invalid context table %v without any entry objects", table)
+	}
+
+	var entryObject = table.EntrySyntax[0]
+
+	if err := client.Client.WalkTable([]snmp.OID{entryObject.OID}, func(varBinds []snmp.VarBind) error {
+		if err := varBinds[0].ErrorValue(); err != nil {
+			return nil
+		} else if index := entryObject.OID.Index(varBinds[0].OID()); index == nil {
+			return fmt.Errorf("Invalid VarBind[%v] OID for %v", varBinds[0].OID(), entryObject)
+		} else if indexValues, err := table.IndexSyntax.UnpackIndex(index); err != nil {
+			return fmt.Errorf("Invalid context index for %v: %v", table, err)
+		} else {
+			contexts = append(contexts, Context{Name: makeContextName(index), Table: table, IndexValues: indexValues})
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return contexts, nil
+}
+
+// Walk the table for each of the contexts in turn, see WalkTable.
+//
+// Any error walking a context is passed to f, continuing with the next context.
+func (client Client) WalkTableContexts(table *Table, contexts []Context, f func(Context, IndexValues, EntryValues, error) error) error {
+	for _, context := range contexts {
+		var contextClient = MakeClient(client.Client.Context(context.Name))
+		var contextErr error
+
+		if err := contextClient.WalkTable(table, func(indexValues IndexValues, entryValues EntryValues, err error) error {
+			contextErr = f(context, indexValues, entryValues, err)
+
+			return contextErr
+		}); contextErr != nil {
+			return contextErr
+		} else if err != nil {
+			if err := f(context, nil, nil, fmt.Errorf("Context %v: %v", context, err)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
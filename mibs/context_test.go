@@ -0,0 +1,202 @@
+package mibs
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/qmsk/snmpbot/client"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+)
+
+type testContextAddr string
+
+func (addr testContextAddr) Network() string {
+	return "test"
+}
+
+func (addr testContextAddr) String() string {
+	return string(addr)
+}
+
+// Answer GetNext requests from the sorted VarBinds for each community
+type testContextTransport struct {
+	agents   map[string][]snmp.VarBind
+	recvChan chan client.IO
+}
+
+func (transport testContextTransport) Resolve(addr string) (net.Addr, error) {
+	return testContextAddr(addr), nil
+}
+
+func (transport testContextTransport) next(community string, oid snmp.OID) snmp.VarBind {
+	for _, varBind := range transport.agents[community] {
+		if varBind.OID().Compare(oid) > 0 {
+			return varBind
+		}
+	}
+
+	return snmp.MakeVarBind(oid, snmp.EndOfMibViewValue)
+}
+
+func (transport testContextTransport) Send(send client.IO) error {
+	var pdu = send.PDU.(snmp.GenericPDU)
+	var recv = client.IO{
+		Addr:    send.Addr,
+		Packet:  send.Packet,
+		PDUMeta: snmp.PDUMeta{PDUType: snmp.GetResponseType, RequestID: send.RequestID},
+	}
+	var varBinds []snmp.VarBind
+
+	if _, ok := transport.agents[string(send.Packet.Community)]; !ok {
+		recv.PDU = snmp.GenericPDU{ErrorStatus: snmp.GenericError, ErrorIndex: 1, VarBinds: pdu.VarBinds}
+
+		go func() {
+			transport.recvChan <- recv
+		}()
+
+		return nil
+	}
+
+	for _, varBind := range pdu.VarBinds {
+		varBinds = append(varBinds, transport.next(string(send.Packet.Community), varBind.OID()))
+	}
+
+	recv.PDU = snmp.GenericPDU{VarBinds: varBinds}
+
+	go func() {
+		transport.recvChan <- recv
+	}()
+
+	return nil
+}
+
+func (transport testContextTransport) Recv() (client.IO, error) {
+	if io, ok := <-transport.recvChan; !ok {
+		return io, client.EOF
+	} else {
+		return io, nil
+	}
+}
+
+func (transport testContextTransport) Close() error {
+	close(transport.recvChan)
+
+	return nil
+}
+
+func withTestContextClient(t *testing.T, agents map[string][]snmp.VarBind, f func(Client)) {
+	var engine = client.NewEngine(testContextTransport{agents: agents, recvChan: make(chan client.IO)})
+	var options = client.Options{Community: "public", Timeout: 100 * time.Millisecond, NoBulk: true}
+
+	go engine.Run()
+	defer engine.Close()
+
+	if c, err := client.NewClient(engine, client.Config{Options: options, Address: "test"}); err != nil {
+		t.Fatalf("NewClient: %v", err)
+	} else {
+		f(MakeClient(c))
+	}
+}
+
+func TestClientWalkTableContexts(t *testing.T) {
+	var table, _ = ResolveTable("TEST2-MIB::testTable")
+	var testName, _ = ResolveObject("TEST2-MIB::testName")
+
+	withTestContextClient(t, map[string][]snmp.VarBind{
+		"public": []snmp.VarBind{
+			snmp.MakeVarBind(testName.OID.Extend(1), []byte("default1")),
+			snmp.MakeVarBind(testName.OID.Extend(10), []byte("default10")),
+		},
+		"public@1": []snmp.VarBind{
+			snmp.MakeVarBind(testName.OID.Extend(2), []byte("context1")),
+		},
+		"public@10": []snmp.VarBind{
+			snmp.MakeVarBind(testName.OID.Extend(3), []byte("context10")),
+			snmp.MakeVarBind(testName.OID.Extend(4), []byte("context10")),
+		},
+	}, func(client Client) {
+		var results []string
+
+		contexts, err := client.WalkContexts(table)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Context{
+			Context{Name: "1", Table: table, IndexValues: IndexValues{Integer(1)}},
+			Context{Name: "10", Table: table, IndexValues: IndexValues{Integer(10)}},
+		}, contexts)
+
+		assert.NoError(t, client.WalkTableContexts(table, contexts, func(context Context, indexValues IndexValues, entryValues EntryValues, err error) error {
+			results = append(results, fmt.Sprintf("%v %v %v", context, indexValues, entryValues))
+
+			return err
+		}))
+
+		assert.Equal(t, []string{"1 [2] [context1]", "10 [3] [context10]", "10 [4] [context10]"}, results)
+		assert.Equal(t, uint64(3+2+3), client.Requests())
+	})
+}
+
+func TestClientWalkTableContextsError(t *testing.T) {
+	var table, _ = ResolveTable("TEST2-MIB::testTable")
+	var testName, _ = ResolveObject("TEST2-MIB::testName")
+
+	withTestContextClient(t, map[string][]snmp.VarBind{
+		"public@10": []snmp.VarBind{
+			snmp.MakeVarBind(testName.OID.Extend(3), []byte("context10")),
+		},
+	}, func(client Client) {
+		var contexts = []Context{
+			Context{Name: "1", Table: table, IndexValues: IndexValues{Integer(1)}},
+			Context{Name: "10", Table: table, IndexValues: IndexValues{Integer(10)}},
+		}
+		var results []string
+		var errs []error
+
+		assert.NoError(t, client.WalkTableContexts(table, contexts, func(context Context, indexValues IndexValues, entryValues EntryValues, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				results = append(results, fmt.Sprintf("%v %v %v", context, indexValues, entryValues))
+			}
+
+			return nil
+		}))
+
+		if assert.Len(t, errs, 1) {
+			assert.Contains(t, errs[0].Error(), "Context 1: ")
+		}
+		assert.Equal(t, []string{"10 [3] [context10]"}, results)
+	})
+}
+
+func TestClientWalkTableContextsStop(t *testing.T) {
+	var table, _ = ResolveTable("TEST2-MIB::testTable")
+	var testName, _ = ResolveObject("TEST2-MIB::testName")
+
+	withTestContextClient(t, map[string][]snmp.VarBind{
+		"public@1": []snmp.VarBind{
+			snmp.MakeVarBind(testName.OID.Extend(2), []byte("context1")),
+		},
+		"public@10": []snmp.VarBind{
+			snmp.MakeVarBind(testName.OID.Extend(3), []byte("context10")),
+		},
+	}, func(client Client) {
+		var contexts = []Context{
+			Context{Name: "1", Table: table, IndexValues: IndexValues{Integer(1)}},
+			Context{Name: "10", Table: table, IndexValues: IndexValues{Integer(10)}},
+		}
+		var stopErr = fmt.Errorf("stop")
+		var results []string
+
+		assert.Equal(t, stopErr, client.WalkTableContexts(table, contexts, func(context Context, indexValues IndexValues, entryValues EntryValues, err error) error {
+			results = append(results, fmt.Sprintf("%v %v %v", context, indexValues, entryValues))
+
+			return stopErr
+		}))
+
+		assert.Equal(t, []string{"1 [2] [context1]"}, results)
+	})
+}
@@ -27,6 +27,11 @@ func (id ID) String() string {
 	}
 }
 
+// Test if this is the top-level ID of a MIB
+func (id ID) IsMIB() bool {
+	return id.MIB != nil && id.Name == "" && id.MIB.OID.Equals(id.OID)
+}
+
 func (id ID) MakeID(name string, ids ...int) ID {
 	return ID{id.MIB, name, id.OID.Extend(ids...)}
 }
@@ -58,3 +63,22 @@ func (id ID) Table() *Table {
 		return id.MIB.Table(id)
 	}
 }
+
+func (id ID) Notification() *Notification {
+	if id.MIB == nil {
+		return nil
+	} else {
+		return id.MIB.Notification(id)
+	}
+}
+
+// Returns the object/table description, if any
+func (id ID) Description() string {
+	if object := id.Object(); object != nil {
+		return object.Description
+	} else if table := id.Table(); table != nil {
+		return table.Description
+	} else {
+		return ""
+	}
+}
@@ -0,0 +1,290 @@
+package mibs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Staged loading of MIBs into a Registry.
+//
+// The loaded MIBs are resolved against each other before the registry, and only get registered
+// once all of them have been loaded without errors.
+type loader struct {
+	registry *Registry
+	replace  bool
+	mibs     map[string]*MIB
+
+	entryMap    map[string]*Table // EntryName => *Table
+	augmentsMap map[string]string // EntryName => AugmentsEntry
+}
+
+func (registry *Registry) newLoader(replace bool) *loader {
+	return &loader{
+		registry:    registry,
+		replace:     replace,
+		mibs:        make(map[string]*MIB),
+		entryMap:    make(map[string]*Table),
+		augmentsMap: make(map[string]string),
+	}
+}
+
+func (loader *loader) resolveMIB(name string) (*MIB, error) {
+	if mib, ok := loader.mibs[name]; ok {
+		return mib, nil
+	} else {
+		return loader.registry.ResolveMIB(name)
+	}
+}
+
+func (loader *loader) resolveObject(name string) (*Object, error) {
+	return resolveObject(name, loader.resolveMIB, nil)
+}
+
+func (loader *loader) loadMIB(config MIBConfig) (*MIB, error) {
+	if _, exists := loader.mibs[config.Name]; exists {
+		return nil, fmt.Errorf("Duplicate MIB: %v", config.Name)
+	} else if _, err := loader.registry.ResolveMIB(config.Name); err == nil && !loader.replace {
+		return nil, fmt.Errorf("MIB already loaded: %v", config.Name)
+	} else if mib, err := config.build(); err != nil {
+		return nil, err
+	} else {
+		loader.mibs[mib.Name] = mib
+
+		return mib, nil
+	}
+}
+
+func (loader *loader) pendingMIB(config MIBConfig) (*MIB, error) {
+	if mib, ok := loader.mibs[config.Name]; !ok {
+		return nil, fmt.Errorf("MIB not loaded: %v", config.Name)
+	} else {
+		return mib, nil
+	}
+}
+
+// Load a single MIB, with all references to other MIBs already loaded.
+func (loader *loader) loadConfig(config MIBConfig) (*MIB, error) {
+	if mib, err := loader.loadMIB(config); err != nil {
+		return mib, fmt.Errorf("Failed to load MIB: %v", err)
+	} else if err := config.loadObjects(mib); err != nil {
+		return mib, fmt.Errorf("Failed to load MIB %v objects: %v", mib, err)
+	} else if err := config.loadTables(mib, loader); err != nil {
+		return mib, fmt.Errorf("Failed to load MIB %v tables: %v", mib, err)
+	} else if err := config.loadTablesIndex(mib, loader); err != nil {
+		return mib, fmt.Errorf("Failed to load MIB %v tables: %v", mib, err)
+	} else if err := config.loadNotifications(mib, loader); err != nil {
+		return mib, fmt.Errorf("Failed to load MIB %v notifications: %v", mib, err)
+	} else {
+		return mib, nil
+	}
+}
+
+// MIB config read from a source
+type sourceConfig struct {
+	MIBConfig
+	path   string
+	source int
+}
+
+// Read the MIB configs from the given sources, in order of precedence.
+//
+// A MIB in an earlier source overrides any MIB with the same name in later sources.
+// Multiple MIBs with the same name within the same source are an error.
+func (loader *loader) readSources(sources []Source) ([]sourceConfig, error) {
+	var configs []sourceConfig
+	var configMap = make(map[string]sourceConfig)
+
+	for i, source := range sources {
+		if err := source.walk(func(mibConfig MIBConfig, path string) error {
+			var config = sourceConfig{mibConfig, path, i}
+
+			if other, exists := configMap[config.Name]; !exists {
+				configMap[config.Name] = config
+				configs = append(configs, config)
+			} else if other.source == config.source {
+				return fmt.Errorf("Duplicate MIB %v in %v and %v", config.Name, other.path, config.path)
+			} else {
+				log.Infof("Skip MIB %v from %v: overridden by %v", config.Name, config.path, other.path)
+			}
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return configs, nil
+}
+
+// Load multiple MIBs, in multiple passes to resolve references between MIBs.
+func (loader *loader) loadConfigs(configs []sourceConfig) error {
+	for _, config := range configs {
+		if mib, err := loader.loadMIB(config.MIBConfig); err != nil {
+			return fmt.Errorf("Failed to load MIB from %v: %v", config.path, err)
+		} else if err := config.loadObjects(mib); err != nil {
+			return fmt.Errorf("Failed to load MIB %v objects from %v: %v", mib, config.path, err)
+		} else {
+			log.Infof("Load MIB %v from %v with %d objects", mib, config.path, len(mib.objects))
+		}
+	}
+
+	for _, config := range configs {
+		if mib, err := loader.pendingMIB(config.MIBConfig); err != nil {
+			return fmt.Errorf("Failed to resolve MIB from %v: %v", config.path, err)
+		} else if err := config.loadTables(mib, loader); err != nil {
+			return fmt.Errorf("Failed to load MIB %v tables from %v: %v", mib, config.path, err)
+		} else if err := config.loadNotifications(mib, loader); err != nil {
+			return fmt.Errorf("Failed to load MIB %v notifications from %v: %v", mib, config.path, err)
+		} else {
+			log.Infof("Load MIB %v from %v with %d tables and %d notifications", mib, config.path, len(mib.tables), len(mib.notifications))
+		}
+	}
+
+	for _, config := range configs {
+		if mib, err := loader.pendingMIB(config.MIBConfig); err != nil {
+			return fmt.Errorf("Failed to resolve MIB from %v: %v", config.path, err)
+		} else if err := config.loadTablesIndex(mib, loader); err != nil {
+			return fmt.Errorf("Failed to load MIB %v tables from %v: %v", mib, config.path, err)
+		}
+	}
+
+	return nil
+}
+
+// Register the loaded MIBs, replacing any existing MIBs if allowed.
+//
+// Either all or none of the loaded MIBs are registered.
+func (loader *loader) commit() error {
+	var registry = loader.registry
+	var exclude = make(map[string]bool, len(loader.mibs))
+
+	for name, _ := range loader.mibs {
+		exclude[name] = true
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for name, mib := range loader.mibs {
+		if existing, exists := registry.mibs[name]; !exists {
+			continue
+		} else if !loader.replace {
+			return fmt.Errorf("MIB already loaded: %v", name)
+		} else if references := registry.references(existing, func(object *Object) bool {
+			return mib.Object(ID{OID: object.OID}) != nil
+		}, exclude); len(references) > 0 {
+			return ReferenceError{MIB: name, References: references}
+		}
+	}
+
+	for name, mib := range loader.mibs {
+		if existing, exists := registry.mibs[name]; exists {
+			registry.unregisterMIB(existing)
+		}
+
+		if err := registry.registerMIB(mib); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Load and register multiple MIBs recursively from the given sources, in order of precedence.
+//
+// A MIB in an earlier source overrides any MIB with the same name in any later sources.
+// References to other MIBs are resolved via multiple passes, MIB ordering does not matter.
+// Fails if any of the MIBs have already been loaded, in which case none of the MIBs are registered.
+func (registry *Registry) LoadSources(sources ...Source) error {
+	var loader = registry.newLoader(false)
+
+	if configs, err := loader.readSources(sources); err != nil {
+		return err
+	} else if err := loader.loadConfigs(configs); err != nil {
+		return err
+	}
+
+	return loader.commit()
+}
+
+// Load and register multiple MIBs recursively from the given filesystem path, see Registry.LoadSources.
+func (registry *Registry) Load(path string) error {
+	return registry.LoadSources(DirSource(path))
+}
+
+func (registry *Registry) loadConfig(config MIBConfig, replace bool) (*MIB, error) {
+	var loader = registry.newLoader(replace)
+
+	if mib, err := loader.loadConfig(config); err != nil {
+		return nil, err
+	} else if err := loader.commit(); err != nil {
+		return nil, err
+	} else {
+		return mib, nil
+	}
+}
+
+// Load and register a single MIB, and return it.
+//
+// Any other MIBs referred to by this MIB must already have been loaded.
+func (registry *Registry) LoadMIBConfig(config MIBConfig) (*MIB, error) {
+	return registry.loadConfig(config, false)
+}
+
+// Load and register a single MIB, replacing any existing MIB with the same name.
+//
+// Fails with a ReferenceError if any objects in the existing MIB that are referred to by tables or notifications
+// in other MIBs are missing from the new MIB. Those other MIBs continue to refer to the objects from the replaced MIB,
+// until they are also reloaded.
+func (registry *Registry) ReplaceMIBConfig(config MIBConfig) (*MIB, error) {
+	return registry.loadConfig(config, true)
+}
+
+func (registry *Registry) LoadMIB(r io.Reader) (*MIB, error) {
+	var config MIBConfig
+
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	return registry.LoadMIBConfig(config)
+}
+
+func (registry *Registry) ReplaceMIB(r io.Reader) (*MIB, error) {
+	var config MIBConfig
+
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	return registry.ReplaceMIBConfig(config)
+}
+
+// Load and register multiple MIBs into the DefaultRegistry, see Registry.LoadSources.
+func LoadSources(sources ...Source) error {
+	return DefaultRegistry.LoadSources(sources...)
+}
+
+// Load and register multiple MIBs into the DefaultRegistry, see Registry.Load.
+func Load(path string) error {
+	return DefaultRegistry.Load(path)
+}
+
+// Load a single MIB into the DefaultRegistry, see Registry.LoadMIB.
+func LoadMIB(r io.Reader) (*MIB, error) {
+	return DefaultRegistry.LoadMIB(r)
+}
+
+// Replace a single MIB in the DefaultRegistry, see Registry.ReplaceMIB.
+func ReplaceMIB(r io.Reader) (*MIB, error) {
+	return DefaultRegistry.ReplaceMIB(r)
+}
+
+func LoadMIBConfig(config MIBConfig) (*MIB, error) {
+	return DefaultRegistry.LoadMIBConfig(config)
+}
+
+func ReplaceMIBConfig(config MIBConfig) (*MIB, error) {
+	return DefaultRegistry.ReplaceMIBConfig(config)
+}
@@ -4,39 +4,63 @@ import (
 	"fmt"
 	"github.com/qmsk/snmpbot/snmp"
 	"regexp"
+	"sync"
 )
 
-func makeMIB(name string, oid snmp.OID) MIB {
-	return MIB{
+func makeMIB(name string, oid snmp.OID) *MIB {
+	var mib = MIB{
 		ID:       ID{OID: oid},
 		Name:     name,
 		registry: makeRegistry(),
 		objects:  make(map[IDKey]*Object),
 		tables:   make(map[IDKey]*Table),
+
+		notifications: make(map[IDKey]*Notification),
 	}
+
+	mib.ID.MIB = &mib
+
+	return &mib
 }
 
 type MIB struct {
 	ID
 	Name string // shadows ID.Name, which is empty
+
+	mutex  sync.RWMutex
+	parent *Registry // set once registered
 	registry
 
-	objects map[IDKey]*Object
-	tables  map[IDKey]*Table
+	objects       map[IDKey]*Object
+	tables        map[IDKey]*Table
+	notifications map[IDKey]*Notification
 }
 
 func (mib *MIB) String() string {
 	return mib.Name
 }
 
+func (mib *MIB) setRegistry(parent *Registry) {
+	mib.mutex.Lock()
+	defer mib.mutex.Unlock()
+
+	mib.parent = parent
+}
+
 func (mib *MIB) MakeID(name string, ids ...int) ID {
 	return ID{mib, name, mib.OID.Extend(ids...)}
 }
 
 func (mib *MIB) registerObject(object Object) *Object {
-	mibRegistry.registerOID(object.ID)
+	mib.mutex.Lock()
 	mib.registry.register(object.ID)
 	mib.objects[object.ID.Key()] = &object
+	var parent = mib.parent
+	mib.mutex.Unlock()
+
+	if parent != nil {
+		parent.registerID(object.ID)
+	}
 
 	return &object
 }
@@ -48,9 +72,15 @@ func (mib *MIB) RegisterObject(id ID, object Object) *Object {
 }
 
 func (mib *MIB) registerTable(table Table) *Table {
-	mibRegistry.registerOID(table.ID)
+	mib.mutex.Lock()
 	mib.registry.register(table.ID)
 	mib.tables[table.ID.Key()] = &table
+	var parent = mib.parent
+	mib.mutex.Unlock()
+
+	if parent != nil {
+		parent.registerID(table.ID)
+	}
 
 	return &table
 }
@@ -61,6 +91,26 @@ func (mib *MIB) RegisterTable(id ID, table Table) *Table {
 	return mib.registerTable(table)
 }
 
+func (mib *MIB) registerNotification(notification Notification) *Notification {
+	mib.mutex.Lock()
+	mib.registry.register(notification.ID)
+	mib.notifications[notification.ID.Key()] = &notification
+	var parent = mib.parent
+	mib.mutex.Unlock()
+
+	if parent != nil {
+		parent.registerID(notification.ID)
+	}
+
+	return &notification
+}
+
+func (mib *MIB) RegisterNotification(id ID, notification Notification) *Notification {
+	notification.ID = id
+
+	return mib.registerNotification(notification)
+}
+
 /* Resolve MIB-relative ID by human-readable name:
 ".1.0"
 "sysDescr"
@@ -103,6 +153,9 @@ func (mib *MIB) Resolve(name string) (ID, error) {
 }
 
 func (mib *MIB) ResolveName(name string) (ID, error) {
+	mib.mutex.RLock()
+	defer mib.mutex.RUnlock()
+
 	if id, ok := mib.registry.getName(name); !ok {
 		return ID{MIB: mib, Name: name}, fmt.Errorf("%v name not found: %v", mib.Name, name)
 	} else {
@@ -111,6 +164,9 @@ func (mib *MIB) ResolveName(name string) (ID, error) {
 }
 
 func (mib *MIB) Lookup(oid snmp.OID) ID {
+	mib.mutex.RLock()
+	defer mib.mutex.RUnlock()
+
 	if id, ok := mib.registry.getOID(oid); !ok {
 		return ID{MIB: mib, OID: oid}
 	} else {
@@ -118,11 +174,25 @@ func (mib *MIB) Lookup(oid snmp.OID) ID {
 	}
 }
 
+// Walk a snapshot of the MIB IDs, the MIB is not locked while calling f.
 func (mib *MIB) Walk(f func(ID)) {
-	mib.registry.walk(f)
+	var ids []ID
+
+	mib.mutex.RLock()
+	mib.registry.walk(func(id ID) {
+		ids = append(ids, id)
+	})
+	mib.mutex.RUnlock()
+
+	for _, id := range ids {
+		f(id)
+	}
 }
 
 func (mib *MIB) Object(id ID) *Object {
+	mib.mutex.RLock()
+	defer mib.mutex.RUnlock()
+
 	if object, ok := mib.objects[id.Key()]; !ok {
 		return nil
 	} else {
@@ -139,6 +209,9 @@ func (mib *MIB) ResolveObject(name string) *Object {
 }
 
 func (mib *MIB) Table(id ID) *Table {
+	mib.mutex.RLock()
+	defer mib.mutex.RUnlock()
+
 	if table, ok := mib.tables[id.Key()]; !ok {
 		return nil
 	} else {
@@ -154,6 +227,25 @@ func (mib *MIB) ResolveTable(name string) *Table {
 	}
 }
 
+func (mib *MIB) Notification(id ID) *Notification {
+	mib.mutex.RLock()
+	defer mib.mutex.RUnlock()
+
+	if notification, ok := mib.notifications[id.Key()]; !ok {
+		return nil
+	} else {
+		return notification
+	}
+}
+
+func (mib *MIB) ResolveNotification(name string) *Notification {
+	if id, err := mib.Resolve(name); err != nil {
+		return nil
+	} else {
+		return mib.Notification(id)
+	}
+}
+
 func (mib *MIB) FormatOID(oid snmp.OID) string {
 	if index := mib.OID.Index(oid); index == nil {
 		return oid.String()
@@ -6,41 +6,32 @@ import (
 	"regexp"
 )
 
-var mibRegistry = makeRegistry()
-
-func registerMIB(mib MIB) *MIB {
-	mib.ID.MIB = &mib
-
-	mibRegistry.registerName(mib.ID, mib.Name)
-
-	if mib.OID != nil {
-		mibRegistry.registerOID(mib.ID)
-	}
-
-	return &mib
-}
-
 func RegisterMIB(name string, oid ...int) *MIB {
-	return registerMIB(makeMIB(name, snmp.OID(oid)))
+	return DefaultRegistry.RegisterMIB(name, oid...)
 }
 
 func ResolveMIB(name string) (*MIB, error) {
-	if id, ok := mibRegistry.getName(name); !ok {
-		return nil, fmt.Errorf("MIB not found: %v", name)
-	} else {
-		return id.MIB, nil
-	}
+	return DefaultRegistry.ResolveMIB(name)
+}
+
+// Unload a previously loaded MIB from the DefaultRegistry.
+func UnloadMIB(mib *MIB) error {
+	return DefaultRegistry.UnloadMIB(mib)
 }
 
 func WalkMIBs(f func(mib *MIB)) {
-	mibRegistry.walk(func(id ID) {
-		if id.OID == nil {
+	DefaultRegistry.WalkMIBs(f)
+}
+
+func (registry *Registry) WalkMIBs(f func(mib *MIB)) {
+	for _, mib := range registry.listMIBs() {
+		if mib.OID == nil {
 			// skip MIBs without a top-level OID
-			return
+			continue
 		}
 
-		f(id.MIB)
-	})
+		f(mib)
+	}
 }
 
 /* Resolve ID by human-readable name:
@@ -49,10 +40,13 @@ func WalkMIBs(f func(mib *MIB)) {
 		"SNMPv2-MIB.1.0"
 		"SNMPv2-MIB::sysDescr"
 		"SNMPv2-MIB::sysDescr.0"
+		"sysDescr"
+		"sysdescr.0"
 */
 var resolveRegexp = regexp.MustCompile("^([^.:]+?)?(?:::([^.]+?))?([.][0-9.]+)?$")
 
-func Resolve(name string) (ID, error) {
+// The optional resolveName func is used for names without any MIB:: prefix that do not match any MIB.
+func resolve(name string, resolveMIB func(string) (*MIB, error), resolveName func(string) (ID, error)) (ID, error) {
 	var id ID
 	var nameMIB, nameID, nameOID string
 
@@ -66,11 +60,17 @@ func Resolve(name string) (ID, error) {
 
 	if nameMIB == "" {
 
-	} else if mib, err := ResolveMIB(nameMIB); err != nil {
-		return id, err
-	} else {
+	} else if mib, err := resolveMIB(nameMIB); err == nil {
 		id = mib.ID
 		id.Name = "" // fixup MIB.ID re-use of Name
+	} else if nameID != "" || resolveName == nil {
+		return id, err
+	} else if resolveID, nameErr := resolveName(nameMIB); nameErr == nil {
+		id = resolveID
+	} else if _, ambiguous := nameErr.(AmbiguousError); ambiguous {
+		return id, nameErr
+	} else {
+		return id, err
 	}
 
 	if nameID == "" {
@@ -98,8 +98,8 @@ func Resolve(name string) (ID, error) {
 	return id, nil
 }
 
-func ResolveObject(name string) (*Object, error) {
-	if id, err := Resolve(name); err != nil {
+func resolveObject(name string, resolveMIB func(string) (*MIB, error), resolveName func(string) (ID, error)) (*Object, error) {
+	if id, err := resolve(name, resolveMIB, resolveName); err != nil {
 		return nil, err
 	} else if id.MIB == nil {
 		return nil, fmt.Errorf("No MIB for name: %v", name)
@@ -110,8 +110,28 @@ func ResolveObject(name string) (*Object, error) {
 	}
 }
 
+func Resolve(name string) (ID, error) {
+	return DefaultRegistry.Resolve(name)
+}
+
+func (registry *Registry) Resolve(name string) (ID, error) {
+	return resolve(name, registry.ResolveMIB, registry.ResolveName)
+}
+
+func ResolveObject(name string) (*Object, error) {
+	return DefaultRegistry.ResolveObject(name)
+}
+
+func (registry *Registry) ResolveObject(name string) (*Object, error) {
+	return resolveObject(name, registry.ResolveMIB, registry.ResolveName)
+}
+
 func ResolveTable(name string) (*Table, error) {
-	if id, err := Resolve(name); err != nil {
+	return DefaultRegistry.ResolveTable(name)
+}
+
+func (registry *Registry) ResolveTable(name string) (*Table, error) {
+	if id, err := registry.Resolve(name); err != nil {
 		return nil, err
 	} else if id.MIB == nil {
 		return nil, fmt.Errorf("No MIB for name: %v", name)
@@ -124,23 +144,19 @@ func ResolveTable(name string) (*Table, error) {
 
 // Lookup ID by OID
 func LookupMIB(oid snmp.OID) *MIB {
-	if id, ok := mibRegistry.getOID(oid); !ok {
-		return nil
-	} else {
-		return id.MIB
-	}
+	return DefaultRegistry.LookupMIB(oid)
 }
 
 func Lookup(oid snmp.OID) ID {
-	if id, ok := mibRegistry.getOID(oid); !ok {
-		return ID{OID: oid}
-	} else {
-		return id
-	}
+	return DefaultRegistry.Lookup(oid)
 }
 
 func LookupObject(oid snmp.OID) *Object {
-	if id := Lookup(oid); id.MIB == nil {
+	return DefaultRegistry.LookupObject(oid)
+}
+
+func (registry *Registry) LookupObject(oid snmp.OID) *Object {
+	if id := registry.Lookup(oid); id.MIB == nil {
 		return nil
 	} else {
 		return id.MIB.Object(id)
@@ -148,13 +164,35 @@ func LookupObject(oid snmp.OID) *Object {
 }
 
 func Walk(f func(i ID)) {
-	mibRegistry.walk(func(id ID) {
-		id.MIB.Walk(f)
-	})
+	DefaultRegistry.Walk(f)
+}
+
+// Walk all IDs in all MIBs, in OID order.
+//
+// Walks a snapshot of the registered IDs, the registry is not locked while calling f.
+func (registry *Registry) Walk(f func(i ID)) {
+	for _, id := range registry.listTree(nil, false) {
+		f(id)
+	}
+}
+
+func WalkTree(root snmp.OID, f func(i ID)) {
+	DefaultRegistry.WalkTree(root, f)
+}
+
+// Walk all IDs within the given OID subtree, in OID order.
+func (registry *Registry) WalkTree(root snmp.OID, f func(i ID)) {
+	for _, id := range registry.listTree(root, false) {
+		f(id)
+	}
 }
 
 func WalkObjects(f func(object *Object)) {
-	Walk(func(id ID) {
+	DefaultRegistry.WalkObjects(f)
+}
+
+func (registry *Registry) WalkObjects(f func(object *Object)) {
+	registry.Walk(func(id ID) {
 		if object := id.MIB.Object(id); object != nil {
 			f(object)
 		}
@@ -162,7 +200,11 @@ func WalkObjects(f func(object *Object)) {
 }
 
 func WalkTables(f func(table *Table)) {
-	Walk(func(id ID) {
+	DefaultRegistry.WalkTables(f)
+}
+
+func (registry *Registry) WalkTables(f func(table *Table)) {
+	registry.Walk(func(id ID) {
 		if table := id.MIB.Table(id); table != nil {
 			f(table)
 		}
@@ -171,7 +213,11 @@ func WalkTables(f func(table *Table)) {
 
 // Lookup human-readable object name with optional index
 func ParseOID(name string) (snmp.OID, error) {
-	if id, err := Resolve(name); err != nil {
+	return DefaultRegistry.ParseOID(name)
+}
+
+func (registry *Registry) ParseOID(name string) (snmp.OID, error) {
+	if id, err := registry.Resolve(name); err != nil {
 		return nil, err
 	} else {
 		return id.OID, nil
@@ -179,5 +225,9 @@ func ParseOID(name string) (snmp.OID, error) {
 }
 
 func FormatOID(oid snmp.OID) string {
-	return Lookup(oid).FormatOID(oid)
+	return DefaultRegistry.FormatOID(oid)
+}
+
+func (registry *Registry) FormatOID(oid snmp.OID) string {
+	return registry.Lookup(oid).FormatOID(oid)
 }
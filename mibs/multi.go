@@ -0,0 +1,158 @@
+package mibs
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/client"
+	"github.com/qmsk/snmpbot/snmp"
+	"sync"
+	"time"
+)
+
+const DefaultMultiConcurrency = 10
+
+// The client interface used by MultiClient, implemented by Client.
+type MultiTarget interface {
+	String() string
+	Requests() uint64
+
+	Get(oids ...snmp.OID) ([]snmp.VarBind, error)
+	WalkObjects(objects []*Object, f func(*Object, IndexValues, Value, error) error) error
+	WalkTable(table *Table, f func(IndexValues, EntryValues, error) error) error
+}
+
+// Per-target result of a MultiClient operation.
+type MultiSummary struct {
+	Target   MultiTarget
+	Duration time.Duration
+	Error    error
+
+	// Requests made by the target client during the operation,
+	// including any concurrent requests made by other users of the same target client.
+	ClientRequests uint64
+}
+
+// Client for querying multiple agents, using at most Concurrency concurrent targets.
+type MultiClient struct {
+	Targets     []MultiTarget
+	Concurrency uint // default DefaultMultiConcurrency
+}
+
+func NewMultiClient(engine *client.Engine, configs []client.Config, concurrency uint) (*MultiClient, error) {
+	var multi = MultiClient{
+		Targets:     make([]MultiTarget, len(configs)),
+		Concurrency: concurrency,
+	}
+
+	for i, config := range configs {
+		if c, err := client.NewClient(engine, config); err != nil {
+			return nil, fmt.Errorf("NewClient %v: %v", config, err)
+		} else {
+			multi.Targets[i] = MakeClient(c)
+		}
+	}
+
+	return &multi, nil
+}
+
+func (multi MultiClient) concurrency() int {
+	if multi.Concurrency == 0 {
+		return DefaultMultiConcurrency
+	} else {
+		return int(multi.Concurrency)
+	}
+}
+
+func (multi MultiClient) run(target MultiTarget, f func(MultiTarget) error) MultiSummary {
+	var summary = MultiSummary{Target: target}
+	var startTime = time.Now()
+	var startRequests = target.Requests()
+
+	summary.Error = f(target)
+	summary.Duration = time.Now().Sub(startTime)
+	summary.ClientRequests = target.Requests() - startRequests
+
+	return summary
+}
+
+// Call f for each target, returning the summaries in the same order as the Targets.
+//
+// The function is called concurrently for different targets.
+func (multi MultiClient) Run(f func(index int, target MultiTarget) error) []MultiSummary {
+	var summaries = make([]MultiSummary, len(multi.Targets))
+	var indexChan = make(chan int)
+	var waitGroup sync.WaitGroup
+
+	for worker := 0; worker < multi.concurrency() && worker < len(multi.Targets); worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+
+			for index := range indexChan {
+				summaries[index] = multi.run(multi.Targets[index], func(target MultiTarget) error {
+					return f(index, target)
+				})
+			}
+		}()
+	}
+
+	for index := range multi.Targets {
+		indexChan <- index
+	}
+	close(indexChan)
+
+	waitGroup.Wait()
+
+	return summaries
+}
+
+// Get the OIDs from each target.
+//
+// The results are streamed to f, which is never called concurrently. Any error returned by f stops that target.
+func (multi MultiClient) Get(oids []snmp.OID, f func(MultiTarget, []snmp.VarBind, error) error) []MultiSummary {
+	var mutex sync.Mutex
+
+	return multi.Run(func(index int, target MultiTarget) error {
+		varBinds, err := target.Get(oids...)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if fErr := f(target, varBinds, err); fErr != nil {
+			return fErr
+		} else {
+			return err
+		}
+	})
+}
+
+// Walk the objects on each target, see Client.WalkObjects.
+//
+// The results are streamed to f, which is never called concurrently. Any error returned by f stops that target.
+func (multi MultiClient) WalkObjects(objects []*Object, f func(MultiTarget, *Object, IndexValues, Value, error) error) []MultiSummary {
+	var mutex sync.Mutex
+
+	return multi.Run(func(index int, target MultiTarget) error {
+		return target.WalkObjects(objects, func(object *Object, indexValues IndexValues, value Value, err error) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			return f(target, object, indexValues, value, err)
+		})
+	})
+}
+
+// Walk the table on each target, see Client.WalkTable.
+//
+// The results are streamed to f, which is never called concurrently. Any error returned by f stops that target.
+func (multi MultiClient) WalkTable(table *Table, f func(MultiTarget, IndexValues, EntryValues, error) error) []MultiSummary {
+	var mutex sync.Mutex
+
+	return multi.Run(func(index int, target MultiTarget) error {
+		return target.WalkTable(table, func(indexValues IndexValues, entryValues EntryValues, err error) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			return f(target, indexValues, entryValues, err)
+		})
+	})
+}
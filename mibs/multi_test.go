@@ -0,0 +1,183 @@
+package mibs
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testMultiTarget struct {
+	name     string
+	requests uint64
+	err      error
+	running  *int32
+	maxCount *int32
+}
+
+func (target *testMultiTarget) String() string {
+	return target.name
+}
+
+func (target *testMultiTarget) Requests() uint64 {
+	return atomic.LoadUint64(&target.requests)
+}
+
+func (target *testMultiTarget) request() error {
+	atomic.AddUint64(&target.requests, 1)
+
+	if target.running != nil {
+		var running = atomic.AddInt32(target.running, 1)
+
+		for {
+			if max := atomic.LoadInt32(target.maxCount); running <= max || atomic.CompareAndSwapInt32(target.maxCount, max, running) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(target.running, -1)
+	}
+
+	return target.err
+}
+
+func (target *testMultiTarget) Get(oids ...snmp.OID) ([]snmp.VarBind, error) {
+	if err := target.request(); err != nil {
+		return nil, err
+	}
+
+	var varBinds = make([]snmp.VarBind, len(oids))
+
+	for i, oid := range oids {
+		varBinds[i] = snmp.MakeVarBind(oid, []byte(target.name))
+	}
+
+	return varBinds, nil
+}
+
+func (target *testMultiTarget) WalkObjects(objects []*Object, f func(*Object, IndexValues, Value, error) error) error {
+	for _, object := range objects {
+		if err := target.request(); err != nil {
+			return err
+		} else if err := f(object, nil, target.name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (target *testMultiTarget) WalkTable(table *Table, f func(IndexValues, EntryValues, error) error) error {
+	if err := target.request(); err != nil {
+		return err
+	}
+
+	return f(nil, EntryValues{target.name}, nil)
+}
+
+func makeTestMultiClient(concurrency uint, targets ...*testMultiTarget) MultiClient {
+	var multi = MultiClient{Concurrency: concurrency}
+
+	for _, target := range targets {
+		multi.Targets = append(multi.Targets, target)
+	}
+
+	return multi
+}
+
+func TestMultiClientRun(t *testing.T) {
+	var running, maxCount int32
+	var targets []*testMultiTarget
+
+	for i := 0; i < 10; i++ {
+		targets = append(targets, &testMultiTarget{name: fmt.Sprintf("test%d", i), running: &running, maxCount: &maxCount})
+	}
+
+	var multi = makeTestMultiClient(3, targets...)
+	var mutex sync.Mutex
+	var called = make(map[int]bool)
+
+	var summaries = multi.Run(func(index int, target MultiTarget) error {
+		mutex.Lock()
+		called[index] = true
+		mutex.Unlock()
+
+		_, err := target.Get(snmp.OID{1, 3, 6, 1})
+
+		return err
+	})
+
+	assert.Len(t, called, 10)
+	assert.Len(t, summaries, 10)
+	assert.LessOrEqual(t, maxCount, int32(3), "concurrency")
+
+	for i, summary := range summaries {
+		assert.Equal(t, targets[i], summary.Target)
+		assert.Equal(t, uint64(1), summary.ClientRequests)
+		assert.NoError(t, summary.Error)
+	}
+}
+
+func TestMultiClientGet(t *testing.T) {
+	var oid = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+	var multi = makeTestMultiClient(0,
+		&testMultiTarget{name: "test1"},
+		&testMultiTarget{name: "test2", err: fmt.Errorf("Test error")},
+	)
+	var results = make(map[string]error)
+
+	var summaries = multi.Get([]snmp.OID{oid}, func(target MultiTarget, varBinds []snmp.VarBind, err error) error {
+		results[target.String()] = err
+
+		return nil
+	})
+
+	assert.Equal(t, map[string]error{"test1": nil, "test2": fmt.Errorf("Test error")}, results)
+	assert.NoError(t, summaries[0].Error)
+	assert.EqualError(t, summaries[1].Error, "Test error")
+}
+
+func TestMultiClientWalkObjects(t *testing.T) {
+	var objects = []*Object{&Object{ID: ID{Name: "test1"}}, &Object{ID: ID{Name: "test2"}}}
+	var multi = makeTestMultiClient(1,
+		&testMultiTarget{name: "host1"},
+		&testMultiTarget{name: "host2"},
+	)
+	var results []string
+
+	var summaries = multi.WalkObjects(objects, func(target MultiTarget, object *Object, indexValues IndexValues, value Value, err error) error {
+		results = append(results, fmt.Sprintf("%v %v", object.Name, value))
+
+		if object.Name == "test1" && value == "host2" {
+			return fmt.Errorf("Stop")
+		}
+
+		return nil
+	})
+
+	assert.Equal(t, []string{"test1 host1", "test2 host1", "test1 host2"}, results)
+	assert.Equal(t, uint64(2), summaries[0].ClientRequests)
+	assert.NoError(t, summaries[0].Error)
+	assert.Equal(t, uint64(1), summaries[1].ClientRequests)
+	assert.EqualError(t, summaries[1].Error, "Stop")
+}
+
+func TestMultiClientWalkTable(t *testing.T) {
+	var multi = makeTestMultiClient(0,
+		&testMultiTarget{name: "host1"},
+		&testMultiTarget{name: "host2"},
+	)
+	var results = make(map[string]EntryValues)
+
+	multi.WalkTable(&Table{}, func(target MultiTarget, indexValues IndexValues, entryValues EntryValues, err error) error {
+		results[target.String()] = entryValues
+
+		return err
+	})
+
+	assert.Equal(t, map[string]EntryValues{"host1": EntryValues{"host1"}, "host2": EntryValues{"host2"}}, results)
+}
@@ -0,0 +1,68 @@
+package mibs
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+)
+
+// NOTIFICATION-TYPE, with the OBJECTS that are included in each notification
+type Notification struct {
+	ID
+
+	Objects []*Object
+}
+
+func (notification *Notification) ObjectIDs() []string {
+	var ids = make([]string, len(notification.Objects))
+
+	for i, object := range notification.Objects {
+		ids[i] = object.String()
+	}
+
+	return ids
+}
+
+func (notification *Notification) String() string {
+	return notification.ID.String()
+}
+
+func ResolveNotification(name string) (*Notification, error) {
+	return DefaultRegistry.ResolveNotification(name)
+}
+
+func (registry *Registry) ResolveNotification(name string) (*Notification, error) {
+	if id, err := registry.Resolve(name); err != nil {
+		return nil, err
+	} else if id.MIB == nil {
+		return nil, fmt.Errorf("No MIB for name: %v", name)
+	} else if notification := id.MIB.Notification(id); notification == nil {
+		return nil, fmt.Errorf("Not a notification: %v", name)
+	} else {
+		return notification, nil
+	}
+}
+
+// Lookup notification by exact snmpTrapOID
+func LookupNotification(oid snmp.OID) *Notification {
+	return DefaultRegistry.LookupNotification(oid)
+}
+
+func (registry *Registry) LookupNotification(oid snmp.OID) *Notification {
+	if mib := registry.LookupMIB(oid); mib == nil {
+		return nil
+	} else {
+		return mib.Notification(ID{OID: oid})
+	}
+}
+
+func WalkNotifications(f func(notification *Notification)) {
+	DefaultRegistry.WalkNotifications(f)
+}
+
+func (registry *Registry) WalkNotifications(f func(notification *Notification)) {
+	registry.Walk(func(id ID) {
+		if notification := id.MIB.Notification(id); notification != nil {
+			f(notification)
+		}
+	})
+}
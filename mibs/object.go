@@ -3,6 +3,7 @@ package mibs
 import (
 	"fmt"
 	"github.com/qmsk/snmpbot/snmp"
+	"sort"
 )
 
 type Object struct {
@@ -11,6 +12,7 @@ type Object struct {
 	IndexSyntax
 	Syntax
 	NotAccessible bool
+	Description   string
 }
 
 func (object *Object) Unpack(varBind snmp.VarBind) (Value, error) {
@@ -55,3 +57,10 @@ func (object *Object) Format(varBind snmp.VarBind) (string, Value, error) {
 		return name, value, err
 	}
 }
+
+// Sort objects by OID
+func SortObjects(objects []*Object) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].OID.Compare(objects[j].OID) < 0
+	})
+}
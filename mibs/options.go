@@ -8,23 +8,38 @@ import (
 )
 
 type Options struct {
-	MIBPath string
+	MIBPath  string
+	NoBundle bool
 }
 
 func (options *Options) InitFlags() {
-	flag.StringVar(&options.MIBPath, "snmp-mibs", os.Getenv("SNMPBOT_MIBS"), "Load MIBs from PATH[:PATH[...]]")
+	flag.StringVar(&options.MIBPath, "snmp-mibs", os.Getenv("SNMPBOT_MIBS"), "Load MIBs from PATH[:PATH[...]], overriding any bundled MIBs")
+	flag.BoolVar(&options.NoBundle, "snmp-mibs-no-bundle", false, "Do not load the bundled MIBs")
 }
 
-func (options *Options) LoadMIBs() error {
-	if options.MIBPath == "" {
-		return fmt.Errorf("Must provide -snmp-mibs/$SNMPBOT_MIBS with path to .../snmpbot-mibs/*.json")
-	}
+// Returns the MIB sources in order of precedence: each of the -snmp-mibs paths in order, followed by any bundles.
+func (options *Options) Sources() []Source {
+	var sources []Source
 
-	for _, path := range filepath.SplitList(options.MIBPath) {
-		if err := Load(path); err != nil {
-			return err
+	if options.MIBPath != "" {
+		for _, path := range filepath.SplitList(options.MIBPath) {
+			sources = append(sources, DirSource(path))
 		}
 	}
 
-	return nil
+	if !options.NoBundle {
+		sources = append(sources, Bundles()...)
+	}
+
+	return sources
+}
+
+func (options *Options) LoadMIBs() error {
+	var sources = options.Sources()
+
+	if len(sources) == 0 {
+		return fmt.Errorf("Must provide -snmp-mibs/$SNMPBOT_MIBS with path to .../snmpbot-mibs/*.json")
+	}
+
+	return LoadSources(sources...)
 }
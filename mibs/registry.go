@@ -3,22 +3,28 @@ package mibs
 import (
 	"fmt"
 	"github.com/qmsk/snmpbot/snmp"
+	"sort"
+	"strings"
+	"sync"
 )
 
 func makeRegistry() registry {
 	return registry{
-		byOID:  make(map[string]ID),
 		byName: make(map[string]ID),
 	}
 }
 
 type registry struct {
-	byOID  map[string]ID
+	byOID  oidTree
 	byName map[string]ID
 }
 
 func (registry *registry) registerOID(id ID) {
-	registry.byOID[id.OID.String()] = id
+	if len(id.OID) == 0 {
+		return
+	}
+
+	registry.byOID.insert(id.OID, id)
 }
 func (registry *registry) registerName(id ID, name string) {
 	registry.byName[name] = id
@@ -37,25 +43,281 @@ func (registry *registry) getName(name string) (ID, bool) {
 	}
 }
 
+// Lookup the ID with the longest matching OID prefix
 func (registry *registry) getOID(oid snmp.OID) (ID, bool) {
-	var key = ""
-	var id = ID{OID: oid}
-	var ok = false
+	if id, ok := registry.byOID.lookup(oid); !ok {
+		return ID{OID: oid}, false
+	} else {
+		return id, true
+	}
+}
+
+// Walk IDs in OID order
+func (registry *registry) walk(f func(ID)) {
+	registry.byOID.walk(f)
+}
+
+// Walk IDs within the given OID subtree, in OID order
+func (registry *registry) walkTree(oid snmp.OID, f func(ID)) {
+	registry.byOID.walkPrefix(oid, f)
+}
+
+// Registry of loaded MIBs.
+//
+// Safe for concurrent use: MIBs can be loaded, replaced and unloaded while other goroutines resolve and lookup IDs.
+type Registry struct {
+	mutex sync.RWMutex
+	mibs  map[string]*MIB
+	names nameIndex // unqualified names for all MIB IDs
+
+	registry // MIB names and OIDs for all MIB IDs
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mibs:     make(map[string]*MIB),
+		names:    make(nameIndex),
+		registry: makeRegistry(),
+	}
+}
+
+// The package-level functions use the DefaultRegistry.
+var DefaultRegistry = NewRegistry()
+
+// Must be called with the write lock held.
+func (registry *Registry) registerMIB(mib *MIB) error {
+	if _, exists := registry.mibs[mib.Name]; exists {
+		return fmt.Errorf("MIB already loaded: %v", mib.Name)
+	}
+
+	registry.mibs[mib.Name] = mib
+	registry.registerName(mib.ID, mib.Name)
+
+	if mib.OID != nil {
+		registry.registerOID(mib.ID)
+	}
+
+	mib.Walk(func(id ID) {
+		registry.registerOID(id)
+		registry.names.add(id)
+	})
 
-	for _, x := range oid {
-		key += fmt.Sprintf(".%d", x)
+	mib.setRegistry(registry)
 
-		if getID, getOK := registry.byOID[key]; getOK {
-			id = getID
-			ok = true
+	return nil
+}
+
+// Must be called with the write lock held.
+func (registry *Registry) unregisterMIB(mib *MIB) {
+	delete(registry.mibs, mib.Name)
+	delete(registry.byName, mib.Name)
+
+	registry.byOID.remove(func(id ID) bool {
+		return id.MIB == mib
+	})
+	registry.names.removeMIB(mib)
+
+	mib.setRegistry(nil)
+}
+
+// Register an MIB object/table/notification ID for OID and unqualified name lookups.
+func (registry *Registry) registerID(id ID) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.registerOID(id)
+	registry.names.add(id)
+}
+
+// Register a new, empty MIB, for use with MIB.RegisterObject() etc.
+//
+// Panics if an MIB with the same name is already registered.
+func (registry *Registry) RegisterMIB(name string, oid ...int) *MIB {
+	var mib = makeMIB(name, snmp.OID(oid))
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if err := registry.registerMIB(mib); err != nil {
+		panic(err)
+	}
+
+	return mib
+}
+
+func (registry *Registry) ResolveMIB(name string) (*MIB, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	if mib, ok := registry.mibs[name]; !ok {
+		return nil, fmt.Errorf("MIB not found: %v", name)
+	} else {
+		return mib, nil
+	}
+}
+
+// Returns a snapshot of the currently registered MIBs, in OID order.
+func (registry *Registry) listMIBs() []*MIB {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	var mibs = make([]*MIB, 0, len(registry.mibs))
+
+	for _, mib := range registry.mibs {
+		mibs = append(mibs, mib)
+	}
+
+	SortMIBs(mibs)
+
+	return mibs
+}
+
+// Sort MIBs by OID, with any MIBs without an OID last, by name.
+func SortMIBs(mibs []*MIB) {
+	sort.Slice(mibs, func(i, j int) bool {
+		if mibs[i].OID == nil || mibs[j].OID == nil {
+			if mibs[i].OID != nil {
+				return true
+			} else if mibs[j].OID != nil {
+				return false
+			} else {
+				return mibs[i].Name < mibs[j].Name
+			}
+		} else if cmp := mibs[i].OID.Compare(mibs[j].OID); cmp != 0 {
+			return cmp < 0
+		} else {
+			return mibs[i].Name < mibs[j].Name
 		}
+	})
+}
+
+// Unload the given MIB.
+//
+// Fails with a ReferenceError if any tables or notifications in other MIBs refer to objects in this MIB.
+func (registry *Registry) UnloadMIB(mib *MIB) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if registry.mibs[mib.Name] != mib {
+		return fmt.Errorf("MIB not loaded: %v", mib.Name)
+	}
+
+	if references := registry.references(mib, nil, nil); len(references) > 0 {
+		return ReferenceError{MIB: mib.Name, References: references}
 	}
 
-	return id, ok
+	registry.unregisterMIB(mib)
+
+	return nil
 }
 
-func (registry *registry) walk(f func(ID)) {
-	for _, id := range registry.byName {
-		f(id)
+// Return any references from tables or notifications in other MIBs to objects in the given MIB.
+//
+// Objects for which the keep() func returns true are ignored.
+// Referring MIBs in the exclude set are ignored.
+//
+// Must be called with the lock held.
+func (registry *Registry) references(mib *MIB, keep func(*Object) bool, exclude map[string]bool) []Reference {
+	var references []Reference
+	var check = func(from ID, objects []*Object) {
+		for _, object := range objects {
+			if object.MIB != mib {
+				continue
+			}
+			if keep != nil && keep(object) {
+				continue
+			}
+
+			references = append(references, Reference{From: from, Object: object})
+		}
+	}
+
+	for _, otherMIB := range registry.mibs {
+		if otherMIB == mib || exclude[otherMIB.Name] {
+			continue
+		}
+
+		otherMIB.Walk(func(id ID) {
+			if table := otherMIB.Table(id); table != nil {
+				check(table.ID, table.IndexSyntax)
+				check(table.ID, table.EntrySyntax)
+			}
+			if notification := otherMIB.Notification(id); notification != nil {
+				check(notification.ID, notification.Objects)
+			}
+		})
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		return references[i].String() < references[j].String()
+	})
+
+	return references
+}
+
+// Reference from a table/notification to an object in a different MIB
+type Reference struct {
+	From   ID
+	Object *Object
+}
+
+func (reference Reference) String() string {
+	return fmt.Sprintf("%v (%v)", reference.From, reference.Object)
+}
+
+type ReferenceError struct {
+	MIB        string
+	References []Reference
+}
+
+func (err ReferenceError) Error() string {
+	var strs = make([]string, len(err.References))
+
+	for i, reference := range err.References {
+		strs[i] = reference.String()
+	}
+
+	return fmt.Sprintf("MIB %v is still referenced by: %v", err.MIB, strings.Join(strs, ", "))
+}
+
+func (registry *Registry) LookupMIB(oid snmp.OID) *MIB {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	if id, ok := registry.getOID(oid); !ok {
+		return nil
+	} else {
+		return id.MIB
+	}
+}
+
+// Returns a snapshot of the IDs within the OID subtree, in OID order.
+//
+// Only includes the top-level MIB IDs if requested.
+func (registry *Registry) listTree(oid snmp.OID, withMIBs bool) []ID {
+	var ids []ID
+
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	registry.walkTree(oid, func(id ID) {
+		if id.IsMIB() && !withMIBs {
+			return
+		}
+
+		ids = append(ids, id)
+	})
+
+	return ids
+}
+
+func (registry *Registry) Lookup(oid snmp.OID) ID {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	if id, ok := registry.getOID(oid); !ok {
+		return ID{OID: oid}
+	} else {
+		return id
 	}
 }
@@ -0,0 +1,191 @@
+package mibs
+
+import (
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+var testRegistryBaseConfig = MIBConfig{
+	Name: "TEST-BASE-MIB",
+	OID:  ".1.0.3",
+	Objects: []ObjectConfig{
+		{ConfigID: ConfigID{Name: "baseIndex", OID: ".1.0.3.1.1.1"}, Syntax: "Integer32"},
+		{ConfigID: ConfigID{Name: "baseName", OID: ".1.0.3.1.1.2"}, Syntax: "SNMPv2-TC::DisplayString"},
+	},
+	Tables: []TableConfig{
+		{
+			ConfigID:     ConfigID{Name: "baseTable", OID: ".1.0.3.1"},
+			IndexObjects: []string{"TEST-BASE-MIB::baseIndex"},
+			EntryObjects: []string{"TEST-BASE-MIB::baseName"},
+			EntryName:    "baseEntry",
+		},
+	},
+}
+
+var testRegistryExtConfig = MIBConfig{
+	Name: "TEST-EXT-MIB",
+	OID:  ".1.0.4",
+	Objects: []ObjectConfig{
+		{ConfigID: ConfigID{Name: "extName", OID: ".1.0.4.1.1.1"}, Syntax: "SNMPv2-TC::DisplayString"},
+	},
+	Tables: []TableConfig{
+		{
+			ConfigID:     ConfigID{Name: "extTable", OID: ".1.0.4.1"},
+			IndexObjects: []string{"TEST-BASE-MIB::baseIndex"},
+			EntryObjects: []string{"TEST-EXT-MIB::extName"},
+			EntryName:    "extEntry",
+		},
+	},
+}
+
+func makeTestRegistry(t *testing.T) *Registry {
+	var registry = NewRegistry()
+
+	if _, err := registry.LoadMIBConfig(testRegistryBaseConfig); err != nil {
+		t.Fatalf("LoadMIBConfig %v: %v", testRegistryBaseConfig.Name, err)
+	}
+	if _, err := registry.LoadMIBConfig(testRegistryExtConfig); err != nil {
+		t.Fatalf("LoadMIBConfig %v: %v", testRegistryExtConfig.Name, err)
+	}
+
+	return registry
+}
+
+func TestRegistryIsolated(t *testing.T) {
+	var registry = makeTestRegistry(t)
+
+	if object, err := registry.ResolveObject("TEST-EXT-MIB::extName"); err != nil {
+		t.Fatalf("ResolveObject: %v", err)
+	} else {
+		assert.Equal(t, "TEST-EXT-MIB::extName", object.String())
+		assert.Equal(t, object, registry.LookupObject(snmp.OID{1, 0, 4, 1, 1, 1}))
+	}
+
+	_, err := ResolveMIB("TEST-EXT-MIB")
+
+	assert.EqualError(t, err, "MIB not found: TEST-EXT-MIB")
+}
+
+func TestRegistryLoadDuplicate(t *testing.T) {
+	var registry = makeTestRegistry(t)
+
+	_, err := registry.LoadMIBConfig(testRegistryBaseConfig)
+
+	assert.EqualError(t, err, "Failed to load MIB: MIB already loaded: TEST-BASE-MIB")
+}
+
+func TestRegistryLoadError(t *testing.T) {
+	var registry = NewRegistry()
+
+	_, err := registry.LoadMIBConfig(testRegistryExtConfig)
+
+	assert.EqualError(t, err, "Failed to load MIB TEST-EXT-MIB tables: Invalid Table extTable: Invalid IndexObject TEST-BASE-MIB::baseIndex: MIB not found: TEST-BASE-MIB")
+
+	_, resolveErr := registry.ResolveMIB("TEST-EXT-MIB")
+
+	assert.EqualError(t, resolveErr, "MIB not found: TEST-EXT-MIB")
+	assert.Equal(t, "", registry.Lookup(snmp.OID{1, 0, 4, 1, 1, 1}).Name)
+}
+
+func TestRegistryUnload(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var mib, _ = registry.ResolveMIB("TEST-EXT-MIB")
+
+	if err := registry.UnloadMIB(mib); err != nil {
+		t.Fatalf("UnloadMIB: %v", err)
+	}
+
+	_, err := registry.ResolveObject("TEST-EXT-MIB::extName")
+
+	assert.EqualError(t, err, "MIB not found: TEST-EXT-MIB")
+	assert.Nil(t, registry.LookupObject(snmp.OID{1, 0, 4, 1, 1, 1}))
+	assert.Nil(t, registry.LookupMIB(snmp.OID{1, 0, 4}))
+
+	assert.EqualError(t, registry.UnloadMIB(mib), "MIB not loaded: TEST-EXT-MIB")
+}
+
+func TestRegistryUnloadReferenced(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var mib, _ = registry.ResolveMIB("TEST-BASE-MIB")
+
+	err := registry.UnloadMIB(mib)
+
+	assert.EqualError(t, err, "MIB TEST-BASE-MIB is still referenced by: TEST-EXT-MIB::extTable (TEST-BASE-MIB::baseIndex)")
+	assert.IsType(t, ReferenceError{}, err)
+
+	if object, err := registry.ResolveObject("TEST-BASE-MIB::baseIndex"); err != nil {
+		t.Errorf("ResolveObject: %v", err)
+	} else {
+		assert.Equal(t, mib, object.MIB)
+	}
+}
+
+func TestRegistryReplace(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var config = testRegistryBaseConfig
+
+	config.Objects = append(config.Objects, ObjectConfig{ConfigID: ConfigID{Name: "baseNew", OID: ".1.0.3.2"}, Syntax: "Integer32"})
+
+	if mib, err := registry.ReplaceMIBConfig(config); err != nil {
+		t.Fatalf("ReplaceMIBConfig: %v", err)
+	} else if object, err := registry.ResolveObject("TEST-BASE-MIB::baseNew"); err != nil {
+		t.Errorf("ResolveObject: %v", err)
+	} else {
+		assert.Equal(t, mib, object.MIB)
+		assert.Equal(t, mib, registry.LookupMIB(snmp.OID{1, 0, 3, 1, 1, 1}))
+	}
+}
+
+func TestRegistryReplaceReferenced(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var config = testRegistryBaseConfig
+	var mib, _ = registry.ResolveMIB("TEST-BASE-MIB")
+
+	config.Objects = config.Objects[1:]
+	config.Tables = nil
+
+	_, err := registry.ReplaceMIBConfig(config)
+
+	assert.EqualError(t, err, "MIB TEST-BASE-MIB is still referenced by: TEST-EXT-MIB::extTable (TEST-BASE-MIB::baseIndex)")
+
+	if resolveMIB, err := registry.ResolveMIB("TEST-BASE-MIB"); err != nil {
+		t.Errorf("ResolveMIB: %v", err)
+	} else {
+		assert.Equal(t, mib, resolveMIB)
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if _, err := registry.ReplaceMIBConfig(testRegistryExtConfig); err != nil {
+					t.Errorf("ReplaceMIBConfig: %v", err)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if _, err := registry.ResolveObject("TEST-EXT-MIB::extName"); err != nil {
+					t.Errorf("ResolveObject: %v", err)
+				}
+
+				registry.WalkTables(func(table *Table) {})
+			}
+		}()
+	}
+
+	wg.Wait()
+}
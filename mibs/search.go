@@ -0,0 +1,265 @@
+package mibs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Index of object/table/notification IDs by case-insensitive, unqualified name.
+type nameIndex map[string][]ID
+
+func (index nameIndex) add(id ID) {
+	if id.Name == "" {
+		return
+	}
+
+	var key = strings.ToLower(id.Name)
+
+	for _, other := range index[key] {
+		if other.MIB == id.MIB && other.Name == id.Name {
+			return
+		}
+	}
+
+	index[key] = append(index[key], id)
+}
+
+func (index nameIndex) removeMIB(mib *MIB) {
+	for key, ids := range index {
+		var keep = ids[:0]
+
+		for _, id := range ids {
+			if id.MIB != mib {
+				keep = append(keep, id)
+			}
+		}
+
+		if len(keep) == 0 {
+			delete(index, key)
+		} else {
+			index[key] = keep
+		}
+	}
+}
+
+// Multiple MIBs define the same unqualified name.
+type AmbiguousError struct {
+	Name       string
+	Candidates []ID
+}
+
+func (err AmbiguousError) Error() string {
+	var strs = make([]string, len(err.Candidates))
+
+	for i, id := range err.Candidates {
+		strs[i] = id.String()
+	}
+
+	return fmt.Sprintf("Ambiguous name %v: %v", err.Name, strings.Join(strs, ", "))
+}
+
+// Resolve an unqualified name without the MIB:: prefix.
+//
+// Matches case-insensitively, unless there are multiple candidates only differing by case.
+// Fails with an AmbiguousError listing the candidates if multiple MIBs define the same name.
+func (registry *Registry) ResolveName(name string) (ID, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	var candidates = registry.names[strings.ToLower(name)]
+	var exact []ID
+
+	for _, id := range candidates {
+		if id.Name == name {
+			exact = append(exact, id)
+		}
+	}
+
+	if len(exact) == 1 {
+		return exact[0], nil
+	} else if len(candidates) == 1 {
+		return candidates[0], nil
+	} else if len(candidates) == 0 {
+		return ID{Name: name}, fmt.Errorf("Name not found: %v", name)
+	} else {
+		var err = AmbiguousError{Name: name, Candidates: append([]ID(nil), candidates...)}
+
+		sort.Slice(err.Candidates, func(i, j int) bool {
+			return err.Candidates[i].String() < err.Candidates[j].String()
+		})
+
+		return ID{Name: name}, err
+	}
+}
+
+// Resolve an unqualified name in the DefaultRegistry, see Registry.ResolveName.
+func ResolveName(name string) (ID, error) {
+	return DefaultRegistry.ResolveName(name)
+}
+
+type SearchMatch int
+
+// Search matches, in order of relevance
+const (
+	SearchExact SearchMatch = iota
+	SearchPrefix
+	SearchSubstring
+	SearchFuzzy
+	SearchDescription
+)
+
+func (match SearchMatch) String() string {
+	switch match {
+	case SearchExact:
+		return "exact"
+	case SearchPrefix:
+		return "prefix"
+	case SearchSubstring:
+		return "substring"
+	case SearchFuzzy:
+		return "fuzzy"
+	case SearchDescription:
+		return "description"
+	default:
+		return fmt.Sprintf("SearchMatch(%d)", int(match))
+	}
+}
+
+type SearchResult struct {
+	ID
+	Match    SearchMatch
+	Distance int // match-specific ordering within the same Match
+}
+
+// Maximum edit distance for fuzzy matches, relative to the query length
+func searchFuzzyDistance(query string) int {
+	if len(query) < 4 {
+		return 0
+	} else if len(query) < 8 {
+		return 1
+	} else {
+		return 2
+	}
+}
+
+// Edit distance with insertions, deletions, substitutions and transpositions of adjacent characters
+func editDistance(a, b string) int {
+	var rows = make([][]int, len(a)+1)
+
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			var cost = 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			rows[i][j] = minInt(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = minInt(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return rows[len(a)][len(b)]
+}
+
+func minInt(value int, values ...int) int {
+	for _, v := range values {
+		if v < value {
+			value = v
+		}
+	}
+
+	return value
+}
+
+func searchMatch(query string, name string, description string) (SearchMatch, int, bool) {
+	var lowerName = strings.ToLower(name)
+
+	if lowerName == query {
+		return SearchExact, 0, true
+	} else if strings.HasPrefix(lowerName, query) {
+		return SearchPrefix, len(name) - len(query), true
+	} else if i := strings.Index(lowerName, query); i >= 0 {
+		return SearchSubstring, i, true
+	} else if d := editDistance(query, lowerName); d <= searchFuzzyDistance(query) {
+		return SearchFuzzy, d, true
+	} else if i := strings.Index(strings.ToLower(description), query); query != "" && i >= 0 {
+		return SearchDescription, i, true
+	} else {
+		return 0, 0, false
+	}
+}
+
+// Search MIB, object, table and notification names, returning the matching IDs in order of relevance.
+//
+// The query is matched case-insensitively as an exact name, name prefix, name substring,
+// name with typos, or within the object/table description.
+//
+// A query of the form "MIB::name" only matches names within the MIB, and an empty name matches all of them.
+func (registry *Registry) Search(query string) []SearchResult {
+	var mibQuery, nameQuery string
+	var qualified = false
+
+	if i := strings.Index(query, "::"); i >= 0 {
+		mibQuery = strings.ToLower(query[:i])
+		nameQuery = strings.ToLower(query[i+2:])
+		qualified = true
+	} else {
+		nameQuery = strings.ToLower(query)
+	}
+
+	var results []SearchResult
+	var add = func(id ID, name string) {
+		if match, distance, ok := searchMatch(nameQuery, name, id.Description()); ok {
+			results = append(results, SearchResult{id, match, distance})
+		}
+	}
+
+	registry.mutex.RLock()
+
+	for _, mib := range registry.mibs {
+		if !qualified {
+			add(mib.ID, mib.Name)
+		}
+	}
+	for _, ids := range registry.names {
+		for _, id := range ids {
+			if qualified && strings.ToLower(id.MIB.Name) != mibQuery {
+				continue
+			}
+
+			add(id, id.Name)
+		}
+	}
+
+	registry.mutex.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Match != results[j].Match {
+			return results[i].Match < results[j].Match
+		} else if results[i].Distance != results[j].Distance {
+			return results[i].Distance < results[j].Distance
+		} else {
+			return results[i].String() < results[j].String()
+		}
+	})
+
+	return results
+}
+
+// Search the DefaultRegistry, see Registry.Search.
+func Search(query string) []SearchResult {
+	return DefaultRegistry.Search(query)
+}
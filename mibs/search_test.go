@@ -0,0 +1,149 @@
+package mibs
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testSearchDupConfig = MIBConfig{
+	Name: "TEST-DUP-MIB",
+	OID:  ".1.0.5",
+	Objects: []ObjectConfig{
+		{ConfigID: ConfigID{Name: "basename", OID: ".1.0.5.1"}, Syntax: "SNMPv2-TC::DisplayString"},
+		{ConfigID: ConfigID{Name: "extName", OID: ".1.0.5.2"}, Syntax: "SNMPv2-TC::DisplayString", Description: "Duplicate extension name"},
+	},
+}
+
+func makeTestSearchRegistry(t *testing.T) *Registry {
+	var registry = makeTestRegistry(t)
+
+	if _, err := registry.LoadMIBConfig(testSearchDupConfig); err != nil {
+		t.Fatalf("LoadMIBConfig %v: %v", testSearchDupConfig.Name, err)
+	}
+
+	return registry
+}
+
+func TestResolveName(t *testing.T) {
+	var registry = makeTestRegistry(t)
+
+	if id, err := registry.ResolveName("extName"); err != nil {
+		t.Errorf("ResolveName: %v", err)
+	} else {
+		assert.Equal(t, "TEST-EXT-MIB::extName", id.String())
+	}
+
+	if id, err := registry.ResolveName("EXTNAME"); err != nil {
+		t.Errorf("ResolveName: %v", err)
+	} else {
+		assert.Equal(t, "TEST-EXT-MIB::extName", id.String())
+	}
+
+	_, err := registry.ResolveName("missing")
+
+	assert.EqualError(t, err, "Name not found: missing")
+}
+
+func TestResolveNameCase(t *testing.T) {
+	var registry = makeTestSearchRegistry(t)
+
+	if id, err := registry.ResolveName("baseName"); err != nil {
+		t.Errorf("ResolveName: %v", err)
+	} else {
+		assert.Equal(t, "TEST-BASE-MIB::baseName", id.String())
+	}
+
+	if id, err := registry.ResolveName("basename"); err != nil {
+		t.Errorf("ResolveName: %v", err)
+	} else {
+		assert.Equal(t, "TEST-DUP-MIB::basename", id.String())
+	}
+
+	_, err := registry.ResolveName("BASENAME")
+
+	assert.EqualError(t, err, "Ambiguous name BASENAME: TEST-BASE-MIB::baseName, TEST-DUP-MIB::basename")
+}
+
+func TestResolveNameAmbiguous(t *testing.T) {
+	var registry = makeTestSearchRegistry(t)
+
+	_, err := registry.Resolve("extName.1")
+
+	if ambiguousErr, ok := err.(AmbiguousError); !ok {
+		t.Fatalf("Resolve: %#v", err)
+	} else {
+		assert.Equal(t, "extName", ambiguousErr.Name)
+		assert.Equal(t, 2, len(ambiguousErr.Candidates))
+	}
+}
+
+func TestResolveNameUnload(t *testing.T) {
+	var registry = makeTestSearchRegistry(t)
+
+	if mib, err := registry.ResolveMIB("TEST-DUP-MIB"); err != nil {
+		t.Fatalf("ResolveMIB: %v", err)
+	} else if err := registry.UnloadMIB(mib); err != nil {
+		t.Fatalf("UnloadMIB: %v", err)
+	}
+
+	if id, err := registry.Resolve("extName.1"); err != nil {
+		t.Errorf("Resolve: %v", err)
+	} else {
+		assert.Equal(t, "TEST-EXT-MIB::extName", id.String())
+		assert.Equal(t, ".1.0.4.1.1.1.1", id.OID.String())
+	}
+}
+
+func TestResolveUnqualifiedMIBNotFound(t *testing.T) {
+	var registry = makeTestRegistry(t)
+
+	_, err := registry.Resolve("ASDF")
+
+	assert.EqualError(t, err, "MIB not found: ASDF")
+}
+
+func testSearch(t *testing.T, registry *Registry, query string, expected []string) {
+	var results []string
+
+	for _, result := range registry.Search(query) {
+		results = append(results, result.ID.String()+" "+result.Match.String())
+	}
+
+	assert.Equal(t, expected, results, "Search(%#v)", query)
+}
+
+func TestSearch(t *testing.T) {
+	var registry = makeTestSearchRegistry(t)
+
+	testSearch(t, registry, "basename", []string{
+		"TEST-BASE-MIB::baseName exact",
+		"TEST-DUP-MIB::basename exact",
+	})
+	testSearch(t, registry, "ext", []string{
+		"TEST-DUP-MIB::extName prefix",
+		"TEST-EXT-MIB::extName prefix",
+		"TEST-EXT-MIB::extTable prefix",
+		"TEST-EXT-MIB substring",
+	})
+	testSearch(t, registry, "TEST-B", []string{
+		"TEST-BASE-MIB prefix",
+	})
+	testSearch(t, registry, "bsaeTable", []string{
+		"TEST-BASE-MIB::baseTable fuzzy",
+	})
+	testSearch(t, registry, "duplicate", []string{
+		"TEST-DUP-MIB::extName description",
+	})
+	testSearch(t, registry, "test-ext-mib::", []string{
+		"TEST-EXT-MIB::extName prefix",
+		"TEST-EXT-MIB::extTable prefix",
+	})
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("ifdescr", "ifdescr"))
+	assert.Equal(t, 1, editDistance("ifdesc", "ifdescr"))
+	assert.Equal(t, 1, editDistance("ifdecsr", "ifdescr"))
+	assert.Equal(t, 2, editDistance("ifdxscx", "ifdescr"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
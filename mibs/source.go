@@ -0,0 +1,124 @@
+package mibs
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Source of MIB config files within a filesystem.
+//
+// The Root may either be a single file, or a directory that is walked recursively.
+type Source struct {
+	Name string // used for logging
+	FS   fs.FS
+	Root string
+}
+
+// Source for the MIB file or directory at the given OS path.
+func DirSource(dirPath string) Source {
+	var cleanPath = filepath.Clean(dirPath)
+	var base = filepath.Base(cleanPath)
+
+	if base == string(filepath.Separator) || base == "." || base == ".." {
+		// no parent directory to open the base name within, must be a directory
+		return Source{
+			Name: dirPath,
+			FS:   os.DirFS(cleanPath),
+			Root: ".",
+		}
+	}
+
+	return Source{
+		Name: dirPath,
+		FS:   os.DirFS(filepath.Dir(cleanPath)),
+		Root: base,
+	}
+}
+
+// Source for all MIB files within the given filesystem.
+func FSSource(name string, fsys fs.FS) Source {
+	return Source{
+		Name: name,
+		FS:   fsys,
+		Root: ".",
+	}
+}
+
+func (source Source) String() string {
+	return source.Name
+}
+
+// Return the displayed path for the given fs path within the source
+func (source Source) path(name string) string {
+	if name == source.Root {
+		return source.Name
+	} else if source.Root == "." {
+		return path.Join(source.Name, name)
+	} else {
+		return path.Join(source.Name, strings.TrimPrefix(name, source.Root+"/"))
+	}
+}
+
+func (source Source) walkFile(name string, handler configWalkFunc) error {
+	switch ext := path.Ext(name); ext {
+	case ".json":
+		if file, err := source.FS.Open(name); err != nil {
+			return err
+		} else {
+			defer file.Close()
+
+			return walkJSON(file, handler, source.path(name))
+		}
+	default:
+		return fmt.Errorf("Unknown MIB file extension: %v", ext)
+	}
+}
+
+// Walk all MIB configs within the source, in lexical order.
+func (source Source) walk(handler configWalkFunc) error {
+	return fs.WalkDir(source.FS, source.Root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if name != source.Root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			} else {
+				return nil
+			}
+		} else if entry.IsDir() {
+			log.Infof("Load MIBs from directory: %v", source.path(name))
+
+			return nil
+		} else {
+			return source.walkFile(name, handler)
+		}
+	})
+}
+
+var bundles = struct {
+	mutex   sync.Mutex
+	sources []Source
+}{}
+
+// Register a built-in bundle of MIB files, typically embedded into the binary.
+//
+// Bundled MIBs are loaded by Options.LoadMIBs with lower precedence than any MIBs from the user-provided paths.
+func RegisterBundle(name string, fsys fs.FS) {
+	bundles.mutex.Lock()
+	defer bundles.mutex.Unlock()
+
+	bundles.sources = append(bundles.sources, FSSource(name, fsys))
+}
+
+// Returns the registered bundles, in registration order.
+func Bundles() []Source {
+	bundles.mutex.Lock()
+	defer bundles.mutex.Unlock()
+
+	return append([]Source(nil), bundles.sources...)
+}
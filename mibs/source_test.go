@@ -0,0 +1,140 @@
+package mibs
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func makeTestSourceFile(t *testing.T, config MIBConfig) *fstest.MapFile {
+	if data, err := json.Marshal(config); err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+		return nil
+	} else {
+		return &fstest.MapFile{Data: data}
+	}
+}
+
+func makeTestSource(t *testing.T, name string, configs map[string]MIBConfig) Source {
+	var fsys = make(fstest.MapFS)
+
+	for path, config := range configs {
+		fsys[path] = makeTestSourceFile(t, config)
+	}
+
+	return FSSource(name, fsys)
+}
+
+func TestLoadSources(t *testing.T) {
+	var registry = NewRegistry()
+	var source = makeTestSource(t, "test", map[string]MIBConfig{
+		"TEST-EXT-MIB.json":         testRegistryExtConfig,
+		"base/TEST-BASE-MIB.json":   testRegistryBaseConfig,
+		".hidden/TEST-EXT-MIB.json": testRegistryExtConfig,
+	})
+
+	if err := registry.LoadSources(source); err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+
+	if table, err := registry.ResolveTable("TEST-EXT-MIB::extTable"); err != nil {
+		t.Errorf("ResolveTable: %v", err)
+	} else {
+		assert.Equal(t, "TEST-BASE-MIB::baseIndex", table.IndexSyntax[0].String())
+	}
+}
+
+func TestLoadSourcesPrecedence(t *testing.T) {
+	var registry = NewRegistry()
+	var overrideConfig = testRegistryBaseConfig
+
+	overrideConfig.Objects = append([]ObjectConfig{
+		{ConfigID: ConfigID{Name: "baseOverride", OID: ".1.0.3.2"}, Syntax: "Integer32"},
+	}, testRegistryBaseConfig.Objects...)
+
+	var userSource = makeTestSource(t, "user", map[string]MIBConfig{
+		"TEST-BASE-MIB.json": overrideConfig,
+	})
+	var bundleSource = makeTestSource(t, "bundle", map[string]MIBConfig{
+		"TEST-BASE-MIB.json": testRegistryBaseConfig,
+		"TEST-EXT-MIB.json":  testRegistryExtConfig,
+	})
+
+	if err := registry.LoadSources(userSource, bundleSource); err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+
+	if _, err := registry.ResolveObject("TEST-BASE-MIB::baseOverride"); err != nil {
+		t.Errorf("ResolveObject: %v", err)
+	}
+	if _, err := registry.ResolveTable("TEST-EXT-MIB::extTable"); err != nil {
+		t.Errorf("ResolveTable: %v", err)
+	}
+}
+
+func TestLoadSourcesDuplicate(t *testing.T) {
+	var registry = NewRegistry()
+	var source = makeTestSource(t, "test", map[string]MIBConfig{
+		"a/TEST-BASE-MIB.json": testRegistryBaseConfig,
+		"b/TEST-BASE-MIB.json": testRegistryBaseConfig,
+	})
+
+	err := registry.LoadSources(source)
+
+	assert.EqualError(t, err, "Duplicate MIB TEST-BASE-MIB in test/a/TEST-BASE-MIB.json and test/b/TEST-BASE-MIB.json")
+
+	if _, err := registry.ResolveMIB("TEST-BASE-MIB"); err == nil {
+		t.Errorf("ResolveMIB: should not be loaded")
+	}
+}
+
+func TestLoadSourcesUnknownExtension(t *testing.T) {
+	var registry = NewRegistry()
+	var source = FSSource("test", fstest.MapFS{
+		"TEST-MIB.txt": &fstest.MapFile{},
+	})
+
+	assert.EqualError(t, registry.LoadSources(source), "Unknown MIB file extension: .txt")
+}
+
+func TestDirSource(t *testing.T) {
+	var registry = NewRegistry()
+
+	if err := registry.LoadSources(DirSource("test/TEST2-MIB.json")); err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+
+	if _, err := registry.ResolveMIB("TEST2-MIB"); err != nil {
+		t.Errorf("ResolveMIB: %v", err)
+	}
+}
+
+func TestDirSourceTrailingSlash(t *testing.T) {
+	for _, dirPath := range []string{"test/", "test//", "./test/", "../mibs/test/"} {
+		var registry = NewRegistry()
+
+		if err := registry.LoadSources(DirSource(dirPath)); err != nil {
+			t.Errorf("LoadSources %v: %v", dirPath, err)
+		} else if _, err := registry.ResolveMIB("TEST2-MIB"); err != nil {
+			t.Errorf("ResolveMIB %v: %v", dirPath, err)
+		}
+	}
+}
+
+func TestDirSourceRoot(t *testing.T) {
+	for _, test := range []struct {
+		dirPath string
+		root    string
+	}{
+		{"/", "."},
+		{"test/..", "."},
+		{"..", "."},
+		{"/opt/snmpbot/mibs/", "mibs"},
+	} {
+		var source = DirSource(test.dirPath)
+
+		assert.Equalf(t, test.dirPath, source.Name, "DirSource(%#v).Name", test.dirPath)
+		assert.Equalf(t, test.root, source.Root, "DirSource(%#v).Root", test.dirPath)
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"github.com/qmsk/snmpbot/snmp"
 )
 
-type Counter uint
+type Counter uint64
 
 func (value Counter) String() string {
-	return fmt.Sprintf("%v", uint(value))
+	return fmt.Sprintf("%v", uint64(value))
 }
 
 type CounterSyntax struct{}
@@ -0,0 +1,50 @@
+package mibs
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+	"math"
+)
+
+type Float float64
+
+func (value Float) String() string {
+	return fmt.Sprintf("%v", float64(value))
+}
+
+// JSON does not support NaN or Inf values, which are encoded as strings
+func (value Float) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+		return json.Marshal(value.String())
+	} else {
+		return json.Marshal(float64(value))
+	}
+}
+
+// Opaque-wrapped float/double values, such as the UCD-SNMP-MIB Float.
+type FloatSyntax struct{}
+
+func (syntax FloatSyntax) UnpackIndex(index []int) (Value, []int, error) {
+	return nil, index, SyntaxIndexError{syntax, index}
+}
+
+func (syntax FloatSyntax) Unpack(varBind snmp.VarBind) (Value, error) {
+	snmpValue, err := varBind.Value()
+	if err != nil {
+		return nil, err
+	}
+	switch value := snmpValue.(type) {
+	case snmp.OpaqueFloat:
+		return Float(value), nil
+	case snmp.OpaqueDouble:
+		return Float(value), nil
+	default:
+		return nil, SyntaxError{syntax, value}
+	}
+}
+
+func init() {
+	RegisterSyntax("Float", FloatSyntax{})
+	RegisterSyntax("UCD-SNMP-MIB::Float", FloatSyntax{})
+}
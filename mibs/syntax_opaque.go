@@ -0,0 +1,39 @@
+package mibs
+
+import (
+	"github.com/qmsk/snmpbot/snmp"
+)
+
+// Opaque values, decoding any of the special net-snmp opaque types to numbers, or hex otherwise.
+type OpaqueSyntax struct{}
+
+func (syntax OpaqueSyntax) UnpackIndex(index []int) (Value, []int, error) {
+	return nil, index, SyntaxIndexError{syntax, index}
+}
+
+func (syntax OpaqueSyntax) Unpack(varBind snmp.VarBind) (Value, error) {
+	snmpValue, err := varBind.Value()
+	if err != nil {
+		return nil, err
+	}
+	switch value := snmpValue.(type) {
+	case snmp.Opaque:
+		return OctetString(value), nil
+	case snmp.OpaqueFloat:
+		return Float(value), nil
+	case snmp.OpaqueDouble:
+		return Float(value), nil
+	case snmp.OpaqueCounter64:
+		return Counter(value), nil
+	case snmp.OpaqueUInt64:
+		return Counter(value), nil
+	case snmp.OpaqueInt64:
+		return int64(value), nil
+	default:
+		return nil, SyntaxError{syntax, value}
+	}
+}
+
+func init() {
+	RegisterSyntax("Opaque", OpaqueSyntax{})
+}
@@ -0,0 +1,44 @@
+package mibs
+
+import (
+	"encoding/json"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestOpaqueSyntax(t *testing.T) {
+	for _, test := range []struct {
+		snmpValue interface{}
+		value     Value
+		json      string
+	}{
+		{snmp.OpaqueFloat(0.5), Float(0.5), `0.5`},
+		{snmp.OpaqueDouble(math.NaN()), nil, `"NaN"`},
+		{snmp.OpaqueCounter64(1<<64 - 1), Counter(1<<64 - 1), `18446744073709551615`},
+		{snmp.OpaqueInt64(-1), int64(-1), `-1`},
+		{snmp.Opaque{0x01, 0x02}, OctetString{0x01, 0x02}, `"01 02"`},
+	} {
+		var varBind = snmp.MakeVarBind(snmp.OID{1}, test.snmpValue)
+
+		if value, err := testOpaqueSyntax.Unpack(varBind); err != nil {
+			t.Errorf("Unpack %#v: %v", test.snmpValue, err)
+		} else if jsonBytes, err := json.Marshal(value); err != nil {
+			t.Errorf("json.Marshal %#v: %v", value, err)
+		} else {
+			if test.value != nil {
+				assert.Equal(t, test.value, value)
+			}
+			assert.Equal(t, test.json, string(jsonBytes))
+		}
+	}
+}
+
+func TestFloatSyntaxError(t *testing.T) {
+	var varBind = snmp.MakeVarBind(snmp.OID{1}, snmp.Opaque{0x01})
+
+	_, err := testFloatSyntax.Unpack(varBind)
+
+	assert.EqualError(t, err, "Invalid value for Syntax mibs.FloatSyntax: <snmp.Opaque> snmp.Opaque{0x1}")
+}
@@ -11,3 +11,5 @@ var testMACAddressSyntax Syntax = MACAddressSyntax{}
 var testOctetStringSyntax Syntax = OctetStringSyntax{}
 var testUnsignedSyntax Syntax = UnsignedSyntax{}
 var testIPAddressSyntax Syntax = IPAddressSyntax{}
+var testOpaqueSyntax Syntax = OpaqueSyntax{}
+var testFloatSyntax Syntax = FloatSyntax{}
@@ -2,8 +2,10 @@ package mibs
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/snmp"
 )
 
@@ -115,6 +117,10 @@ type Table struct {
 
 	IndexSyntax IndexSyntax
 	EntrySyntax EntrySyntax
+	Description string
+
+	// column-wise walking for sparse tables, default auto
+	WalkMode client.TableWalkMode
 }
 
 func (table Table) EntryOIDs() []snmp.OID {
@@ -155,3 +161,10 @@ func (table Table) Map(varBinds []snmp.VarBind) (IndexMap, EntryMap, error) {
 		return indexMap, entryMap, nil
 	}
 }
+
+// Sort tables by OID
+func SortTables(tables []*Table) {
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].OID.Compare(tables[j].OID) < 0
+	})
+}
@@ -0,0 +1,159 @@
+package mibs
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+)
+
+// Decoded notification VarBind.
+//
+// The Object is nil for unexpected VarBinds that do not match any known object.
+type TrapObject struct {
+	Object      *Object
+	VarBind     snmp.VarBind
+	IndexValues IndexValues
+	Value       Value
+	Error       error
+}
+
+func (trapObject TrapObject) String() string {
+	if trapObject.Object == nil {
+		return fmt.Sprintf("%v=%v", FormatOID(trapObject.VarBind.OID()), trapObject.Value)
+	} else if trapObject.Error != nil {
+		return fmt.Sprintf("%v!%v", trapObject.Object, trapObject.Error)
+	} else {
+		return fmt.Sprintf("%v%v=%v", trapObject.Object, trapObject.Object.IndexSyntax.FormatValues(trapObject.IndexValues), trapObject.Value)
+	}
+}
+
+// Decoded SNMPv2 notification.
+//
+// The Notification is nil if the snmpTrapOID does not match any loaded NOTIFICATION-TYPE.
+type Trap struct {
+	TrapOID      snmp.OID
+	Uptime       TimeTicks
+	Notification *Notification
+
+	// Notification OBJECTS, in order. Missing objects have an Error.
+	Objects []TrapObject
+
+	// Any VarBinds not declared in the notification OBJECTS.
+	Extra []TrapObject
+}
+
+func (trap Trap) String() string {
+	if trap.Notification != nil {
+		return trap.Notification.String()
+	} else {
+		return FormatOID(trap.TrapOID)
+	}
+}
+
+func decodeTrapObject(object *Object, varBind snmp.VarBind) TrapObject {
+	var trapObject = TrapObject{
+		Object:  object,
+		VarBind: varBind,
+	}
+
+	if value, err := object.Unpack(varBind); err != nil {
+		trapObject.Error = err
+	} else if indexValues, err := object.UnpackIndex(varBind.OID()); err != nil {
+		trapObject.Value = value
+		trapObject.Error = err
+	} else {
+		trapObject.Value = value
+		trapObject.IndexValues = indexValues
+	}
+
+	return trapObject
+}
+
+func decodeTrapVarBind(varBind snmp.VarBind) TrapObject {
+	if object := LookupObject(varBind.OID()); object != nil {
+		return decodeTrapObject(object, varBind)
+	} else if value, err := varBind.Value(); err != nil {
+		return TrapObject{VarBind: varBind, Error: err}
+	} else {
+		return TrapObject{VarBind: varBind, Value: value}
+	}
+}
+
+// Decode the VarBinds of an SNMPv2-Trap-PDU or InformRequest-PDU:
+//
+//	sysUpTime.0, snmpTrapOID.0, variable-bindings...
+//
+// Each of the Notification OBJECTS is mapped to the first VarBind within the object's OID tree.
+func DecodeTrap(varBinds []snmp.VarBind) (Trap, error) {
+	var trap Trap
+
+	if len(varBinds) < 2 {
+		return trap, fmt.Errorf("Invalid trap with %d vars", len(varBinds))
+	}
+
+	if oid := varBinds[0].OID(); !oid.Equals(snmp.SysUpTimeOID) {
+		return trap, fmt.Errorf("Invalid trap VarBind[0] OID, expected sysUpTime.0: %v", oid)
+	} else if value, err := varBinds[0].Value(); err != nil {
+		return trap, fmt.Errorf("Invalid trap sysUpTime.0 value: %v", err)
+	} else if timeTicks, ok := value.(snmp.TimeTicks32); !ok {
+		return trap, fmt.Errorf("Invalid trap sysUpTime.0 value: <%T> %v", value, value)
+	} else {
+		trap.Uptime = unpackTimeTicks(int(timeTicks))
+	}
+
+	if oid := varBinds[1].OID(); !oid.Equals(snmp.SNMPTrapOID) {
+		return trap, fmt.Errorf("Invalid trap VarBind[1] OID, expected snmpTrapOID.0: %v", oid)
+	} else if value, err := varBinds[1].Value(); err != nil {
+		return trap, fmt.Errorf("Invalid trap snmpTrapOID.0 value: %v", err)
+	} else if trapOID, ok := value.([]int); !ok {
+		return trap, fmt.Errorf("Invalid trap snmpTrapOID.0 value: <%T> %v", value, value)
+	} else {
+		trap.TrapOID = snmp.OID(trapOID)
+		trap.Notification = LookupNotification(trap.TrapOID)
+	}
+
+	varBinds = varBinds[2:]
+
+	var used = make([]bool, len(varBinds))
+
+	if trap.Notification != nil {
+		trap.Objects = make([]TrapObject, len(trap.Notification.Objects))
+
+		for i, object := range trap.Notification.Objects {
+			var found = false
+
+			for j, varBind := range varBinds {
+				if used[j] || object.OID.Index(varBind.OID()) == nil {
+					continue
+				}
+
+				trap.Objects[i] = decodeTrapObject(object, varBind)
+				used[j] = true
+				found = true
+
+				break
+			}
+
+			if !found {
+				trap.Objects[i] = TrapObject{
+					Object: object,
+					Error:  fmt.Errorf("Missing notification %v object: %v", trap.Notification, object),
+				}
+			}
+		}
+	}
+
+	for j, varBind := range varBinds {
+		if !used[j] {
+			trap.Extra = append(trap.Extra, decodeTrapVarBind(varBind))
+		}
+	}
+
+	return trap, nil
+}
+
+// Decode an SNMPv1 Trap-PDU, translated to the SNMPv2 notification form per RFC 3584.
+//
+// The snmpTrapAddress.0 and snmpTrapEnterprise.0 VarBinds added by the translation are returned as Extra objects.
+func DecodeTrapV1(pdu snmp.TrapPDU) (Trap, error) {
+	return DecodeTrap(pdu.TrapV2VarBinds())
+}
@@ -0,0 +1,121 @@
+package mibs
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func testTrapVarBinds(trapOID snmp.OID, varBinds ...snmp.VarBind) []snmp.VarBind {
+	return append([]snmp.VarBind{
+		snmp.MakeVarBind(snmp.SysUpTimeOID, snmp.TimeTicks32(100)),
+		snmp.MakeVarBind(snmp.SNMPTrapOID, asn1.ObjectIdentifier(trapOID)),
+	}, varBinds...)
+}
+
+func TestDecodeTrap(t *testing.T) {
+	var notification, _ = ResolveNotification("TEST2-MIB::testNotification")
+	var testName, _ = ResolveObject("TEST2-MIB::testName")
+	var testEnum, _ = ResolveObject("TEST2-MIB::testEnum")
+
+	trap, err := DecodeTrap(testTrapVarBinds(notification.OID,
+		snmp.MakeVarBind(testEnum.OID.Extend(0), 2),
+		snmp.MakeVarBind(testName.OID.Extend(10), []byte("foobar")),
+	))
+	if err != nil {
+		t.Fatalf("DecodeTrap: %v", err)
+	}
+
+	assert.Equal(t, "TEST2-MIB::testNotification", trap.String())
+	assert.Equal(t, TimeTicks(1*time.Second), trap.Uptime)
+	assert.Equal(t, notification, trap.Notification)
+	assert.Equal(t, 2, len(trap.Objects))
+	assert.Equal(t, 0, len(trap.Extra))
+
+	assert.Equal(t, testName, trap.Objects[0].Object)
+	assert.NoError(t, trap.Objects[0].Error)
+	assert.Equal(t, IndexValues{Integer(10)}, trap.Objects[0].IndexValues)
+	assert.Equal(t, "foobar", fmt.Sprintf("%v", trap.Objects[0].Value))
+
+	assert.Equal(t, testEnum, trap.Objects[1].Object)
+	assert.NoError(t, trap.Objects[1].Error)
+	assert.Equal(t, IndexValues{}, trap.Objects[1].IndexValues)
+	assert.Equal(t, "two", fmt.Sprintf("%v", trap.Objects[1].Value))
+}
+
+func TestDecodeTrapMissingExtra(t *testing.T) {
+	var notification, _ = ResolveNotification("TEST2-MIB::testNotification")
+	var testName, _ = ResolveObject("TEST2-MIB::testName")
+	var testEnum, _ = ResolveObject("TEST2-MIB::testEnum")
+	var extraOID = snmp.OID{1, 3, 6, 1, 4, 1, 1, 1}
+
+	trap, err := DecodeTrap(testTrapVarBinds(notification.OID,
+		snmp.MakeVarBind(testName.OID.Extend(10), []byte("foobar")),
+		snmp.MakeVarBind(extraOID, 1),
+	))
+	if err != nil {
+		t.Fatalf("DecodeTrap: %v", err)
+	}
+
+	assert.Equal(t, 2, len(trap.Objects))
+	assert.NoError(t, trap.Objects[0].Error)
+	assert.Equal(t, testEnum, trap.Objects[1].Object)
+	assert.EqualError(t, trap.Objects[1].Error, "Missing notification TEST2-MIB::testNotification object: TEST2-MIB::testEnum")
+
+	assert.Equal(t, []TrapObject{
+		{VarBind: snmp.MakeVarBind(extraOID, 1), Value: int64(1)},
+	}, trap.Extra)
+}
+
+func TestDecodeTrapUnknown(t *testing.T) {
+	var trapOID = snmp.OID{1, 3, 6, 1, 4, 1, 1, 0, 1}
+
+	trap, err := DecodeTrap(testTrapVarBinds(trapOID))
+	if err != nil {
+		t.Fatalf("DecodeTrap: %v", err)
+	}
+
+	assert.Nil(t, trap.Notification)
+	assert.Equal(t, trapOID, trap.TrapOID)
+	assert.Equal(t, ".1.3.6.1.4.1.1.0.1", trap.String())
+}
+
+func TestDecodeTrapInvalid(t *testing.T) {
+	_, err := DecodeTrap([]snmp.VarBind{
+		snmp.MakeVarBind(snmp.SNMPTrapOID, asn1.ObjectIdentifier{1, 0, 2, 0, 1}),
+		snmp.MakeVarBind(snmp.SysUpTimeOID, snmp.TimeTicks32(100)),
+	})
+
+	assert.EqualError(t, err, "Invalid trap VarBind[0] OID, expected sysUpTime.0: .1.3.6.1.6.3.1.1.4.1.0")
+}
+
+func TestDecodeTrapV1(t *testing.T) {
+	var notification, _ = ResolveNotification("TEST2-MIB::testNotification")
+	var testName, _ = ResolveObject("TEST2-MIB::testName")
+
+	trap, err := DecodeTrapV1(snmp.TrapPDU{
+		Enterprise:   asn1.ObjectIdentifier{1, 0, 2},
+		AgentAddr:    net.IP{10, 0, 0, 1},
+		GenericTrap:  snmp.TrapEnterpriseSpecific,
+		SpecificTrap: 1,
+		TimeStamp:    100,
+		VarBinds: []snmp.VarBind{
+			snmp.MakeVarBind(testName.OID.Extend(10), []byte("foobar")),
+		},
+	})
+	if err != nil {
+		t.Fatalf("DecodeTrapV1: %v", err)
+	}
+
+	assert.Equal(t, notification, trap.Notification)
+	assert.Equal(t, TimeTicks(1*time.Second), trap.Uptime)
+	assert.NoError(t, trap.Objects[0].Error)
+	assert.Error(t, trap.Objects[1].Error)
+	assert.Equal(t, 2, len(trap.Extra))
+	assert.Equal(t, snmp.SNMPTrapAddressOID, trap.Extra[0].VarBind.OID())
+	assert.Equal(t, snmp.SNMPTrapEnterpriseOID, trap.Extra[1].VarBind.OID())
+}
@@ -0,0 +1,217 @@
+package mibs
+
+import (
+	"github.com/qmsk/snmpbot/snmp"
+)
+
+// OID radix tree, with each edge labeled by one or more OID arcs.
+//
+// Children are kept sorted by their first arc, for ordered traversal.
+type oidTree struct {
+	arcs     snmp.OID // edge from parent node
+	set      bool
+	id       ID
+	children []*oidTree
+}
+
+func commonPrefix(a, b snmp.OID) int {
+	var n = 0
+
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	return n
+}
+
+// Return the index for the child beginning with the given arc, and the child if it exists.
+func (tree *oidTree) child(arc int) (int, *oidTree) {
+	var lo, hi = 0, len(tree.children)
+
+	for lo < hi {
+		var mid = (lo + hi) / 2
+
+		if tree.children[mid].arcs[0] < arc {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	if lo < len(tree.children) && tree.children[lo].arcs[0] == arc {
+		return lo, tree.children[lo]
+	} else {
+		return lo, nil
+	}
+}
+
+func (tree *oidTree) insert(oid snmp.OID, id ID) {
+	if len(oid) == 0 {
+		tree.set = true
+		tree.id = id
+
+		return
+	}
+
+	var i, child = tree.child(oid[0])
+
+	if child == nil {
+		child = &oidTree{arcs: append(snmp.OID(nil), oid...), set: true, id: id}
+
+		tree.children = append(tree.children, nil)
+		copy(tree.children[i+1:], tree.children[i:])
+		tree.children[i] = child
+
+		return
+	}
+
+	var n = commonPrefix(child.arcs, oid)
+
+	if n < len(child.arcs) {
+		// split the edge
+		var split = &oidTree{
+			arcs:     append(snmp.OID(nil), child.arcs[:n]...),
+			children: []*oidTree{child},
+		}
+
+		child.arcs = append(snmp.OID(nil), child.arcs[n:]...)
+		tree.children[i] = split
+		child = split
+	}
+
+	child.insert(oid[n:], id)
+}
+
+// Find the longest prefix of the OID with a set ID.
+func (tree *oidTree) lookup(oid snmp.OID) (ID, bool) {
+	var id ID
+	var ok bool
+
+	for node := tree; node != nil; {
+		if node.set {
+			id = node.id
+			ok = true
+		}
+
+		if len(oid) == 0 {
+			break
+		} else if _, child := node.child(oid[0]); child == nil || commonPrefix(child.arcs, oid) < len(child.arcs) {
+			break
+		} else {
+			oid = oid[len(child.arcs):]
+			node = child
+		}
+	}
+
+	return id, ok
+}
+
+// Walk all set IDs in OID order.
+func (tree *oidTree) walk(f func(ID)) {
+	if tree.set {
+		f(tree.id)
+	}
+
+	for _, child := range tree.children {
+		child.walk(f)
+	}
+}
+
+// Walk all set IDs within the given OID prefix, in OID order.
+func (tree *oidTree) walkPrefix(oid snmp.OID, f func(ID)) {
+	var node = tree
+
+	for len(oid) > 0 {
+		if _, child := node.child(oid[0]); child == nil {
+			return
+		} else if n := commonPrefix(child.arcs, oid); n == len(oid) {
+			// prefix ends within or at the end of the child edge
+			node = child
+			oid = nil
+		} else if n < len(child.arcs) {
+			return
+		} else {
+			oid = oid[n:]
+			node = child
+		}
+	}
+
+	node.walk(f)
+}
+
+// Remove all IDs matching the given func, pruning any empty nodes.
+//
+// Returns false if the tree node is left empty.
+func (tree *oidTree) remove(f func(ID) bool) bool {
+	if tree.set && f(tree.id) {
+		tree.set = false
+		tree.id = ID{}
+	}
+
+	var children = tree.children[:0]
+
+	for _, child := range tree.children {
+		if child.remove(f) {
+			children = append(children, child)
+		}
+	}
+
+	for i := len(children); i < len(tree.children); i++ {
+		tree.children[i] = nil
+	}
+
+	tree.children = children
+
+	if !tree.set && len(tree.children) == 1 && tree.arcs != nil {
+		// merge with the single remaining child
+		var child = tree.children[0]
+
+		tree.arcs = append(tree.arcs, child.arcs...)
+		tree.set = child.set
+		tree.id = child.id
+		tree.children = child.children
+	}
+
+	return tree.set || len(tree.children) > 0
+}
+
+// Hierarchy of registered IDs, with each ID nested under the closest registered parent ID.
+type TreeNode struct {
+	ID       ID
+	Children []*TreeNode
+}
+
+func Tree(root snmp.OID) *TreeNode {
+	return DefaultRegistry.Tree(root)
+}
+
+// Build the hierarchy of MIB and object/table/notification IDs within the given OID subtree, in OID order.
+//
+// The top-level node for the root OID has an ID without any Name if the root OID is not registered.
+func (registry *Registry) Tree(root snmp.OID) *TreeNode {
+	var tree = &TreeNode{ID: ID{OID: root}}
+	var stack = []*TreeNode{tree}
+
+	if id := registry.Lookup(root); id.MIB != nil {
+		tree.ID.MIB = id.MIB
+	}
+
+	for _, id := range registry.listTree(root, true) {
+		if len(root) > 0 && id.OID.Equals(root) {
+			tree.ID = id
+			continue
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].ID.OID.Index(id.OID) == nil {
+			stack = stack[:len(stack)-1]
+		}
+
+		var node = &TreeNode{ID: id}
+		var parent = stack[len(stack)-1]
+
+		parent.Children = append(parent.Children, node)
+		stack = append(stack, node)
+	}
+
+	return tree
+}
@@ -0,0 +1,180 @@
+package mibs
+
+import (
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func makeTestTree(names ...string) *oidTree {
+	var tree oidTree
+
+	for _, name := range names {
+		if oid, err := snmp.ParseOID(name); err != nil {
+			panic(err)
+		} else {
+			tree.insert(oid, ID{Name: name, OID: oid})
+		}
+	}
+
+	return &tree
+}
+
+func walkTestTree(walk func(func(ID))) []string {
+	var names []string
+
+	walk(func(id ID) {
+		names = append(names, id.Name)
+	})
+
+	return names
+}
+
+func TestTreeWalk(t *testing.T) {
+	var tree = makeTestTree(".1.3.6.1.2.1.2.2", ".1.3.6.1.2.1.1", ".1.3.6.1.2.1.10", ".1.3.6.1.2.1.2", ".1.3.6.1.2.1.1.1", ".1.3.6.1.4.1")
+
+	assert.Equal(t, []string{
+		".1.3.6.1.2.1.1",
+		".1.3.6.1.2.1.1.1",
+		".1.3.6.1.2.1.2",
+		".1.3.6.1.2.1.2.2",
+		".1.3.6.1.2.1.10",
+		".1.3.6.1.4.1",
+	}, walkTestTree(tree.walk))
+}
+
+func TestTreeLookup(t *testing.T) {
+	var tree = makeTestTree(".1.3.6.1.2.1.1", ".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.2")
+
+	var tests = []struct {
+		oid  snmp.OID
+		name string
+		ok   bool
+	}{
+		{snmp.OID{1, 3, 6, 1, 2, 1, 1}, ".1.3.6.1.2.1.1", true},
+		{snmp.OID{1, 3, 6, 1, 2, 1, 1, 1, 0}, ".1.3.6.1.2.1.1.1", true},
+		{snmp.OID{1, 3, 6, 1, 2, 1, 1, 2, 0}, ".1.3.6.1.2.1.1", true},
+		{snmp.OID{1, 3, 6, 1, 2, 1, 2, 2, 1}, ".1.3.6.1.2.1.2", true},
+		{snmp.OID{1, 3, 6, 1, 2, 1}, "", false},
+		{snmp.OID{1, 3, 6, 1, 2, 1, 3}, "", false},
+		{snmp.OID{1, 3, 6, 2}, "", false},
+		{nil, "", false},
+	}
+
+	for _, test := range tests {
+		id, ok := tree.lookup(test.oid)
+
+		assert.Equal(t, test.ok, ok, "lookup %v", test.oid)
+		assert.Equal(t, test.name, id.Name, "lookup %v", test.oid)
+	}
+}
+
+func TestTreeWalkPrefix(t *testing.T) {
+	var tree = makeTestTree(".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.2", ".1.3.6.1.2.1.2.1", ".1.3.6.1.4.1")
+
+	assert.Equal(t, []string{".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.2"}, walkTestTree(func(f func(ID)) {
+		tree.walkPrefix(snmp.OID{1, 3, 6, 1, 2, 1, 1}, f)
+	}))
+	assert.Equal(t, []string{".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.2", ".1.3.6.1.2.1.2.1"}, walkTestTree(func(f func(ID)) {
+		tree.walkPrefix(snmp.OID{1, 3, 6, 1, 2}, f)
+	}))
+	assert.Equal(t, []string{".1.3.6.1.2.1.2.1"}, walkTestTree(func(f func(ID)) {
+		tree.walkPrefix(snmp.OID{1, 3, 6, 1, 2, 1, 2, 1}, f)
+	}))
+	assert.Nil(t, walkTestTree(func(f func(ID)) {
+		tree.walkPrefix(snmp.OID{1, 3, 6, 1, 3}, f)
+	}))
+	assert.Nil(t, walkTestTree(func(f func(ID)) {
+		tree.walkPrefix(snmp.OID{1, 3, 6, 1, 2, 1, 1, 1, 0}, f)
+	}))
+}
+
+func TestTreeRemove(t *testing.T) {
+	var tree = makeTestTree(".1.3.6.1.2.1.1", ".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.2", ".1.3.6.1.4.1")
+
+	tree.remove(func(id ID) bool {
+		return id.Name == ".1.3.6.1.2.1.1" || id.Name == ".1.3.6.1.4.1"
+	})
+
+	assert.Equal(t, []string{".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.2"}, walkTestTree(tree.walk))
+
+	if id, ok := tree.lookup(snmp.OID{1, 3, 6, 1, 2, 1, 1, 1, 0}); assert.True(t, ok) {
+		assert.Equal(t, ".1.3.6.1.2.1.1.1", id.Name)
+	}
+
+	_, ok := tree.lookup(snmp.OID{1, 3, 6, 1, 2, 1, 1, 2})
+
+	assert.False(t, ok)
+
+	tree.remove(func(id ID) bool {
+		return id.Name == ".1.3.6.1.2.1.2"
+	})
+
+	assert.Equal(t, []string{".1.3.6.1.2.1.1.1"}, walkTestTree(tree.walk))
+	assert.Equal(t, 1, len(tree.children))
+	assert.Equal(t, snmp.OID{1, 3, 6, 1, 2, 1, 1, 1}, tree.children[0].arcs)
+
+	tree.insert(snmp.OID{1, 3, 6, 1, 2, 1, 1, 2}, ID{Name: ".1.3.6.1.2.1.1.2"})
+
+	assert.Equal(t, []string{".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.2"}, walkTestTree(tree.walk))
+}
+
+func TestRegistryWalkOrder(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var names []string
+
+	registry.Walk(func(id ID) {
+		names = append(names, id.String())
+	})
+
+	assert.Equal(t, []string{
+		"TEST-BASE-MIB::baseTable",
+		"TEST-BASE-MIB::baseIndex",
+		"TEST-BASE-MIB::baseName",
+		"TEST-EXT-MIB::extTable",
+		"TEST-EXT-MIB::extName",
+	}, names)
+}
+
+func TestRegistryWalkTree(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var names []string
+
+	registry.WalkTree(snmp.OID{1, 0, 3, 1, 1}, func(id ID) {
+		names = append(names, id.String())
+	})
+
+	assert.Equal(t, []string{
+		"TEST-BASE-MIB::baseIndex",
+		"TEST-BASE-MIB::baseName",
+	}, names)
+}
+
+func TestRegistryTree(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var tree = registry.Tree(snmp.OID{1, 0, 3})
+
+	assert.Equal(t, "TEST-BASE-MIB", tree.ID.String())
+	if assert.Equal(t, 1, len(tree.Children)) {
+		var table = tree.Children[0]
+
+		assert.Equal(t, "TEST-BASE-MIB::baseTable", table.ID.String())
+		if assert.Equal(t, 2, len(table.Children)) {
+			assert.Equal(t, "TEST-BASE-MIB::baseIndex", table.Children[0].ID.String())
+			assert.Equal(t, "TEST-BASE-MIB::baseName", table.Children[1].ID.String())
+		}
+	}
+}
+
+func TestRegistryTreeRoot(t *testing.T) {
+	var registry = makeTestRegistry(t)
+	var tree = registry.Tree(snmp.OID{1, 0})
+	var names []string
+
+	for _, node := range tree.Children {
+		names = append(names, node.ID.String())
+	}
+
+	assert.Equal(t, ".1.0", tree.ID.String())
+	assert.Equal(t, []string{"TEST-BASE-MIB", "TEST-EXT-MIB"}, names)
+}
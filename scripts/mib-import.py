@@ -53,6 +53,17 @@ def parseDeclArg(arg):
 def parseDeclArgs(args):
     return tuple(parseDeclArg(arg) for arg in args)
 
+def parseDescription(description):
+    """
+    Returns the DESCRIPTION text with normalized whitespace, or None if missing (e.g. notexts MIBs).
+    """
+    if not description:
+        return None
+    elif isinstance(description, dict):
+        description, = description.values()
+
+    return ' '.join(str(description).strip('"').split()) or None
+
 def parseOIDPart(part):
     if isinstance(part, tuple):
         name, id = part
@@ -107,6 +118,7 @@ class Context:
         ('SNMPv2-TC', 'PhysAddress'),
         ('Q-BRIDGE-MIB', 'PortList'),
         ('BRIDGE-MIB', 'BridgeId'),
+        ('UCD-SNMP-MIB', 'Float'),
     ])
 
     @classmethod
@@ -140,6 +152,7 @@ class Context:
         self.moduleOID = None
         self.objects = []
         self.tables = []
+        self.notifications = []
 
     def lookupSymbol(self, mib, name):
         sym = self.symbolCache.get((mib, name))
@@ -317,7 +330,7 @@ class Context:
 
         log.info("load mib %s@%s", name, self.moduleOID)
 
-    def load_objectTypeClause_object(self, name, syntax, maxAccessPart, oid):
+    def load_objectTypeClause_object(self, name, syntax, maxAccessPart, description, oid):
         oid = self.parseObjectIdentifier(oid['objectIdentifier'])
 
         syntax_name, syntax_options = self.parseObjectSyntax(syntax)
@@ -338,11 +351,16 @@ class Context:
         if maxAccessPart and maxAccessPart['MaxAccessPart'] == 'not-accessible':
             object['NotAccessible'] = True
 
+        description = parseDescription(description)
+
+        if description:
+            object['Description'] = description
+
         log.info("load object %s::%s@%s: %r", self.moduleName, name, oid, object)
 
         self.objects.append(object)
 
-    def load_objectTypeClause_table(self, name, syntax, oid):
+    def load_objectTypeClause_table(self, name, syntax, description, oid):
         conceptualTable = parseDeclArg(syntax['conceptualTable'])
         oid = self.parseObjectIdentifier(oid['objectIdentifier'])
 
@@ -353,6 +371,11 @@ class Context:
             'OID': str(oid),
         }
 
+        description = parseDescription(description)
+
+        if description:
+            table['Description'] = description
+
         log.info("load table %s::%s@%s with entryType=%s", self.moduleName, name, oid, entryType)
 
         self.tables.append(table)
@@ -386,11 +409,24 @@ class Context:
     # load objects once all types are registered
     def load_objectTypeClause(self, name, syntax, units, maxAccessPart, status, description, reference, augmention, index, defval, oid):
         if 'conceptualTable' in syntax:
-            return self.load_objectTypeClause_table(name, syntax, oid)
+            return self.load_objectTypeClause_table(name, syntax, description, oid)
         elif 'row' in syntax and syntax['row'] in self.entryTypes:
             return self.load_objectTypeClause_entry(name, syntax, augmention, index, oid)
         else:
-            return self.load_objectTypeClause_object(name, syntax, maxAccessPart, oid)
+            return self.load_objectTypeClause_object(name, syntax, maxAccessPart, description, oid)
+
+    def load_notificationTypeClause(self, name, objects, description, reference, oid):
+        oid = self.parseObjectIdentifier(oid['objectIdentifier'])
+
+        notification = {
+            'Name': name,
+            'OID': str(oid),
+            'Objects': [self.formatObject(object) for object in objects or []],
+        }
+
+        log.info("load notification %s::%s@%s: %r", self.moduleName, name, oid, notification)
+
+        self.notifications.append(notification)
 
 class CodeGen(pysmi.codegen.base.AbstractCodeGen):
     def genCode(self, ast, symbolTable, **kwargs):
@@ -444,6 +480,11 @@ class CodeGen(pysmi.codegen.base.AbstractCodeGen):
                     log.debug("load mib=%s <%s>%s: %s", moduleName, type, name, args)
 
                     ctx.load_objectTypeClause(name, *args)
+
+                elif type == 'notificationTypeClause':
+                    log.debug("load mib=%s <%s>%s: %s", moduleName, type, name, args)
+
+                    ctx.load_notificationTypeClause(name, *args)
             except Exception as exc:
                 log.exception("Failed to load {type} {mib}::{name}: {exc}".format(type=type, mib=moduleName, name=name, exc=exc))
                 raise exc
@@ -452,6 +493,7 @@ class CodeGen(pysmi.codegen.base.AbstractCodeGen):
             'Name': moduleName,
             'Objects': ctx.objects,
             'Tables': ctx.tables,
+            'Notifications': ctx.notifications,
         }
 
         if ctx.moduleOID:
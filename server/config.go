@@ -28,7 +28,13 @@ func (err ConfigKeysError) Error() string {
 
 type Config struct {
 	ClientOptions client.Options
+	Credentials   map[string]CredentialConfig
+	Quirks        map[string]QuirkConfig
 	Hosts         map[string]HostConfig
+	Proxy         ProxyConfig
+
+	// maximum number of hosts queried concurrently, default mibs.DefaultMultiConcurrency
+	QueryConcurrency uint
 }
 
 func (config *Config) LoadTOML(path string) error {
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/mibs"
 	"github.com/stretchr/testify/assert"
 )
@@ -40,3 +43,81 @@ func TestEngineTables(t *testing.T) {
 
 	assert.ElementsMatch(t, []string{"TEST-MIB::testTable"}, engine.Tables().Strings(), "Engine.Tables()")
 }
+
+func TestLoadTOMLCredentials(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "config.toml")
+	var config Config
+
+	if err := ioutil.WriteFile(path, []byte(`
+[credentials.legacy]
+Community = "secret"
+
+[hosts.test]
+SNMP = "localhost"
+Credentials = ["legacy"]
+`), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	assert.NoError(t, config.LoadTOML(path))
+	assert.Equal(t, map[string]CredentialConfig{"legacy": CredentialConfig{Community: "secret"}}, config.Credentials)
+	assert.Equal(t, []string{"legacy"}, config.Hosts["test"].Credentials)
+}
+
+func TestLoadTOMLProxy(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "config.toml")
+	var config Config
+
+	if err := ioutil.WriteFile(path, []byte(`
+[proxy]
+Listen = ":1161"
+Community = "proxy"
+
+[proxy.Hosts]
+legacy = "test"
+`), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	assert.NoError(t, config.LoadTOML(path))
+	assert.Equal(t, ProxyConfig{Listen: ":1161", Community: "proxy", Hosts: map[string]HostID{"legacy": "test"}}, config.Proxy)
+}
+
+func TestLoadTOMLQuirks(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "config.toml")
+	var config Config
+
+	if err := ioutil.WriteFile(path, []byte(`
+[quirks.edgeswitch]
+SysObjectID = ".1.3.6.1.4.1.4413"
+NoBulk = true
+TableWalkMode = "columns"
+NoSuchEndOfMibView = true
+TruncateCounter64 = true
+
+[hosts.test]
+SNMP = "localhost"
+Quirks = "edgeswitch"
+`), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	assert.NoError(t, config.LoadTOML(path))
+
+	var quirk = config.Quirks["edgeswitch"]
+
+	assert.Equal(t, ".1.3.6.1.4.1.4413", quirk.SysObjectID)
+	if assert.NotNil(t, quirk.NoBulk) {
+		assert.True(t, *quirk.NoBulk)
+	}
+	if assert.NotNil(t, quirk.TableWalkMode) {
+		assert.Equal(t, client.TableWalkColumns, *quirk.TableWalkMode)
+	}
+	if assert.NotNil(t, quirk.NoSuchEndOfMibView) {
+		assert.True(t, *quirk.NoSuchEndOfMibView)
+	}
+	if assert.NotNil(t, quirk.TruncateCounter64) {
+		assert.True(t, *quirk.TruncateCounter64)
+	}
+	assert.Equal(t, "edgeswitch", config.Hosts["test"].Quirks)
+}
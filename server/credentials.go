@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Named SNMP credentials, referenced by HostConfig.Credentials.
+//
+// The secret community is loaded from one of Community, CommunityEnv or CommunityFile.
+type CredentialConfig struct {
+	Community     string
+	CommunityEnv  string // environment variable
+	CommunityFile string // file contents, without any trailing newline
+}
+
+func (config CredentialConfig) loadCommunity() (string, error) {
+	if config.Community != "" {
+		return config.Community, nil
+	} else if config.CommunityEnv != "" {
+		if value, ok := os.LookupEnv(config.CommunityEnv); !ok {
+			return "", fmt.Errorf("Environment variable %v is not set", config.CommunityEnv)
+		} else {
+			return value, nil
+		}
+	} else if config.CommunityFile != "" {
+		if buf, err := ioutil.ReadFile(config.CommunityFile); err != nil {
+			return "", err
+		} else {
+			return strings.TrimRight(string(buf), "\r\n"), nil
+		}
+	} else {
+		return "", fmt.Errorf("Missing Community, CommunityEnv or CommunityFile")
+	}
+}
+
+type Credential struct {
+	Name      string
+	Community string // secret
+}
+
+func (credential Credential) String() string {
+	return credential.Name
+}
+
+type Credentials map[string]Credential
+
+func loadCredentials(configs map[string]CredentialConfig) (Credentials, error) {
+	var credentials = make(Credentials, len(configs))
+
+	for name, config := range configs {
+		if community, err := config.loadCommunity(); err != nil {
+			return nil, fmt.Errorf("Invalid credentials %v: %v", name, err)
+		} else {
+			credentials[name] = Credential{Name: name, Community: community}
+		}
+	}
+
+	return credentials, nil
+}
+
+// Lookup credentials in order
+func (credentials Credentials) List(names ...string) ([]Credential, error) {
+	var list = make([]Credential, len(names))
+
+	for i, name := range names {
+		if credential, ok := credentials[name]; !ok {
+			return nil, fmt.Errorf("Unknown credentials: %v", name)
+		} else {
+			list[i] = credential
+		}
+	}
+
+	return list, nil
+}
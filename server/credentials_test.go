@@ -0,0 +1,57 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCredentials(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "community")
+
+	if err := ioutil.WriteFile(path, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	os.Setenv("SNMPBOT_TEST_COMMUNITY", "env-secret")
+	defer os.Unsetenv("SNMPBOT_TEST_COMMUNITY")
+
+	credentials, err := loadCredentials(map[string]CredentialConfig{
+		"config": CredentialConfig{Community: "secret"},
+		"env":    CredentialConfig{CommunityEnv: "SNMPBOT_TEST_COMMUNITY"},
+		"file":   CredentialConfig{CommunityFile: path},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{
+		"config": Credential{Name: "config", Community: "secret"},
+		"env":    Credential{Name: "env", Community: "env-secret"},
+		"file":   Credential{Name: "file", Community: "file-secret"},
+	}, credentials)
+
+	list, err := credentials.List("file", "config")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Credential{credentials["file"], credentials["config"]}, list)
+
+	_, err = credentials.List("unknown")
+
+	assert.EqualError(t, err, "Unknown credentials: unknown")
+}
+
+func TestLoadCredentialsError(t *testing.T) {
+	_, err := loadCredentials(map[string]CredentialConfig{
+		"env": CredentialConfig{CommunityEnv: "SNMPBOT_TEST_MISSING"},
+	})
+
+	assert.EqualError(t, err, "Invalid credentials env: Environment variable SNMPBOT_TEST_MISSING is not set")
+
+	_, err = loadCredentials(map[string]CredentialConfig{
+		"empty": CredentialConfig{},
+	})
+
+	assert.EqualError(t, err, "Invalid credentials empty: Missing Community, CommunityEnv or CommunityFile")
+}
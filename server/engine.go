@@ -3,20 +3,31 @@ package server
 import (
 	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
 )
 
 type engineClient interface {
-	String() string
+	mibs.MultiTarget
+
 	Probe(ids []mibs.ID) ([]bool, error)
-	WalkObjects(objects []*mibs.Object, f func(*mibs.Object, mibs.IndexValues, mibs.Value, error) error) error
-	WalkTable(table *mibs.Table, f func(mibs.IndexValues, mibs.EntryValues, error) error) error
+	WalkTablePage(table *mibs.Table, after snmp.OID, limit int, f func(mibs.IndexValues, mibs.EntryValues, error) error) (snmp.OID, error)
+	WalkContexts(table *mibs.Table) ([]mibs.Context, error)
+	WalkTableContexts(table *mibs.Table, contexts []mibs.Context, f func(mibs.Context, mibs.IndexValues, mibs.EntryValues, error) error) error
+	Forward(requestType snmp.PDUType, pdu snmp.PDU) (snmp.PDUType, snmp.PDU, error)
 }
 
 type Engine interface {
 	ClientOptions() client.Options
+	ClientStats() client.EngineStats
+	Credentials() Credentials
+	Quirks() Quirks
 	client(config client.Config) (engineClient, error)
 
 	MIBs() MIBs
+	LoadMIB(config mibs.MIBConfig) (*mibs.MIB, error)
+	ReplaceMIB(config mibs.MIBConfig) (*mibs.MIB, error)
+	UnloadMIB(mib *mibs.MIB) error
+
 	Objects() Objects
 	Tables() Tables
 
@@ -32,7 +43,6 @@ type Engine interface {
 func newEngine(clientEngine *client.Engine) *engine {
 	return &engine{
 		clientEngine: clientEngine,
-		mibs:         AllMIBs(),
 		hosts:        makeEngineHosts(),
 	}
 }
@@ -40,13 +50,28 @@ func newEngine(clientEngine *client.Engine) *engine {
 type engine struct {
 	clientEngine  *client.Engine
 	clientOptions client.Options
+	credentials   Credentials
+	quirks        Quirks
+	concurrency   uint
 
-	mibs  MIBs
 	hosts engineHosts
 }
 
 func (engine *engine) loadConfig(config Config) error {
 	engine.clientOptions = config.ClientOptions
+	engine.concurrency = config.QueryConcurrency
+
+	if credentials, err := loadCredentials(config.Credentials); err != nil {
+		return err
+	} else {
+		engine.credentials = credentials
+	}
+
+	if quirks, err := loadQuirks(config.Quirks); err != nil {
+		return err
+	} else {
+		engine.quirks = quirks
+	}
 
 	for hostName, hostConfig := range config.Hosts {
 		go engine.loadHost(HostID(hostName), hostConfig)
@@ -75,6 +100,18 @@ func (engine *engine) ClientOptions() client.Options {
 	return engine.clientOptions
 }
 
+func (engine *engine) ClientStats() client.EngineStats {
+	return engine.clientEngine.Stats()
+}
+
+func (engine *engine) Credentials() Credentials {
+	return engine.credentials
+}
+
+func (engine *engine) Quirks() Quirks {
+	return engine.quirks
+}
+
 func (engine *engine) client(config client.Config) (engineClient, error) {
 	if c, err := client.NewClient(engine.clientEngine, config); err != nil {
 		return nil, err
@@ -84,7 +121,19 @@ func (engine *engine) client(config client.Config) (engineClient, error) {
 }
 
 func (engine *engine) MIBs() MIBs {
-	return engine.mibs
+	return AllMIBs()
+}
+
+func (engine *engine) LoadMIB(config mibs.MIBConfig) (*mibs.MIB, error) {
+	return mibs.LoadMIBConfig(config)
+}
+
+func (engine *engine) ReplaceMIB(config mibs.MIBConfig) (*mibs.MIB, error) {
+	return mibs.ReplaceMIBConfig(config)
+}
+
+func (engine *engine) UnloadMIB(mib *mibs.MIB) error {
+	return mibs.UnloadMIB(mib)
 }
 
 func (engine *engine) Objects() Objects {
@@ -118,6 +167,7 @@ func (engine *engine) QueryObjects(query ObjectQuery) <-chan ObjectResult {
 
 	var q = objectQuery{
 		ObjectQuery: query,
+		concurrency: engine.concurrency,
 		resultChan:  make(chan ObjectResult),
 	}
 
@@ -130,8 +180,9 @@ func (engine *engine) QueryTables(query TableQuery) <-chan TableResult {
 	log.Infof("Query tables %v @ %v", query.Tables, query.Hosts)
 
 	var q = tableQuery{
-		TableQuery: query,
-		resultChan: make(chan TableResult),
+		TableQuery:  query,
+		concurrency: engine.concurrency,
+		resultChan:  make(chan TableResult),
 	}
 
 	go q.query()
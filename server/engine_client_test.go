@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -26,6 +27,21 @@ func (c *testEngineClient) Probe(ids []mibs.ID) ([]bool, error) {
 	}
 }
 
+func (c *testEngineClient) Requests() uint64 {
+	return 0
+}
+
+func (c *testEngineClient) Get(oids ...snmp.OID) ([]snmp.VarBind, error) {
+	if c.mock != nil {
+		var args = c.mock.MethodCalled("Get", oids)
+		var varBinds, _ = args.Get(0).([]snmp.VarBind)
+
+		return varBinds, args.Error(1)
+	} else {
+		return nil, nil
+	}
+}
+
 func (c *testEngineClient) WalkObjects(objects []*mibs.Object, f func(*mibs.Object, mibs.IndexValues, mibs.Value, error) error) error {
 	return nil // TODO
 }
@@ -33,3 +49,57 @@ func (c *testEngineClient) WalkObjects(objects []*mibs.Object, f func(*mibs.Obje
 func (c *testEngineClient) WalkTable(table *mibs.Table, f func(mibs.IndexValues, mibs.EntryValues, error) error) error {
 	return nil // TODO
 }
+
+func (c *testEngineClient) WalkTablePage(table *mibs.Table, after snmp.OID, limit int, f func(mibs.IndexValues, mibs.EntryValues, error) error) (snmp.OID, error) {
+	if c.mock != nil {
+		var args = c.mock.MethodCalled("WalkTablePage", table, after, limit)
+
+		for _, entry := range args.Get(0).([]TableResult) {
+			if err := f(entry.IndexValues, entry.EntryValues, entry.Error); err != nil {
+				return nil, err
+			}
+		}
+
+		return args.Get(1).(snmp.OID), args.Error(2)
+	} else {
+		return nil, nil
+	}
+}
+
+func (c *testEngineClient) WalkContexts(table *mibs.Table) ([]mibs.Context, error) {
+	if c.mock != nil {
+		var args = c.mock.MethodCalled("WalkContexts", table)
+		var contexts, _ = args.Get(0).([]mibs.Context)
+
+		return contexts, args.Error(1)
+	} else {
+		return nil, nil
+	}
+}
+
+func (c *testEngineClient) WalkTableContexts(table *mibs.Table, contexts []mibs.Context, f func(mibs.Context, mibs.IndexValues, mibs.EntryValues, error) error) error {
+	if c.mock != nil {
+		var args = c.mock.MethodCalled("WalkTableContexts", table, contexts)
+
+		for _, entry := range args.Get(0).([]TableResult) {
+			if err := f(*entry.Context, entry.IndexValues, entry.EntryValues, entry.Error); err != nil {
+				return err
+			}
+		}
+
+		return args.Error(1)
+	} else {
+		return nil
+	}
+}
+
+func (c *testEngineClient) Forward(requestType snmp.PDUType, pdu snmp.PDU) (snmp.PDUType, snmp.PDU, error) {
+	if c.mock != nil {
+		var args = c.mock.MethodCalled("Forward", requestType, pdu)
+		var response, _ = args.Get(1).(snmp.PDU)
+
+		return args.Get(0).(snmp.PDUType), response, args.Error(2)
+	} else {
+		return snmp.GetResponseType, pdu, nil
+	}
+}
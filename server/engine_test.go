@@ -2,19 +2,25 @@ package server
 
 import (
 	"github.com/qmsk/snmpbot/client"
+	"github.com/qmsk/snmpbot/mibs"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 type testConfig struct {
-	hosts map[HostID]HostConfig
-	mibs  MIBs
+	hosts       map[HostID]HostConfig
+	mibs        MIBs
+	credentials Credentials
+	quirks      Quirks
 
 	clientMock bool
 }
 
 type testEngine struct {
-	hosts engineHosts
-	mibs  MIBs
+	hosts       engineHosts
+	mibs        MIBs
+	credentials Credentials
+	quirks      Quirks
 
 	mock.Mock
 	clientMock *mock.Mock
@@ -22,7 +28,9 @@ type testEngine struct {
 
 func makeTestEngine(config testConfig) *testEngine {
 	var engine = testEngine{
-		hosts: makeEngineHosts(),
+		hosts:       makeEngineHosts(),
+		credentials: config.credentials,
+		quirks:      config.quirks,
 	}
 
 	if config.mibs != nil {
@@ -54,6 +62,30 @@ func (e *testEngine) ClientOptions() client.Options {
 	}
 }
 
+func (e *testEngine) ClientStats() client.EngineStats {
+	return client.EngineStats{
+		Requests:  2,
+		Responses: 1,
+		Timeouts:  1,
+		Agents: map[string]client.AgentStats{
+			"192.0.2.1:161": client.AgentStats{
+				Requests:  2,
+				Responses: 1,
+				Timeouts:  1,
+				SRTT:      10 * time.Millisecond,
+			},
+		},
+	}
+}
+
+func (e *testEngine) Credentials() Credentials {
+	return e.credentials
+}
+
+func (e *testEngine) Quirks() Quirks {
+	return e.quirks
+}
+
 func (e *testEngine) mockClient(snmp string, clientErr error) {
 	if clientOptions, err := client.ParseConfig(e.ClientOptions(), snmp); err != nil {
 		panic(err)
@@ -77,6 +109,40 @@ func (e *testEngine) MIBs() MIBs {
 	return e.mibs
 }
 
+func (e *testEngine) LoadMIB(config mibs.MIBConfig) (*mibs.MIB, error) {
+	if mib, err := mibs.LoadMIBConfig(config); err != nil {
+		return nil, err
+	} else {
+		e.mibs = MakeMIBs(append(e.mibs.List(), mib)...)
+
+		return mib, nil
+	}
+}
+
+func (e *testEngine) ReplaceMIB(config mibs.MIBConfig) (*mibs.MIB, error) {
+	if mib, err := mibs.ReplaceMIBConfig(config); err != nil {
+		return nil, err
+	} else {
+		e.mibs = MakeMIBs(append(e.mibs.List(), mib)...)
+
+		return mib, nil
+	}
+}
+
+func (e *testEngine) UnloadMIB(mib *mibs.MIB) error {
+	if err := mibs.UnloadMIB(mib); err != nil {
+		return err
+	} else {
+		var mibs = MakeMIBs(e.mibs.List()...)
+
+		delete(mibs, mib.Name)
+
+		e.mibs = mibs
+
+		return nil
+	}
+}
+
 func (e *testEngine) Objects() Objects {
 	return AllObjects()
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"github.com/qmsk/go-logging"
 	"github.com/qmsk/go-web"
@@ -17,6 +18,19 @@ type HostConfig struct {
 
 	// optional, defaults to global config
 	ClientOptions *client.Options
+
+	// optional, overrides the ClientOptions request limits for this host
+	Limits *client.Limits
+
+	// optional named client transport, instead of any NAME+snmp:// scheme in the SNMP URL
+	Transport string
+
+	// optional names of Config.Credentials to use instead of any community in the SNMP URL,
+	// falling back to the next credentials if probing times out
+	Credentials []string
+
+	// optional name of Config.Quirks to use instead of matching the probed sysObjectID or sysDescr
+	Quirks string
 }
 
 func newHost(id HostID) *Host {
@@ -31,7 +45,7 @@ func loadHost(engine Engine, id HostID, config HostConfig) (*Host, error) {
 
 	if err := host.init(engine, config); err != nil {
 		return host, err
-	} else if err := host.probe(engine.MIBs()); err != nil {
+	} else if err := host.probe(engine); err != nil {
 		return host, err
 	} else {
 		return host, nil
@@ -44,6 +58,11 @@ type Host struct {
 	config HostConfig
 	client engineClient
 
+	clientConfig client.Config
+	credentials  []Credential // in fallback order
+	credential   string       // name of the credentials used by the client
+	quirk        string       // name of the quirks applied to the client options
+
 	mibs   MIBs
 	err    error
 	online bool
@@ -62,6 +81,9 @@ func (host *Host) init(engine Engine, config HostConfig) error {
 	if config.ClientOptions != nil {
 		clientOptions = *config.ClientOptions
 	}
+	if config.Limits != nil {
+		clientOptions.Limits = *config.Limits
+	}
 
 	if config.SNMP == "" {
 		config.SNMP = string(host.id)
@@ -71,9 +93,39 @@ func (host *Host) init(engine Engine, config HostConfig) error {
 
 	host.log.Infof("Config: %#v", host.config)
 
-	if clientConfig, err := client.ParseConfig(clientOptions, config.SNMP); err != nil {
+	clientConfig, err := client.ParseConfig(clientOptions, config.SNMP)
+	if err != nil {
 		return err
-	} else if client, err := engine.client(clientConfig); err != nil {
+	}
+
+	if config.Transport != "" {
+		clientConfig.Transport = config.Transport
+	}
+
+	if config.Quirks == "" {
+
+	} else if quirk, err := engine.Quirks().Lookup(config.Quirks); err != nil {
+		return fmt.Errorf("Host %v: %v", host, err)
+	} else {
+		clientConfig.Options = quirk.apply(clientConfig.Options)
+		host.quirk = quirk.Name
+	}
+
+	host.clientConfig = clientConfig
+
+	if len(config.Credentials) == 0 {
+		return host.connect(engine, clientConfig)
+	} else if credentials, err := engine.Credentials().List(config.Credentials...); err != nil {
+		return fmt.Errorf("Host %v: %v", host, err)
+	} else {
+		host.credentials = credentials
+
+		return host.connectCredential(engine, credentials[0])
+	}
+}
+
+func (host *Host) connect(engine Engine, clientConfig client.Config) error {
+	if client, err := engine.client(clientConfig); err != nil {
 		return fmt.Errorf("NewClient %v: %v", host, err)
 	} else {
 		host.log.Infof("Connected client: %v", client)
@@ -84,14 +136,99 @@ func (host *Host) init(engine Engine, config HostConfig) error {
 	return nil
 }
 
-func (host *Host) probe(probeMIBs MIBs) error {
+func (host *Host) connectCredential(engine Engine, credential Credential) error {
+	var clientConfig = host.clientConfig
+
+	clientConfig.Community = credential.Community
+	clientConfig.SecretCommunity = true
+
+	if err := host.connect(engine, clientConfig); err != nil {
+		return err
+	}
+
+	host.credential = credential.Name
+
+	return nil
+}
+
+// Probe using any matching quirks, and each of the fallback credentials in turn, until one does not time out.
+func (host *Host) probe(engine Engine) error {
+	if err := host.probeQuirks(engine); err != nil {
+		return fmt.Errorf("Probe %v quirks: %v", host, err)
+	}
+
+	var err = host.probeMIBs(engine.MIBs())
+	var timeoutErr client.TimeoutError
+
+	for _, credential := range host.credentials {
+		if !errors.As(err, &timeoutErr) {
+			break
+		} else if credential.Name == host.credential {
+			continue
+		}
+
+		host.log.Infof("Probe timeout using credentials %v, trying %v", host.credential, credential)
+
+		if err := host.connectCredential(engine, credential); err != nil {
+			return err
+		} else if err := host.probeQuirks(engine); err != nil {
+			return fmt.Errorf("Probe %v quirks: %v", host, err)
+		}
+
+		err = host.probeMIBs(engine.MIBs())
+	}
+
+	if err != nil {
+		return fmt.Errorf("Probe %v: %v", host, err)
+	}
+
+	return nil
+}
+
+// Match the configured quirks against the host sysObjectID and sysDescr, reconnecting using the quirk options.
+//
+// Failing to get the sysObjectID is logged, and the host is probed without any quirks.
+func (host *Host) probeQuirks(engine Engine) error {
+	var quirks = engine.Quirks()
+
+	if host.quirk != "" || len(quirks) == 0 {
+		return nil
+	}
+
+	varBinds, err := host.client.Get(sysObjectIDOID, sysDescrOID)
+	if err != nil {
+		host.log.Warnf("Probe quirks: %v", err)
+
+		return nil
+	}
+
+	quirk, ok := quirks.Match(varBinds)
+	if !ok {
+		return nil
+	}
+
+	host.log.Infof("Using quirks %v", quirk)
+
+	host.clientConfig.Options = quirk.apply(host.clientConfig.Options)
+	host.quirk = quirk.Name
+
+	for _, credential := range host.credentials {
+		if credential.Name == host.credential {
+			return host.connectCredential(engine, credential)
+		}
+	}
+
+	return host.connect(engine, host.clientConfig)
+}
+
+func (host *Host) probeMIBs(probeMIBs MIBs) error {
 	var ids = probeMIBs.ListIDs()
 	var mibs = make(MIBs)
 
 	host.log.Infof("Probing MIBs: %v", probeMIBs)
 
 	if probed, err := host.client.Probe(ids); err != nil {
-		return fmt.Errorf("Probe %v: %v", host, err)
+		return err
 	} else {
 		for i, ok := range probed {
 			if ok {
@@ -143,7 +280,7 @@ func (route *hostRoute) Index(name string) (web.Resource, error) {
 		// pre-configured host
 	} else if err := route.host.init(route.engine, *route.loadConfig); err != nil {
 		return nil, err
-	} else if err := route.host.probe(route.engine.MIBs()); err != nil {
+	} else if err := route.host.probe(route.engine); err != nil {
 		return nil, err
 	}
 
@@ -236,9 +373,16 @@ func (view hostView) makeTables() []api.TableIndex {
 	return tables
 }
 
+// Does not include the community for any credentials
 func (view hostView) makeAPISNMP() string {
 	if view.host.client == nil {
 		return ""
+	} else if view.host.credential != "" {
+		var clientConfig = view.host.clientConfig
+
+		clientConfig.Community = ""
+
+		return clientConfig.String()
 	}
 
 	return view.host.client.String()
@@ -254,11 +398,13 @@ func (view hostView) makeAPIError() *api.Error {
 
 func (view hostView) makeAPIIndex() api.HostIndex {
 	return api.HostIndex{
-		ID:       string(view.host.id),
-		SNMP:     view.makeAPISNMP(),
-		Location: view.host.config.Location,
-		Online:   view.host.online,
-		Error:    view.makeAPIError(),
+		ID:          string(view.host.id),
+		SNMP:        view.makeAPISNMP(),
+		Credentials: view.host.credential,
+		Quirks:      view.host.quirk,
+		Location:    view.host.config.Location,
+		Online:      view.host.online,
+		Error:       view.makeAPIError(),
 	}
 }
 
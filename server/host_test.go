@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/qmsk/snmpbot/client"
 	"github.com/qmsk/snmpbot/mibs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestLoadHost(t *testing.T) {
@@ -128,3 +130,70 @@ func TestLoadHostClientProbeFalse(t *testing.T) {
 	assert.True(t, host.IsUp(), "Host.IsUp")
 	assert.Empty(t, host.MIBs(), "Host.MIBs()")
 }
+
+func TestLoadHostConfigLimits(t *testing.T) {
+	var engine = makeTestEngine(testConfig{})
+	var limits = client.Limits{MaxPending: 2, Rate: 10}
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP:   "localhost",
+		Limits: &limits,
+	})
+
+	assert.NoError(t, err, "loadHost")
+	assert.Equal(t, limits, host.client.(*testEngineClient).config.Limits, "Host.client.config.Limits")
+}
+
+func TestLoadHostConfigTransport(t *testing.T) {
+	var engine = makeTestEngine(testConfig{})
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP:      "localhost",
+		Transport: "vrf1",
+	})
+
+	assert.NoError(t, err, "loadHost")
+	assert.Equal(t, "vrf1", host.client.(*testEngineClient).config.Transport, "Host.client.config.Transport")
+	assert.Equal(t, "vrf1+snmp://public@localhost", host.client.String(), "Host.client.String()")
+}
+
+func TestLoadHostCredentialsFallback(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		clientMock: true,
+		credentials: Credentials{
+			"legacy1": Credential{Name: "legacy1", Community: "secret1"},
+			"legacy2": Credential{Name: "legacy2", Community: "secret2"},
+		},
+	})
+
+	engine.On("client", mock.AnythingOfType("client.Config")).Return(nil)
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{false}, client.TimeoutError{}).Once()
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil).Once()
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP:        "localhost",
+		Credentials: []string{"legacy1", "legacy2"},
+	})
+
+	assert.NoError(t, err, "loadHost")
+	assert.True(t, host.IsUp(), "Host.IsUp")
+	assert.Equal(t, "legacy2", host.credential, "Host.credential")
+	assert.Equal(t, "secret2", host.client.(*testEngineClient).config.Community, "Host.client.config.Community")
+	assert.Equal(t, "localhost", host.client.String(), "Host.client.String()")
+
+	var apiHost = hostView{host: host}.makeAPIIndex()
+
+	assert.Equal(t, "localhost", apiHost.SNMP, "api.HostIndex.SNMP")
+	assert.Equal(t, "legacy2", apiHost.Credentials, "api.HostIndex.Credentials")
+}
+
+func TestLoadHostCredentialsError(t *testing.T) {
+	var engine = makeTestEngine(testConfig{})
+
+	var _, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP:        "localhost",
+		Credentials: []string{"unknown"},
+	})
+
+	assert.EqualError(t, err, "Host test: Unknown credentials: unknown")
+}
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"encoding/json"
 	"github.com/qmsk/go-web"
 	"github.com/qmsk/snmpbot/api"
 	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
 )
 
 type MIBs map[string]*mibs.MIB
@@ -38,6 +40,19 @@ func (mibs MIBs) Keys() []string {
 	return keys
 }
 
+// List MIBs in OID order
+func (mibMap MIBs) List() []*mibs.MIB {
+	var list = make([]*mibs.MIB, 0, len(mibMap))
+
+	for _, mib := range mibMap {
+		list = append(list, mib)
+	}
+
+	mibs.SortMIBs(list)
+
+	return list
+}
+
 func (mibMap MIBs) ListIDs() []mibs.ID {
 	var list = make([]mibs.ID, 0, len(mibMap))
 
@@ -82,17 +97,91 @@ func (mibMap MIBs) Tables() Tables {
 }
 
 type mibsRoute struct {
-	mibs MIBs
+	engine Engine
+	mibs   MIBs
 }
 
 func (route mibsRoute) Index(name string) (web.Resource, error) {
 	if name == "" {
-		return mibsView{route.mibs}, nil
+		return &mibsView{engine: route.engine, mibs: route.mibs}, nil
+	} else if name == "tree" {
+		return &mibTreeHandler{}, nil
+	} else if name == "search" {
+		return &mibSearchHandler{}, nil
 	} else if mib, ok := route.mibs[name]; !ok {
 		return nil, web.Errorf(404, "MIB not found: %v", name)
 	} else {
-		return mibView{mib}, nil
+		return &mibRoute{mibView: mibView{mib}, engine: route.engine}, nil
+	}
+}
+
+// JSON-encoded MIBConfig request body, as generated by scripts/mib-import.py
+//
+// Any form-encoded request body is rejected, SMI MIBs must be converted using scripts/mib-import.py.
+type mibConfigBody struct {
+	config mibs.MIBConfig
+	json   bool
+}
+
+func (body *mibConfigBody) UnmarshalJSON(buf []byte) error {
+	body.json = true
+
+	return json.Unmarshal(buf, &body.config)
+}
+
+func (body mibConfigBody) MIBConfig() (mibs.MIBConfig, error) {
+	if !body.json {
+		return body.config, web.Errorf(415, "Unsupported MIB Content-Type: only application/json MIBConfig is supported, see scripts/mib-import.py")
+	}
+
+	return body.config, nil
+}
+
+func mibLoadError(err error) error {
+	if _, ok := err.(mibs.ReferenceError); ok {
+		return web.Errorf(409, "%v", err)
+	} else {
+		return web.RequestError(err)
+	}
+}
+
+type mibRoute struct {
+	mibView
+	engine Engine
+
+	put mibConfigBody
+}
+
+func (route *mibRoute) IntoREST() interface{} {
+	return &route.put
+}
+
+// Replace the MIB from the JSON-encoded MIBConfig, as generated by scripts/mib-import.py
+func (route *mibRoute) PutREST() (web.Resource, error) {
+	config, err := route.put.MIBConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Name == "" {
+		config.Name = route.mib.Name
+	} else if config.Name != route.mib.Name {
+		return nil, web.RequestErrorf("Mismatching MIB name: %v", config.Name)
+	}
+
+	if mib, err := route.engine.ReplaceMIB(config); err != nil {
+		return nil, mibLoadError(err)
+	} else {
+		return mibView{mib}.makeAPI(), nil
+	}
+}
+
+func (route *mibRoute) DeleteREST() (web.Resource, error) {
+	if err := route.engine.UnloadMIB(route.mib); err != nil {
+		return nil, mibLoadError(err)
 	}
+
+	return nil, nil
 }
 
 type mibView struct {
@@ -120,13 +209,16 @@ func (view mibView) GetREST() (web.Resource, error) {
 }
 
 type mibsView struct {
-	mibs MIBs
+	engine Engine
+	mibs   MIBs
+
+	post mibConfigBody
 }
 
 func (view mibsView) makeAPIIndex() []api.MIBIndex {
 	var index []api.MIBIndex
 
-	for _, mib := range view.mibs {
+	for _, mib := range view.mibs.List() {
 		index = append(index, mibView{mib}.makeAPIIndex())
 	}
 
@@ -136,7 +228,7 @@ func (view mibsView) makeAPIIndex() []api.MIBIndex {
 func (view mibsView) makeAPI() []api.MIB {
 	var rets []api.MIB
 
-	for _, mib := range view.mibs {
+	for _, mib := range view.mibs.List() {
 		rets = append(rets, mibView{mib}.makeAPI())
 	}
 
@@ -146,3 +238,104 @@ func (view mibsView) makeAPI() []api.MIB {
 func (view mibsView) GetREST() (web.Resource, error) {
 	return view.makeAPI(), nil
 }
+
+func (view *mibsView) IntoREST() interface{} {
+	return &view.post
+}
+
+// Load a new MIB from the JSON-encoded MIBConfig, as generated by scripts/mib-import.py
+//
+// Any other MIBs referred to by the new MIB must already be loaded.
+func (view *mibsView) PostREST() (web.Resource, error) {
+	if config, err := view.post.MIBConfig(); err != nil {
+		return nil, err
+	} else if _, exists := view.mibs[config.Name]; exists {
+		return nil, web.Errorf(409, "MIB already loaded: %v", config.Name)
+	} else if mib, err := view.engine.LoadMIB(config); err != nil {
+		return nil, mibLoadError(err)
+	} else {
+		return mibView{mib}.makeAPI(), nil
+	}
+}
+
+type mibTreeHandler struct {
+	params api.MIBTreeQuery
+}
+
+func (handler *mibTreeHandler) QueryREST() interface{} {
+	return &handler.params
+}
+
+func (handler *mibTreeHandler) GetREST() (web.Resource, error) {
+	var root snmp.OID
+
+	if handler.params.Root == "" {
+
+	} else if oid, err := mibs.ParseOID(handler.params.Root); err != nil {
+		return nil, web.RequestErrorf("Invalid root %v: %v", handler.params.Root, err)
+	} else {
+		root = oid
+	}
+
+	return makeAPIMIBTree(mibs.Tree(root)), nil
+}
+
+func makeAPIIDType(id mibs.ID) string {
+	if id.IsMIB() {
+		return "MIB"
+	} else if id.Object() != nil {
+		return "Object"
+	} else if id.Table() != nil {
+		return "Table"
+	} else if id.Notification() != nil {
+		return "Notification"
+	} else {
+		return ""
+	}
+}
+
+func makeAPIMIBTree(node *mibs.TreeNode) api.MIBTree {
+	var tree = api.MIBTree{
+		ID:   node.ID.String(),
+		OID:  node.ID.OID.String(),
+		Type: makeAPIIDType(node.ID),
+	}
+
+	for _, child := range node.Children {
+		tree.Children = append(tree.Children, makeAPIMIBTree(child))
+	}
+
+	return tree
+}
+
+type mibSearchHandler struct {
+	params api.MIBSearchQuery
+}
+
+func (handler *mibSearchHandler) QueryREST() interface{} {
+	return &handler.params
+}
+
+func (handler *mibSearchHandler) GetREST() (web.Resource, error) {
+	if handler.params.Query == "" {
+		return nil, web.RequestErrorf("Missing ?q= query")
+	}
+
+	var results = []api.MIBSearchResult{}
+
+	for _, result := range mibs.Search(handler.params.Query) {
+		if handler.params.Limit > 0 && uint(len(results)) >= handler.params.Limit {
+			break
+		}
+
+		results = append(results, api.MIBSearchResult{
+			ID:          result.ID.String(),
+			OID:         result.ID.OID.String(),
+			Type:        makeAPIIDType(result.ID),
+			Match:       result.Match.String(),
+			Description: result.ID.Description(),
+		})
+	}
+
+	return results, nil
+}
@@ -2,10 +2,13 @@ package server
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/qmsk/go-web/webtest"
 	"github.com/qmsk/snmpbot/api"
+	"github.com/qmsk/snmpbot/mibs"
 )
 
 func TestGetMibsIndex(t *testing.T) {
@@ -106,3 +109,257 @@ func TestGetMibNotFound(t *testing.T) {
 		},
 	})
 }
+
+var testMIB3Config = mibs.MIBConfig{
+	Name: "TEST3-MIB",
+	OID:  ".1.0.3",
+	Objects: []mibs.ObjectConfig{
+		{ConfigID: mibs.ConfigID{Name: "test3Name", OID: ".1.0.3.1.1.1"}, Syntax: "SNMPv2-TC::DisplayString"},
+	},
+	Tables: []mibs.TableConfig{
+		{
+			ConfigID:     mibs.ConfigID{Name: "test3Table", OID: ".1.0.3.1"},
+			IndexObjects: []string{"TEST-MIB::testID"},
+			EntryObjects: []string{"TEST3-MIB::test3Name"},
+			EntryName:    "test3Entry",
+		},
+	},
+}
+
+func TestPostPutDeleteMIB(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	var apiMIB api.MIB
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "POST",
+			Target: "/mibs/",
+			Object: testMIB3Config,
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 200,
+			Object:     &apiMIB,
+		},
+	})
+
+	assert.Equal(t, api.MIBIndex{ID: "TEST3-MIB"}, apiMIB.MIBIndex, "response MIB")
+	assert.Equal(t, []api.TableIndex{
+		{ID: "TEST3-MIB::test3Table", IndexKeys: []string{"TEST-MIB::testID"}, ObjectKeys: []string{"TEST3-MIB::test3Name"}},
+	}, apiMIB.Tables, "response MIB tables")
+	assert.ElementsMatch(t, []string{"TEST-MIB", "TEST3-MIB"}, engine.MIBs().Keys(), "engine.MIBs")
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "POST",
+			Target: "/mibs/",
+			Object: testMIB3Config,
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 409,
+			Text:       "MIB already loaded: TEST3-MIB\n",
+		},
+	})
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "DELETE",
+			Target: "/mibs/TEST-MIB",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 409,
+			Text:       "MIB TEST-MIB is still referenced by: TEST3-MIB::test3Table (TEST-MIB::testID)\n",
+		},
+	})
+
+	var putConfig = testMIB3Config
+
+	putConfig.Objects = append(putConfig.Objects, mibs.ObjectConfig{ConfigID: mibs.ConfigID{Name: "test3", OID: ".1.0.3.2"}, Syntax: "Integer32"})
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "PUT",
+			Target: "/mibs/TEST3-MIB",
+			Object: putConfig,
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 200,
+			Object:     &apiMIB,
+		},
+	})
+
+	assert.ElementsMatch(t, []api.ObjectIndex{
+		{ID: "TEST3-MIB::test3Name", IndexKeys: []string{"TEST-MIB::testID"}},
+		{ID: "TEST3-MIB::test3"},
+	}, apiMIB.Objects, "response MIB objects")
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "DELETE",
+			Target: "/mibs/TEST3-MIB",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 204,
+		},
+	})
+
+	assert.ElementsMatch(t, []string{"TEST-MIB"}, engine.MIBs().Keys(), "engine.MIBs")
+
+	if _, err := mibs.ResolveMIB("TEST3-MIB"); err == nil {
+		t.Errorf("ResolveMIB TEST3-MIB: still loaded")
+	}
+}
+
+func TestPostMIBContentType(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	for _, test := range []struct {
+		method      string
+		target      string
+		contentType string
+		body        string
+		statusCode  int
+	}{
+		{"POST", "/mibs/", "text/plain", "TEST3-MIB DEFINITIONS ::= BEGIN\nEND\n", 415},
+		{"POST", "/mibs/", "application/x-www-form-urlencoded", "", 415},
+		{"POST", "/mibs/", "application/x-www-form-urlencoded", "Name=TEST3-MIB", 422},
+		{"PUT", "/mibs/TEST-MIB", "application/x-www-form-urlencoded", "", 415},
+	} {
+		var request = httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		var responseWriter = httptest.NewRecorder()
+
+		request.Header.Set("Content-Type", test.contentType)
+
+		WebAPI(engine).ServeHTTP(responseWriter, request)
+
+		assert.Equalf(t, test.statusCode, responseWriter.Code, "%v %v with Content-Type %v: %v", test.method, test.target, test.contentType, responseWriter.Body.String())
+	}
+
+	assert.ElementsMatch(t, []string{"TEST-MIB"}, engine.MIBs().Keys(), "engine.MIBs")
+}
+
+func TestGetMibTree(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	var apiTree api.MIBTree
+	var testTree = api.MIBTree{
+		ID:   "TEST-MIB::testTable",
+		OID:  ".1.0.1.1.2",
+		Type: "Table",
+		Children: []api.MIBTree{
+			{ID: "TEST-MIB::testID", OID: ".1.0.1.1.2.1", Type: "Object"},
+			{ID: "TEST-MIB::testName", OID: ".1.0.1.1.2.2", Type: "Object"},
+		},
+	}
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/mibs/tree?root=TEST-MIB::testTable",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 200,
+			Object:     &apiTree,
+		},
+	})
+
+	assert.Equal(t, testTree, apiTree, "response tree")
+}
+
+func TestGetMibTreeMIB(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	var apiTree api.MIBTree
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/mibs/tree?root=.1.0.1",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 200,
+			Object:     &apiTree,
+		},
+	})
+
+	var children []string
+
+	for _, child := range apiTree.Children {
+		children = append(children, child.ID)
+	}
+
+	assert.Equal(t, "TEST-MIB", apiTree.ID)
+	assert.Equal(t, "MIB", apiTree.Type)
+	assert.Equal(t, []string{"TEST-MIB::test", "TEST-MIB::testTable", "TEST-MIB::testEnum"}, children)
+}
+
+func TestGetMibTreeError(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/mibs/tree?root=ASDF-MIB",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 422,
+			Text:       "Invalid root ASDF-MIB: MIB not found: ASDF-MIB\n",
+		},
+	})
+}
+
+func TestGetMibSearch(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/mibs/search?q=testenum&limit=1",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 200,
+			Object: &[]api.MIBSearchResult{
+				{ID: "TEST-MIB::testEnum", OID: ".1.0.1.1.3", Type: "Object", Match: "exact"},
+			},
+		},
+	})
+}
+
+func TestGetMibSearchError(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/mibs/search",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 422,
+			Text:       "Missing ?q= query\n",
+		},
+	})
+}
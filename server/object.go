@@ -77,6 +77,7 @@ func (objects Objects) String() string {
 	return "{" + strings.Join(ss, ", ") + "}"
 }
 
+// List objects in OID order
 func (objects Objects) List() []*mibs.Object {
 	var list = make([]*mibs.Object, 0, len(objects))
 
@@ -84,6 +85,8 @@ func (objects Objects) List() []*mibs.Object {
 		list = append(list, object)
 	}
 
+	mibs.SortObjects(list)
+
 	return list
 }
 
@@ -241,7 +244,7 @@ type objectsView struct {
 func (view objectsView) makeAPIIndex() []api.ObjectIndex {
 	var objects []api.ObjectIndex
 
-	for _, object := range view.objects {
+	for _, object := range view.objects.List() {
 		objects = append(objects, objectView{object}.makeAPIIndex())
 	}
 
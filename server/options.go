@@ -7,11 +7,13 @@ import (
 )
 
 type Options struct {
-	ConfigFile string
+	ConfigFile  string
+	ProxyListen string
 }
 
 func (options *Options) InitFlags() {
 	flag.StringVar(&options.ConfigFile, "config", "", "Load TOML config")
+	flag.StringVar(&options.ProxyListen, "proxy-listen", "", "SNMP proxy listen, overriding the config Proxy.Listen: [HOST]:PORT")
 }
 
 func (options Options) LoadConfig(clientOptions client.Options) (Config, error) {
@@ -40,3 +42,20 @@ func (options Options) Engine(clientEngine *client.Engine, config Config) (Engin
 
 	return engine, nil
 }
+
+// Returns nil if the proxy is not enabled.
+func (options Options) Proxy(engine Engine, config Config) (*Proxy, error) {
+	var proxyConfig = config.Proxy
+
+	if options.ProxyListen != "" {
+		proxyConfig.Listen = options.ProxyListen
+	}
+
+	if proxyConfig.Listen == "" {
+		return nil, nil
+	} else if proxy, err := NewProxy(engine, proxyConfig); err != nil {
+		return nil, err
+	} else {
+		return proxy, nil
+	}
+}
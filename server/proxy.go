@@ -0,0 +1,254 @@
+package server
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"github.com/qmsk/go-logging"
+	"github.com/qmsk/snmpbot/client"
+	"github.com/qmsk/snmpbot/snmp"
+	"strings"
+)
+
+const DefaultProxyConcurrency = 100
+
+// SNMP proxy, forwarding requests to a Host based on the request community.
+type ProxyConfig struct {
+	Listen string // [HOST]:PORT, disabled if empty
+
+	// optional community for addressing any host using the COMMUNITY@HOST context convention
+	Community string
+
+	// map request community => host
+	Hosts map[string]HostID
+
+	// maximum number of requests forwarded concurrently, default DefaultProxyConcurrency
+	Concurrency uint
+}
+
+func NewProxy(engine Engine, config ProxyConfig) (*Proxy, error) {
+	if udp, err := client.ListenUDP(config.Listen, client.UDPOptions{}); err != nil {
+		return nil, fmt.Errorf("Listen %v: %v", config.Listen, err)
+	} else {
+		return newProxy(engine, config, udp), nil
+	}
+}
+
+func newProxy(engine Engine, config ProxyConfig, transport client.Transport) *Proxy {
+	var proxy = Proxy{
+		engine:    engine,
+		config:    config,
+		transport: transport,
+	}
+
+	if config.Concurrency == 0 {
+		proxy.workers = make(chan struct{}, DefaultProxyConcurrency)
+	} else {
+		proxy.workers = make(chan struct{}, config.Concurrency)
+	}
+
+	proxy.log = logging.WithPrefix(log, fmt.Sprintf("Proxy<%v>", transport))
+
+	return &proxy
+}
+
+// Forwards Get, GetNext, GetBulk and Set requests via the host client, using the client.Engine retries and limits.
+//
+// SNMPv1 requests are translated to SNMPv2c and back, see forwardV1.
+//
+// Requests for unknown communities are dropped, as are any requests timing out.
+// Any requests received while the Concurrency limit of requests are being forwarded are also dropped.
+type Proxy struct {
+	engine    Engine
+	config    ProxyConfig
+	transport client.Transport
+	workers   chan struct{}
+	log       logging.PrefixLogging
+}
+
+// Lookup host by request community
+func (proxy *Proxy) lookup(community string) (*Host, error) {
+	var hostID HostID
+
+	if id, ok := proxy.config.Hosts[community]; ok {
+		hostID = id
+	} else if i := strings.LastIndex(community, "@"); i >= 0 && proxy.config.Community != "" && community[:i] == proxy.config.Community {
+		hostID = HostID(community[i+1:])
+	} else {
+		return nil, fmt.Errorf("Unknown community")
+	}
+
+	if host, ok := proxy.engine.Hosts()[hostID]; !ok {
+		return nil, fmt.Errorf("Unknown host: %v", hostID)
+	} else if host.client == nil {
+		return nil, fmt.Errorf("Host %v is not connected", host)
+	} else {
+		return host, nil
+	}
+}
+
+func (proxy *Proxy) forward(recv client.IO) (client.IO, error) {
+	var send = client.IO{
+		Addr: recv.Addr,
+		Packet: snmp.Packet{
+			Version:   recv.Packet.Version,
+			Community: recv.Packet.Community,
+		},
+		PDUMeta: snmp.PDUMeta{
+			RequestID: recv.RequestID,
+		},
+	}
+
+	switch recv.PDUType {
+	case snmp.GetRequestType, snmp.GetNextRequestType, snmp.SetRequestType:
+	case snmp.GetBulkRequestType:
+		if recv.Packet.Version == snmp.SNMPv1 {
+			return send, fmt.Errorf("Unsupported SNMPv1 request type: %v", recv.PDUType)
+		}
+	default:
+		return send, fmt.Errorf("Unsupported request type: %v", recv.PDUType)
+	}
+
+	if host, err := proxy.lookup(string(recv.Packet.Community)); err != nil {
+		return send, err
+	} else if recv.Packet.Version == snmp.SNMPv1 {
+		if pduType, pdu, err := proxy.forwardV1(host, recv.PDUType, recv.PDU); err != nil {
+			return send, fmt.Errorf("Forward %v: %v", host, err)
+		} else {
+			send.PDUType = pduType
+			send.PDU = pdu
+		}
+	} else if pduType, pdu, err := host.client.Forward(recv.PDUType, recv.PDU); err != nil {
+		return send, fmt.Errorf("Forward %v: %v", host, err)
+	} else {
+		send.PDUType = pduType
+		send.PDU = pdu
+	}
+
+	return send, nil
+}
+
+// Map SNMPv2 error-status values to SNMPv1, as per RFC 3584 section 4.4.
+var proxyV1ErrorStatus = map[snmp.ErrorStatus]snmp.ErrorStatus{
+	6:  snmp.NoSuchNameError, // noAccess
+	7:  snmp.BadValueError,   // wrongType
+	8:  snmp.BadValueError,   // wrongLength
+	9:  snmp.BadValueError,   // wrongEncoding
+	10: snmp.BadValueError,   // wrongValue
+	11: snmp.NoSuchNameError, // noCreation
+	12: snmp.BadValueError,   // inconsistentValue
+	13: snmp.GenericError,    // resourceUnavailable
+	14: snmp.GenericError,    // commitFailed
+	15: snmp.GenericError,    // undoFailed
+	16: snmp.NoSuchNameError, // authorizationError
+	17: snmp.NoSuchNameError, // notWritable
+	18: snmp.NoSuchNameError, // inconsistentName
+}
+
+// Forward an SNMPv1 request via the SNMPv2c host client, translating the response as per RFC 3584 section 4.2.
+//
+// Any noSuchObject, noSuchInstance or endOfMibView exception values are returned as a noSuchName error.
+// Any Counter64 values are skipped for GetNext requests by re-sending the request, and returned as a noSuchName error otherwise.
+func (proxy *Proxy) forwardV1(host *Host, requestType snmp.PDUType, pdu snmp.PDU) (snmp.PDUType, snmp.PDU, error) {
+	request, ok := pdu.(snmp.GenericPDU)
+	if !ok {
+		return 0, nil, fmt.Errorf("Invalid %v PDU: %T", requestType, pdu)
+	}
+
+	var varBinds = make([]snmp.VarBind, len(request.VarBinds))
+	var noSuchName = func(index int) snmp.GenericPDU {
+		return snmp.GenericPDU{
+			RequestID:   request.RequestID,
+			ErrorStatus: snmp.NoSuchNameError,
+			ErrorIndex:  index + 1,
+			VarBinds:    request.VarBinds,
+		}
+	}
+
+	copy(varBinds, request.VarBinds)
+
+	for {
+		var resend = false
+
+		responseType, pdu, err := host.client.Forward(requestType, snmp.GenericPDU{RequestID: request.RequestID, VarBinds: varBinds})
+		if err != nil {
+			return 0, nil, err
+		}
+
+		response, ok := pdu.(snmp.GenericPDU)
+		if !ok {
+			return 0, nil, fmt.Errorf("Invalid %v PDU: %T", responseType, pdu)
+		} else if response.ErrorStatus != snmp.Success {
+			if errorStatus, ok := proxyV1ErrorStatus[response.ErrorStatus]; ok {
+				response.ErrorStatus = errorStatus
+			}
+
+			return responseType, response, nil
+		} else if len(response.VarBinds) != len(varBinds) {
+			return 0, nil, fmt.Errorf("Invalid %v PDU with %d of %d VarBinds", responseType, len(response.VarBinds), len(varBinds))
+		}
+
+		for i, varBind := range response.VarBinds {
+			if varBind.ErrorValue() != nil {
+				return responseType, noSuchName(i), nil
+			} else if varBind.RawValue.Class != asn1.ClassApplication || snmp.ApplicationValueType(varBind.RawValue.Tag) != snmp.Counter64Type {
+				continue
+			} else if requestType == snmp.GetNextRequestType && varBind.OID().Compare(varBinds[i].OID()) > 0 {
+				varBinds[i] = snmp.MakeVarBind(varBind.OID(), nil)
+				resend = true
+			} else {
+				return responseType, noSuchName(i), nil
+			}
+		}
+
+		if !resend {
+			return responseType, response, nil
+		}
+	}
+}
+
+func (proxy *Proxy) handle(recv client.IO) {
+	var timeoutErr client.TimeoutError
+
+	if send, err := proxy.forward(recv); errors.As(err, &timeoutErr) {
+		proxy.log.Infof("Drop %v from %v: %v", recv.PDUType, recv.Addr, err)
+	} else if err != nil {
+		proxy.log.Warnf("Drop %v from %v: %v", recv.PDUType, recv.Addr, err)
+	} else if err := proxy.transport.Send(send); err != nil {
+		proxy.log.Warnf("Send %v to %v: %v", send.PDUType, send.Addr, err)
+	} else {
+		proxy.log.Debugf("Forward %v<%v> from %v => %v<%v>", recv.PDUType, recv.PDU, recv.Addr, send.PDUType, send.PDU)
+	}
+}
+
+// Receive and forward requests until the transport is closed.
+func (proxy *Proxy) Run() error {
+	proxy.log.Infof("Listening...")
+
+	for {
+		var protocolErr client.ProtocolError
+
+		if recv, err := proxy.transport.Recv(); err == client.EOF {
+			return nil
+		} else if errors.As(err, &protocolErr) {
+			proxy.log.Warnf("Recv: %v", err)
+		} else if err != nil {
+			return err
+		} else {
+			select {
+			case proxy.workers <- struct{}{}:
+				go func() {
+					defer func() { <-proxy.workers }()
+
+					proxy.handle(recv)
+				}()
+			default:
+				proxy.log.Warnf("Drop %v from %v: too many concurrent requests", recv.PDUType, recv.Addr)
+			}
+		}
+	}
+}
+
+func (proxy *Proxy) Close() error {
+	return proxy.transport.Close()
+}
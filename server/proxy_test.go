@@ -0,0 +1,334 @@
+package server
+
+import (
+	"encoding/asn1"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/qmsk/snmpbot/client"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testProxyAddr string
+
+func (addr testProxyAddr) Network() string {
+	return "test"
+}
+
+func (addr testProxyAddr) String() string {
+	return string(addr)
+}
+
+type testProxyTransport struct {
+	recvChan chan client.IO
+	sendChan chan client.IO
+}
+
+func makeTestProxyTransport() testProxyTransport {
+	return testProxyTransport{
+		recvChan: make(chan client.IO),
+		sendChan: make(chan client.IO, 1),
+	}
+}
+
+func (transport testProxyTransport) String() string {
+	return "test"
+}
+
+func (transport testProxyTransport) Resolve(addr string) (net.Addr, error) {
+	return testProxyAddr(addr), nil
+}
+
+func (transport testProxyTransport) Send(io client.IO) error {
+	transport.sendChan <- io
+
+	return nil
+}
+
+func (transport testProxyTransport) Recv() (client.IO, error) {
+	if io, ok := <-transport.recvChan; !ok {
+		return io, client.EOF
+	} else {
+		return io, nil
+	}
+}
+
+func (transport testProxyTransport) Close() error {
+	close(transport.recvChan)
+
+	return nil
+}
+
+var testProxyOID = snmp.OID{1, 3, 6, 1, 2, 1, 1, 5, 0}
+
+func makeTestProxyRequest(pduType snmp.PDUType, community string) client.IO {
+	return client.IO{
+		Addr: testProxyAddr("nms"),
+		Packet: snmp.Packet{
+			Version:   snmp.SNMPv2c,
+			Community: []byte(community),
+		},
+		PDUMeta: snmp.PDUMeta{
+			PDUType:   pduType,
+			RequestID: 1234,
+		},
+		PDU: snmp.GenericPDU{
+			RequestID: 1234,
+			VarBinds:  []snmp.VarBind{snmp.MakeVarBind(testProxyOID, nil)},
+		},
+	}
+}
+
+func makeTestProxy(config ProxyConfig) (*Proxy, testProxyTransport) {
+	var engine = makeTestEngine(testConfig{
+		hosts: map[HostID]HostConfig{
+			"test": HostConfig{SNMP: "localhost"},
+		},
+	})
+	var transport = makeTestProxyTransport()
+
+	return newProxy(engine, config, transport), transport
+}
+
+func TestProxyForward(t *testing.T) {
+	var proxy, _ = makeTestProxy(ProxyConfig{
+		Hosts: map[string]HostID{"test-community": "test"},
+	})
+
+	var recv = makeTestProxyRequest(snmp.GetRequestType, "test-community")
+	var send, err = proxy.forward(recv)
+
+	assert.NoError(t, err)
+	assert.Equal(t, recv.Addr, send.Addr)
+	assert.Equal(t, recv.Packet.Community, send.Packet.Community)
+	assert.Equal(t, snmp.PDUMeta{PDUType: snmp.GetResponseType, RequestID: 1234}, send.PDUMeta)
+	assert.Equal(t, recv.PDU, send.PDU)
+}
+
+func TestProxyForwardContext(t *testing.T) {
+	var proxy, _ = makeTestProxy(ProxyConfig{
+		Community: "proxy",
+	})
+
+	var _, err = proxy.forward(makeTestProxyRequest(snmp.GetNextRequestType, "proxy@test"))
+
+	assert.NoError(t, err)
+
+	_, err = proxy.forward(makeTestProxyRequest(snmp.GetNextRequestType, "proxy@unknown"))
+
+	assert.EqualError(t, err, "Unknown host: unknown")
+
+	_, err = proxy.forward(makeTestProxyRequest(snmp.GetNextRequestType, "public@test"))
+
+	assert.EqualError(t, err, "Unknown community")
+}
+
+func TestProxyForwardUnsupported(t *testing.T) {
+	var proxy, _ = makeTestProxy(ProxyConfig{
+		Community: "proxy",
+	})
+
+	var _, err = proxy.forward(makeTestProxyRequest(snmp.GetResponseType, "proxy@test"))
+
+	assert.EqualError(t, err, "Unsupported request type: GetResponse")
+}
+
+func TestProxyForwardTimeout(t *testing.T) {
+	var engine = makeTestEngine(testConfig{clientMock: true})
+	var transport = makeTestProxyTransport()
+	var proxy = newProxy(engine, ProxyConfig{Community: "proxy"}, transport)
+
+	engine.On("client", mock.AnythingOfType("client.Config")).Return(nil)
+	engine.clientMock.On("Probe", mock.Anything).Return([]bool{true}, nil)
+	engine.clientMock.On("Forward", snmp.GetRequestType, mock.Anything).Return(snmp.PDUType(0), nil, client.TimeoutError{})
+
+	if host, err := loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost"}); err != nil {
+		t.Fatalf("loadHost: %v", err)
+	} else {
+		engine.AddHost(host)
+	}
+
+	proxy.handle(makeTestProxyRequest(snmp.GetRequestType, "proxy@test"))
+
+	assert.Empty(t, transport.sendChan, "dropped")
+	engine.clientMock.AssertExpectations(t)
+}
+
+func TestProxyRun(t *testing.T) {
+	var proxy, transport = makeTestProxy(ProxyConfig{
+		Hosts: map[string]HostID{"test-community": "test"},
+	})
+	var runErr = make(chan error)
+
+	go func() {
+		runErr <- proxy.Run()
+	}()
+
+	transport.recvChan <- makeTestProxyRequest(snmp.GetBulkRequestType, "test-community")
+
+	var send = <-transport.sendChan
+
+	assert.Equal(t, snmp.GetResponseType, send.PDUType)
+	assert.Equal(t, 1234, send.RequestID)
+
+	assert.NoError(t, proxy.Close())
+	assert.NoError(t, <-runErr)
+}
+
+func makeTestProxyV1(t *testing.T) (*Proxy, *testEngine) {
+	var engine = makeTestEngine(testConfig{clientMock: true})
+	var proxy = newProxy(engine, ProxyConfig{Community: "proxy"}, makeTestProxyTransport())
+
+	engine.On("client", mock.AnythingOfType("client.Config")).Return(nil)
+	engine.clientMock.On("Probe", mock.Anything).Return([]bool{true}, nil)
+
+	if host, err := loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost"}); err != nil {
+		t.Fatalf("loadHost: %v", err)
+	} else {
+		engine.AddHost(host)
+	}
+
+	return proxy, engine
+}
+
+func makeTestProxyRequestV1(pduType snmp.PDUType, oid snmp.OID) client.IO {
+	var recv = makeTestProxyRequest(pduType, "proxy@test")
+
+	recv.Packet.Version = snmp.SNMPv1
+	recv.PDU = snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(oid, nil)},
+	}
+
+	return recv
+}
+
+func TestProxyForwardV1NoSuchName(t *testing.T) {
+	var proxy, engine = makeTestProxyV1(t)
+	var missingOID = snmp.OID{1, 3, 6, 1, 2, 1, 1, 99, 0}
+	var noSuchInstance = snmp.VarBind{Name: asn1.ObjectIdentifier(missingOID)}
+
+	noSuchInstance.SetError(snmp.NoSuchInstanceValue)
+
+	engine.clientMock.On("Forward", snmp.GetRequestType, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(missingOID, nil)},
+	}).Return(snmp.GetResponseType, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{noSuchInstance},
+	}, nil)
+
+	var recv = makeTestProxyRequestV1(snmp.GetRequestType, missingOID)
+	var send, err = proxy.forward(recv)
+
+	assert.NoError(t, err)
+	assert.Equal(t, snmp.SNMPv1, send.Packet.Version)
+	assert.Equal(t, snmp.GetResponseType, send.PDUType)
+	assert.Equal(t, snmp.GenericPDU{
+		RequestID:   1234,
+		ErrorStatus: snmp.NoSuchNameError,
+		ErrorIndex:  1,
+		VarBinds:    recv.PDU.(snmp.GenericPDU).VarBinds,
+	}, send.PDU)
+}
+
+func TestProxyForwardV1Counter64(t *testing.T) {
+	var proxy, engine = makeTestProxyV1(t)
+	var counterOID = snmp.OID{1, 3, 6, 1, 2, 1, 31, 1, 1, 1, 6, 1}
+	var nextOID = snmp.OID{1, 3, 6, 1, 2, 1, 31, 1, 1, 1, 18, 1}
+
+	engine.clientMock.On("Forward", snmp.GetNextRequestType, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(testProxyOID, nil)},
+	}).Return(snmp.GetResponseType, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(counterOID, snmp.Counter64(1000))},
+	}, nil)
+	engine.clientMock.On("Forward", snmp.GetNextRequestType, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(counterOID, nil)},
+	}).Return(snmp.GetResponseType, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(nextOID, []byte("test"))},
+	}, nil)
+	engine.clientMock.On("Forward", snmp.GetRequestType, mock.Anything).Return(snmp.GetResponseType, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(counterOID, snmp.Counter64(1000))},
+	}, nil)
+
+	var send, err = proxy.forward(makeTestProxyRequestV1(snmp.GetNextRequestType, testProxyOID))
+
+	assert.NoError(t, err)
+	assert.Equal(t, snmp.GenericPDU{
+		RequestID: 1234,
+		VarBinds:  []snmp.VarBind{snmp.MakeVarBind(nextOID, []byte("test"))},
+	}, send.PDU)
+
+	send, err = proxy.forward(makeTestProxyRequestV1(snmp.GetRequestType, counterOID))
+
+	assert.NoError(t, err)
+	assert.Equal(t, snmp.NoSuchNameError, send.PDU.GetError().ErrorStatus)
+}
+
+func TestProxyForwardV1ErrorStatus(t *testing.T) {
+	var proxy, engine = makeTestProxyV1(t)
+
+	engine.clientMock.On("Forward", snmp.SetRequestType, mock.Anything).Return(snmp.GetResponseType, snmp.GenericPDU{
+		RequestID:   1234,
+		ErrorStatus: snmp.ErrorStatus(17), // notWritable
+		ErrorIndex:  1,
+		VarBinds:    []snmp.VarBind{snmp.MakeVarBind(testProxyOID, nil)},
+	}, nil)
+
+	var send, err = proxy.forward(makeTestProxyRequestV1(snmp.SetRequestType, testProxyOID))
+
+	assert.NoError(t, err)
+	assert.Equal(t, snmp.NoSuchNameError, send.PDU.GetError().ErrorStatus)
+}
+
+func TestProxyForwardV1GetBulk(t *testing.T) {
+	var proxy, _ = makeTestProxyV1(t)
+
+	var _, err = proxy.forward(makeTestProxyRequestV1(snmp.GetBulkRequestType, testProxyOID))
+
+	assert.EqualError(t, err, "Unsupported SNMPv1 request type: GetBulkRequest")
+}
+
+func TestProxyRunConcurrency(t *testing.T) {
+	var engine = makeTestEngine(testConfig{clientMock: true})
+	var transport = makeTestProxyTransport()
+	var proxy = newProxy(engine, ProxyConfig{Community: "proxy", Concurrency: 1}, transport)
+	var forwardWait = make(chan time.Time)
+	var runErr = make(chan error)
+
+	engine.On("client", mock.AnythingOfType("client.Config")).Return(nil)
+	engine.clientMock.On("Probe", mock.Anything).Return([]bool{true}, nil)
+	engine.clientMock.On("Forward", snmp.GetRequestType, mock.Anything).Return(snmp.GetResponseType, snmp.GenericPDU{RequestID: 1234}, nil).WaitUntil(forwardWait)
+
+	if host, err := loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost"}); err != nil {
+		t.Fatalf("loadHost: %v", err)
+	} else {
+		engine.AddHost(host)
+	}
+
+	go func() {
+		runErr <- proxy.Run()
+	}()
+
+	transport.recvChan <- makeTestProxyRequest(snmp.GetRequestType, "proxy@test")
+	transport.recvChan <- makeTestProxyRequest(snmp.GetRequestType, "proxy@test")
+	transport.recvChan <- makeTestProxyRequest(snmp.GetRequestType, "proxy@test")
+
+	close(forwardWait)
+
+	var send = <-transport.sendChan
+
+	assert.Equal(t, snmp.GetResponseType, send.PDUType)
+	assert.NoError(t, proxy.Close())
+	assert.NoError(t, <-runErr)
+	assert.Empty(t, transport.sendChan, "dropped")
+	engine.clientMock.AssertNumberOfCalls(t, "Forward", 1)
+}
@@ -1,8 +1,9 @@
 package server
 
 import (
+	"fmt"
 	"github.com/qmsk/snmpbot/mibs"
-	"sync"
+	"github.com/qmsk/snmpbot/snmp"
 )
 
 // Object may not be set if Error
@@ -14,12 +15,42 @@ type ObjectResult struct {
 	Error       error
 }
 
+// Next is only set on a final result without any entry, if the query Limit was reached
+//
+// Context is only set for entries of a query using Contexts
 type TableResult struct {
 	Host        *Host
 	Table       *mibs.Table
+	Context     *mibs.Context
 	IndexValues mibs.IndexValues
 	EntryValues mibs.EntryValues
 	Error       error
+	Next        snmp.OID
+}
+
+// Query each connected host using a mibs.MultiClient, with bounded concurrency
+func queryHosts(hosts Hosts, concurrency uint, f func(*Host) error, fail func(*Host, error)) {
+	var list = make([]*Host, 0, len(hosts))
+	var multi = mibs.MultiClient{Concurrency: concurrency}
+
+	for _, host := range hosts {
+		if host.client == nil {
+			fail(host, fmt.Errorf("Host %v is not connected", host))
+		} else {
+			list = append(list, host)
+			multi.Targets = append(multi.Targets, host.client)
+		}
+	}
+
+	for i, summary := range multi.Run(func(index int, target mibs.MultiTarget) error {
+		return f(list[index])
+	}) {
+		if summary.Error != nil {
+			fail(list[i], summary.Error)
+		}
+
+		log.Debugf("Query host %v in %v: %v (%d client requests, including any concurrent queries)", list[i], summary.Duration, summary.Error, summary.ClientRequests)
+	}
 }
 
 type ObjectQuery struct {
@@ -29,8 +60,8 @@ type ObjectQuery struct {
 
 type objectQuery struct {
 	ObjectQuery
-	resultChan chan ObjectResult
-	waitGroup  sync.WaitGroup
+	concurrency uint
+	resultChan  chan ObjectResult
 }
 
 func (q *objectQuery) fail(host *Host, err error) {
@@ -59,37 +90,80 @@ func (q *objectQuery) queryHost(host *Host) error {
 func (q *objectQuery) query() {
 	defer close(q.resultChan)
 
-	for _, host := range q.Hosts {
-		q.waitGroup.Add(1)
-		go func(host *Host) {
-			defer q.waitGroup.Done()
-
-			if err := q.queryHost(host); err != nil {
-				q.fail(host, err)
-			}
-		}(host)
-	}
-
-	q.waitGroup.Wait()
+	queryHosts(q.Hosts, q.concurrency, q.queryHost, q.fail)
 }
 
+// Walk at most Limit entries per host and table, starting after the After index of each host
+//
+// Walk each table using each of the contexts from the index of the Contexts table, not supported with Limit or After.
 type TableQuery struct {
-	Hosts  Hosts
-	Tables Tables
+	Hosts    Hosts
+	Tables   Tables
+	Limit    int
+	After    map[HostID]snmp.OID
+	Contexts *mibs.Table
 }
 
 type tableQuery struct {
 	TableQuery
-	resultChan chan TableResult
-	waitGroup  sync.WaitGroup
+	concurrency uint
+	resultChan  chan TableResult
 }
 
 func (q *tableQuery) fail(host *Host, table *mibs.Table, err error) {
 	q.resultChan <- TableResult{Host: host, Table: table, Error: err}
 }
 
+func (q *tableQuery) failHost(host *Host, err error) {
+	for _, table := range q.Tables {
+		q.fail(host, table, err)
+	}
+}
+
+// Each table is queried in turn, any table errors do not stop the host query
+func (q *tableQuery) queryHost(host *Host) error {
+	if q.Contexts != nil {
+		return q.queryHostContexts(host)
+	}
+
+	for _, table := range q.Tables {
+		if err := q.queryHostTable(host, table); err != nil {
+			q.fail(host, table, err)
+		}
+	}
+
+	return nil
+}
+
+func (q *tableQuery) queryHostContexts(host *Host) error {
+	contexts, err := host.client.WalkContexts(q.Contexts)
+	if err != nil {
+		return fmt.Errorf("Contexts %v: %v", q.Contexts, err)
+	}
+
+	for _, table := range q.Tables {
+		var table = table
+
+		if err := host.client.WalkTableContexts(table, contexts, func(context mibs.Context, indexValues mibs.IndexValues, entryValues mibs.EntryValues, err error) error {
+			q.resultChan <- TableResult{
+				Host:        host,
+				Table:       table,
+				Context:     &context,
+				IndexValues: indexValues,
+				EntryValues: entryValues,
+				Error:       err,
+			}
+			return nil
+		}); err != nil {
+			q.fail(host, table, err)
+		}
+	}
+
+	return nil
+}
+
 func (q *tableQuery) queryHostTable(host *Host, table *mibs.Table) error {
-	if err := host.client.WalkTable(table, func(indexValues mibs.IndexValues, entryValues mibs.EntryValues, err error) error {
+	var f = func(indexValues mibs.IndexValues, entryValues mibs.EntryValues, err error) error {
 		q.resultChan <- TableResult{
 			Host:        host,
 			Table:       table,
@@ -98,8 +172,16 @@ func (q *tableQuery) queryHostTable(host *Host, table *mibs.Table) error {
 			Error:       err,
 		}
 		return nil
-	}); err != nil {
+	}
+
+	var after = q.After[host.id]
+
+	if q.Limit == 0 && after == nil {
+		return host.client.WalkTable(table, f)
+	} else if next, err := host.client.WalkTablePage(table, after, q.Limit, f); err != nil {
 		return err
+	} else if next != nil {
+		q.resultChan <- TableResult{Host: host, Table: table, Next: next}
 	}
 
 	return nil
@@ -108,18 +190,5 @@ func (q *tableQuery) queryHostTable(host *Host, table *mibs.Table) error {
 func (q *tableQuery) query() {
 	defer close(q.resultChan)
 
-	for _, host := range q.Hosts {
-		for _, table := range q.Tables {
-			q.waitGroup.Add(1)
-			go func(host *Host, table *mibs.Table) {
-				defer q.waitGroup.Done()
-
-				if err := q.queryHostTable(host, table); err != nil {
-					q.fail(host, table, err)
-				}
-			}(host, table)
-		}
-	}
-
-	q.waitGroup.Wait()
+	queryHosts(q.Hosts, q.concurrency, q.queryHost, q.failHost)
 }
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryObjectsNotConnected(t *testing.T) {
+	var object = testMIB.ResolveObject("test")
+	var q = objectQuery{
+		ObjectQuery: ObjectQuery{
+			Hosts:   MakeHosts(newHost("test")),
+			Objects: MakeObjects(object),
+		},
+		resultChan: make(chan ObjectResult),
+	}
+	var results []ObjectResult
+
+	go q.query()
+
+	for result := range q.resultChan {
+		results = append(results, result)
+	}
+
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, object, results[0].Object)
+		assert.EqualError(t, results[0].Error, "Host test is not connected")
+	}
+}
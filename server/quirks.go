@@ -0,0 +1,170 @@
+package server
+
+import (
+	"fmt"
+	"github.com/qmsk/snmpbot/client"
+	"github.com/qmsk/snmpbot/snmp"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var (
+	sysDescrOID    = snmp.OID{1, 3, 6, 1, 2, 1, 1, 1, 0} // SNMPv2-MIB::sysDescr.0
+	sysObjectIDOID = snmp.OID{1, 3, 6, 1, 2, 1, 1, 2, 0} // SNMPv2-MIB::sysObjectID.0
+)
+
+// Named agent quirk profile, matched against each host when probing, or referenced by HostConfig.Quirks.
+//
+// Any of the optional settings override the host client.Options.
+type QuirkConfig struct {
+	SysObjectID string // match any sysObjectID under the OID prefix
+	SysDescr    string // match any sysDescr using the regexp
+
+	NoBulk            *bool
+	MaxVars           *uint
+	MaxRepetitions    *uint
+	Timeout           *time.Duration
+	SkipNonIncreasing *bool
+	MaxWalkRequests   *uint
+	TableWalkMode     *client.TableWalkMode
+	Limits            *client.Limits
+
+	NoSuchEndOfMibView *bool // broken endOfMibView
+	TruncateCounter64  *bool // broken Counter64 encoding
+}
+
+type Quirk struct {
+	Name string
+
+	config      QuirkConfig
+	sysObjectID snmp.OID
+	sysDescr    *regexp.Regexp
+}
+
+func (quirk Quirk) String() string {
+	return quirk.Name
+}
+
+// Returns the length of any matching sysObjectID prefix, zero for a sysDescr match, or -1 if not matching.
+func (quirk Quirk) match(sysObjectID snmp.OID, sysDescr string) int {
+	if quirk.sysObjectID != nil && sysObjectID != nil && quirk.sysObjectID.Index(sysObjectID) != nil {
+		return len(quirk.sysObjectID)
+	} else if quirk.sysDescr != nil && quirk.sysDescr.MatchString(sysDescr) {
+		return 0
+	} else {
+		return -1
+	}
+}
+
+func (quirk Quirk) apply(options client.Options) client.Options {
+	var config = quirk.config
+
+	if config.NoBulk != nil {
+		options.NoBulk = *config.NoBulk
+	}
+	if config.MaxVars != nil {
+		options.MaxVars = *config.MaxVars
+	}
+	if config.MaxRepetitions != nil {
+		options.MaxRepetitions = *config.MaxRepetitions
+	}
+	if config.Timeout != nil {
+		options.Timeout = *config.Timeout
+	}
+	if config.SkipNonIncreasing != nil {
+		options.SkipNonIncreasing = *config.SkipNonIncreasing
+	}
+	if config.MaxWalkRequests != nil {
+		options.MaxWalkRequests = *config.MaxWalkRequests
+	}
+	if config.TableWalkMode != nil {
+		options.TableWalkMode = *config.TableWalkMode
+	}
+	if config.Limits != nil {
+		options.Limits = *config.Limits
+	}
+	if config.NoSuchEndOfMibView != nil {
+		options.NoSuchEndOfMibView = *config.NoSuchEndOfMibView
+	}
+	if config.TruncateCounter64 != nil {
+		options.TruncateCounter64 = *config.TruncateCounter64
+	}
+
+	return options
+}
+
+// Quirks in name order
+type Quirks []Quirk
+
+func loadQuirks(configs map[string]QuirkConfig) (Quirks, error) {
+	var quirks = make(Quirks, 0, len(configs))
+
+	for name, config := range configs {
+		var quirk = Quirk{Name: name, config: config}
+
+		if config.SysObjectID == "" {
+
+		} else if oid, err := snmp.ParseOID(config.SysObjectID); err != nil {
+			return nil, fmt.Errorf("Invalid quirks %v SysObjectID: %v", name, err)
+		} else {
+			quirk.sysObjectID = oid
+		}
+
+		if config.SysDescr == "" {
+
+		} else if re, err := regexp.Compile(config.SysDescr); err != nil {
+			return nil, fmt.Errorf("Invalid quirks %v SysDescr: %v", name, err)
+		} else {
+			quirk.sysDescr = re
+		}
+
+		quirks = append(quirks, quirk)
+	}
+
+	sort.Slice(quirks, func(i, j int) bool {
+		return quirks[i].Name < quirks[j].Name
+	})
+
+	return quirks, nil
+}
+
+func (quirks Quirks) Lookup(name string) (Quirk, error) {
+	for _, quirk := range quirks {
+		if quirk.Name == name {
+			return quirk, nil
+		}
+	}
+
+	return Quirk{}, fmt.Errorf("Unknown quirks: %v", name)
+}
+
+// Match the sysObjectID.0 and sysDescr.0 VarBinds, preferring the longest sysObjectID prefix over any sysDescr matches, and then by name.
+func (quirks Quirks) Match(varBinds []snmp.VarBind) (Quirk, bool) {
+	var sysObjectID snmp.OID
+	var sysDescr string
+	var match Quirk
+	var best = -1
+
+	for _, varBind := range varBinds {
+		if value, err := varBind.Value(); err != nil {
+			continue
+		} else if oid := varBind.OID(); oid.Equals(sysObjectIDOID) {
+			if oidValue, isOID := value.([]int); isOID {
+				sysObjectID = snmp.OID(oidValue)
+			}
+		} else if oid.Equals(sysDescrOID) {
+			if bytesValue, isBytes := value.([]byte); isBytes {
+				sysDescr = string(bytesValue)
+			}
+		}
+	}
+
+	for _, quirk := range quirks {
+		if m := quirk.match(sysObjectID, sysDescr); m > best {
+			match, best = quirk, m
+		}
+	}
+
+	return match, best >= 0
+}
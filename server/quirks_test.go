@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"testing"
+
+	"github.com/qmsk/snmpbot/client"
+	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func makeTestQuirks(configs map[string]QuirkConfig) Quirks {
+	if quirks, err := loadQuirks(configs); err != nil {
+		panic(err)
+	} else {
+		return quirks
+	}
+}
+
+func makeTestSystemVars(sysObjectID snmp.OID, sysDescr string) []snmp.VarBind {
+	return []snmp.VarBind{
+		snmp.MakeVarBind(sysObjectIDOID, asn1.ObjectIdentifier(sysObjectID)),
+		snmp.MakeVarBind(sysDescrOID, []byte(sysDescr)),
+	}
+}
+
+func TestLoadQuirksError(t *testing.T) {
+	var _, err = loadQuirks(map[string]QuirkConfig{"test": QuirkConfig{SysDescr: "("}})
+
+	assert.EqualError(t, err, "Invalid quirks test SysDescr: error parsing regexp: missing closing ): `(`")
+}
+
+func TestQuirksMatch(t *testing.T) {
+	var quirks = makeTestQuirks(map[string]QuirkConfig{
+		"vendor":   QuirkConfig{SysObjectID: ".1.3.6.1.4.1.4413"},
+		"model":    QuirkConfig{SysObjectID: ".1.3.6.1.4.1.4413.1.1"},
+		"firmware": QuirkConfig{SysDescr: `^EdgeSwitch .* 1\.[0-7]\.`},
+	})
+
+	for _, test := range []struct {
+		sysObjectID snmp.OID
+		sysDescr    string
+		match       string
+	}{
+		{snmp.OID{1, 3, 6, 1, 4, 1, 4413, 1, 1, 43}, "EdgeSwitch 24-Port, 1.9.1", "model"},
+		{snmp.OID{1, 3, 6, 1, 4, 1, 4413, 2}, "EdgeSwitch 24-Port, 1.9.1", "vendor"},
+		{snmp.OID{1, 3, 6, 1, 4, 1, 41112}, "EdgeSwitch 24-Port, 1.7.4", "firmware"},
+		{snmp.OID{1, 3, 6, 1, 4, 1, 41112}, "EdgeOS", ""},
+	} {
+		var quirk, ok = quirks.Match(makeTestSystemVars(test.sysObjectID, test.sysDescr))
+
+		assert.Equal(t, test.match != "", ok, "match %v %v", test.sysObjectID, test.sysDescr)
+		assert.Equal(t, test.match, quirk.Name, "match %v %v", test.sysObjectID, test.sysDescr)
+	}
+}
+
+func TestQuirkApply(t *testing.T) {
+	var noBulk = true
+	var maxRepetitions = uint(5)
+	var tableWalkMode = client.TableWalkColumns
+	var quirk = makeTestQuirks(map[string]QuirkConfig{"test": QuirkConfig{
+		NoBulk:         &noBulk,
+		MaxRepetitions: &maxRepetitions,
+		TableWalkMode:  &tableWalkMode,
+	}})[0]
+
+	var options = quirk.apply(client.Options{Community: "public", MaxVars: 50, MaxRepetitions: 20})
+
+	assert.Equal(t, client.Options{Community: "public", MaxVars: 50, MaxRepetitions: 5, NoBulk: true, TableWalkMode: client.TableWalkColumns}, options)
+}
+
+func TestQuirkApplyWorkarounds(t *testing.T) {
+	var enabled = true
+	var quirk = makeTestQuirks(map[string]QuirkConfig{"test": QuirkConfig{
+		NoSuchEndOfMibView: &enabled,
+		TruncateCounter64:  &enabled,
+	}})[0]
+
+	var options = quirk.apply(client.Options{Community: "public"})
+
+	assert.Equal(t, client.Options{Community: "public", NoSuchEndOfMibView: true, TruncateCounter64: true}, options)
+}
+
+func TestLoadHostQuirks(t *testing.T) {
+	var noBulk = true
+	var engine = makeTestEngine(testConfig{
+		clientMock: true,
+		quirks: makeTestQuirks(map[string]QuirkConfig{
+			"edgeswitch": QuirkConfig{SysObjectID: ".1.3.6.1.4.1.4413", NoBulk: &noBulk},
+		}),
+	})
+
+	engine.On("client", mock.AnythingOfType("client.Config")).Return(nil)
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil)
+	engine.clientMock.On("Get", []snmp.OID{sysObjectIDOID, sysDescrOID}).Return(makeTestSystemVars(snmp.OID{1, 3, 6, 1, 4, 1, 4413, 1, 1, 43}, "EdgeSwitch"), nil)
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost"})
+
+	assert.NoError(t, err, "loadHost")
+	assert.Equal(t, "edgeswitch", host.quirk, "Host.quirk")
+	assert.True(t, host.client.(*testEngineClient).config.NoBulk, "Host.client.config.NoBulk")
+	assert.Equal(t, "edgeswitch", hostView{host: host}.makeAPIIndex().Quirks, "api.HostIndex.Quirks")
+	engine.AssertNumberOfCalls(t, "client", 2)
+}
+
+func TestLoadHostQuirksBeforeProbe(t *testing.T) {
+	var noBulk = true
+	var engine = makeTestEngine(testConfig{
+		clientMock: true,
+		quirks: makeTestQuirks(map[string]QuirkConfig{
+			"edgeswitch": QuirkConfig{SysObjectID: ".1.3.6.1.4.1.4413", NoBulk: &noBulk},
+		}),
+	})
+	var clientConfig client.Config
+	var probeNoBulk []bool
+
+	engine.On("client", mock.AnythingOfType("client.Config")).Return(nil).Run(func(args mock.Arguments) {
+		clientConfig = args.Get(0).(client.Config)
+	})
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil).Run(func(args mock.Arguments) {
+		probeNoBulk = append(probeNoBulk, clientConfig.NoBulk)
+	})
+	engine.clientMock.On("Get", []snmp.OID{sysObjectIDOID, sysDescrOID}).Return(makeTestSystemVars(snmp.OID{1, 3, 6, 1, 4, 1, 4413, 1, 1, 43}, "EdgeSwitch"), nil)
+
+	var _, err = loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost"})
+
+	assert.NoError(t, err, "loadHost")
+	assert.Equal(t, []bool{true}, probeNoBulk, "Probe using client.Config.NoBulk")
+}
+
+func TestLoadHostQuirksGetError(t *testing.T) {
+	var noBulk = true
+	var engine = makeTestEngine(testConfig{
+		clientMock: true,
+		quirks: makeTestQuirks(map[string]QuirkConfig{
+			"edgeswitch": QuirkConfig{SysObjectID: ".1.3.6.1.4.1.4413", NoBulk: &noBulk},
+		}),
+	})
+
+	engine.On("client", mock.AnythingOfType("client.Config")).Return(nil)
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil)
+	engine.clientMock.On("Get", []snmp.OID{sysObjectIDOID, sysDescrOID}).Return(nil, fmt.Errorf("SNMP error: noSuchName"))
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost"})
+
+	assert.NoError(t, err, "loadHost")
+	assert.Equal(t, "", host.quirk, "Host.quirk")
+	assert.False(t, host.client.(*testEngineClient).config.NoBulk, "Host.client.config.NoBulk")
+	engine.clientMock.AssertNumberOfCalls(t, "Probe", 1)
+	engine.AssertNumberOfCalls(t, "client", 1)
+}
+
+func TestLoadHostQuirksConfig(t *testing.T) {
+	var maxVars = uint(10)
+	var engine = makeTestEngine(testConfig{
+		quirks: makeTestQuirks(map[string]QuirkConfig{
+			"slow": QuirkConfig{MaxVars: &maxVars},
+		}),
+	})
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost", Quirks: "slow"})
+
+	assert.NoError(t, err, "loadHost")
+	assert.Equal(t, "slow", host.quirk, "Host.quirk")
+	assert.Equal(t, uint(10), host.client.(*testEngineClient).config.MaxVars, "Host.client.config.MaxVars")
+
+	_, err = loadHost(engine, HostID("test"), HostConfig{SNMP: "localhost", Quirks: "unknown"})
+
+	assert.EqualError(t, err, "Host test: Unknown quirks: unknown")
+}
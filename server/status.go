@@ -0,0 +1,71 @@
+package server
+
+import (
+	"github.com/qmsk/go-web"
+	"github.com/qmsk/snmpbot/api"
+	"github.com/qmsk/snmpbot/client"
+	"sort"
+)
+
+type statusView struct {
+	engine Engine
+}
+
+func (view statusView) makeAPIHistogram(histogram client.LatencyHistogram) api.Histogram {
+	var apiHistogram = api.Histogram{
+		Buckets: make([]float64, len(histogram.Buckets)),
+		Counts:  histogram.Counts,
+		Count:   histogram.Count,
+		Sum:     histogram.Sum.Seconds(),
+	}
+
+	for i, bucket := range histogram.Buckets {
+		apiHistogram.Buckets[i] = bucket.Seconds()
+	}
+
+	return apiHistogram
+}
+
+func (view statusView) makeAPIStatus() api.Status {
+	var stats = view.engine.ClientStats()
+	var status = api.Status{
+		Engine: api.EngineStatus{
+			Requests:         stats.Requests,
+			Responses:        stats.Responses,
+			Timeouts:         stats.Timeouts,
+			Retries:          stats.Retries,
+			SendErrors:       stats.SendErrors,
+			UnknownResponses: stats.UnknownResponses,
+			UnknownTimeouts:  stats.UnknownTimeouts,
+			ProtocolErrors:   stats.ProtocolErrors,
+			Pending:          stats.Pending,
+			Queued:           stats.Queued,
+		},
+		Agents: make([]api.AgentStatus, 0, len(stats.Agents)),
+	}
+
+	for addr, agentStats := range stats.Agents {
+		status.Agents = append(status.Agents, api.AgentStatus{
+			Addr:      addr,
+			Requests:  agentStats.Requests,
+			Responses: agentStats.Responses,
+			Timeouts:  agentStats.Timeouts,
+			Retries:   agentStats.Retries,
+			Pending:   agentStats.Pending,
+			Queued:    agentStats.Queued,
+			SRTT:      agentStats.SRTT.Seconds(),
+			RTTVar:    agentStats.RTTVar.Seconds(),
+			Latency:   view.makeAPIHistogram(agentStats.Latency),
+		})
+	}
+
+	sort.Slice(status.Agents, func(i, j int) bool {
+		return status.Agents[i].Addr < status.Agents[j].Addr
+	})
+
+	return status
+}
+
+func (view statusView) GetREST() (web.Resource, error) {
+	return view.makeAPIStatus(), nil
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"github.com/qmsk/go-web/webtest"
+	"github.com/qmsk/snmpbot/api"
+)
+
+func TestGetStatus(t *testing.T) {
+	var engine = makeTestEngine(testConfig{})
+
+	var apiStatus api.Status
+	var testStatus = api.Status{
+		Engine: api.EngineStatus{
+			Requests:  2,
+			Responses: 1,
+			Timeouts:  1,
+		},
+		Agents: []api.AgentStatus{
+			{
+				Addr:      "192.0.2.1:161",
+				Requests:  2,
+				Responses: 1,
+				Timeouts:  1,
+				SRTT:      0.01,
+				Latency:   api.Histogram{Buckets: []float64{}},
+			},
+		},
+	}
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/status",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 200,
+			Object:     &apiStatus,
+		},
+	})
+
+	assert.Equal(t, testStatus, apiStatus, "response status")
+}
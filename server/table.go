@@ -4,6 +4,7 @@ import (
 	"github.com/qmsk/go-web"
 	"github.com/qmsk/snmpbot/api"
 	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
 	"path"
 	"strings"
 )
@@ -60,6 +61,19 @@ func (tables Tables) add(table *mibs.Table) {
 	tables[TableID(table.Key())] = table
 }
 
+// List tables in OID order
+func (tables Tables) List() []*mibs.Table {
+	var list = make([]*mibs.Table, 0, len(tables))
+
+	for _, table := range tables {
+		list = append(list, table)
+	}
+
+	mibs.SortTables(list)
+
+	return list
+}
+
 func (tables Tables) Keys() []TableID {
 	var keys = make([]TableID, 0, len(tables))
 
@@ -200,6 +214,11 @@ func (view tableView) entryFromResult(result TableResult) api.TableEntry {
 		Objects: make(api.TableObjectsMap),
 	}
 
+	if result.Context != nil {
+		for i, indexObject := range result.Context.Table.IndexSyntax {
+			entry.Index[indexObject.String()] = result.Context.IndexValues[i]
+		}
+	}
 	for i, indexObject := range view.table.IndexSyntax {
 		entry.Index[indexObject.String()] = result.IndexValues[i]
 	}
@@ -231,6 +250,23 @@ func (view tableView) errorFromResult(result TableResult) api.TableError {
 	}
 }
 
+// Include the context table index keys
+func (view tableView) makeAPIContextIndex(contexts *mibs.Table) api.TableIndex {
+	var index = view.makeAPIIndex()
+
+	if contexts != nil {
+		var indexKeys = make([]string, 0, len(contexts.IndexSyntax)+len(index.IndexKeys))
+
+		for _, indexObject := range contexts.IndexSyntax {
+			indexKeys = append(indexKeys, indexObject.String())
+		}
+
+		index.IndexKeys = append(indexKeys, index.IndexKeys...)
+	}
+
+	return index
+}
+
 type tablesView struct {
 	tables Tables
 }
@@ -238,7 +274,7 @@ type tablesView struct {
 func (view tablesView) makeAPIIndex() []api.TableIndex {
 	var tables = make([]api.TableIndex, 0, len(view.tables))
 
-	for _, table := range view.tables {
+	for _, table := range view.tables.List() {
 		tables = append(tables, tableView{table}.makeAPIIndex())
 	}
 
@@ -261,23 +297,82 @@ func (view mibTablesView) makeAPIIndex() []api.TableIndex {
 	return tables
 }
 
+func resolveContexts(name string) (*mibs.Table, error) {
+	if table, err := mibs.ResolveTable(name); err != nil {
+		return nil, web.RequestErrorf("Invalid contexts %v: %v", name, err)
+	} else if len(table.EntrySyntax) == 0 {
+		return nil, web.RequestErrorf("Invalid contexts %v: table has no entry objects", name)
+	} else {
+		return table, nil
+	}
+}
+
 type tableHandler struct {
-	engine Engine
-	hosts  Hosts
-	table  *mibs.Table
-	params api.TableQuery
+	engine   Engine
+	hosts    Hosts
+	table    *mibs.Table
+	after    map[HostID]snmp.OID
+	contexts *mibs.Table
+	params   api.TableQuery
+}
+
+// Parse the ?after=HOST:INDEX params, only querying the hosts with a next page.
+//
+// A plain ?after=INDEX is only supported when querying a single host.
+func (handler *tableHandler) parseAfter(params []string) error {
+	var hosts = make(Hosts)
+
+	handler.after = make(map[HostID]snmp.OID)
+
+	for _, param := range params {
+		var hostID HostID
+		var index string
+
+		if i := strings.LastIndex(param, ":"); i >= 0 {
+			hostID, index = HostID(param[:i]), param[i+1:]
+		} else if len(handler.hosts) != 1 {
+			return web.RequestErrorf("Invalid after %v: must be given as HOST:INDEX when querying multiple hosts", param)
+		} else {
+			for id := range handler.hosts {
+				hostID = id
+			}
+			index = param
+		}
+
+		if host, ok := handler.hosts[hostID]; !ok {
+			return web.RequestErrorf("Invalid after %v: unknown host %v", param, hostID)
+		} else if oid, err := snmp.ParseOID(index); err != nil {
+			return web.RequestErrorf("Invalid after %v: %v", param, err)
+		} else {
+			hosts[hostID] = host
+			handler.after[hostID] = oid
+		}
+	}
+
+	handler.hosts = hosts
+
+	return nil
 }
 
 func (handler *tableHandler) query() api.Table {
 	var table = api.Table{
-		TableIndex: tableView{handler.table}.makeAPIIndex(),
+		TableIndex: tableView{handler.table}.makeAPIContextIndex(handler.contexts),
 	}
 
 	for result := range handler.engine.QueryTables(TableQuery{
-		Hosts:  handler.hosts,
-		Tables: MakeTables(handler.table),
+		Hosts:    handler.hosts,
+		Tables:   MakeTables(handler.table),
+		Limit:    handler.params.Limit,
+		After:    handler.after,
+		Contexts: handler.contexts,
 	}) {
-		if result.IndexValues == nil || result.EntryValues == nil {
+		if result.Next != nil {
+			if table.Next == nil {
+				table.Next = make(map[string]string)
+			}
+
+			table.Next[string(result.Host.id)] = result.Next.String()
+		} else if result.IndexValues == nil || result.EntryValues == nil {
 			table.Errors = append(table.Errors, tableView{result.Table}.errorFromResult(result))
 		} else {
 			table.Entries = append(table.Entries, tableView{result.Table}.entryFromResult(result))
@@ -298,15 +393,33 @@ func (handler *tableHandler) GetREST() (web.Resource, error) {
 	if handler.params.Objects != nil {
 		handler.table = FilterTableObjects(handler.table, handler.params.Objects...)
 	}
+	if handler.params.Limit < 0 {
+		return nil, web.RequestErrorf("Invalid limit: %v", handler.params.Limit)
+	}
+	if handler.params.After != nil {
+		if err := handler.parseAfter(handler.params.After); err != nil {
+			return nil, err
+		}
+	}
+	if handler.params.Contexts == "" {
+
+	} else if handler.params.Limit != 0 || handler.params.After != nil {
+		return nil, web.RequestErrorf("Invalid contexts: not supported with limit or after")
+	} else if contexts, err := resolveContexts(handler.params.Contexts); err != nil {
+		return nil, err
+	} else {
+		handler.contexts = contexts
+	}
 
 	return handler.query(), nil
 }
 
 type tablesHandler struct {
-	engine Engine
-	hosts  Hosts
-	tables Tables
-	params api.TablesQuery
+	engine   Engine
+	hosts    Hosts
+	tables   Tables
+	contexts *mibs.Table
+	params   api.TablesQuery
 }
 
 func (handler *tablesHandler) query() []*api.Table {
@@ -315,7 +428,7 @@ func (handler *tablesHandler) query() []*api.Table {
 
 	for tableID, t := range handler.tables {
 		var table = &api.Table{
-			TableIndex: tableView{t}.makeAPIIndex(),
+			TableIndex: tableView{t}.makeAPIContextIndex(handler.contexts),
 			Entries:    []api.TableEntry{},
 		}
 
@@ -324,8 +437,9 @@ func (handler *tablesHandler) query() []*api.Table {
 	}
 
 	for result := range handler.engine.QueryTables(TableQuery{
-		Hosts:  handler.hosts,
-		Tables: handler.tables,
+		Hosts:    handler.hosts,
+		Tables:   handler.tables,
+		Contexts: handler.contexts,
 	}) {
 		var table = tableMap[TableID(result.Table.Key())]
 
@@ -353,6 +467,16 @@ func (handler *tablesHandler) GetREST() (web.Resource, error) {
 	if handler.params.Objects != nil {
 		handler.tables = handler.tables.FilterObjects(handler.params.Objects...)
 	}
+	if handler.params.Limit != 0 || handler.params.After != nil {
+		return nil, web.RequestErrorf("Invalid limit or after: only supported when querying a single table")
+	}
+	if handler.params.Contexts == "" {
+
+	} else if contexts, err := resolveContexts(handler.params.Contexts); err != nil {
+		return nil, err
+	} else {
+		handler.contexts = contexts
+	}
 
 	return handler.query(), nil
 }
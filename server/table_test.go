@@ -1,11 +1,14 @@
 package server
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
 
 	"github.com/qmsk/go-web/webtest"
 	"github.com/qmsk/snmpbot/api"
+	"github.com/qmsk/snmpbot/mibs"
+	"github.com/qmsk/snmpbot/snmp"
 )
 
 func TestGetTablesIndex(t *testing.T) {
@@ -39,3 +42,303 @@ func TestGetTablesIndex(t *testing.T) {
 
 	assert.Equal(t, testIndexTables, apiIndexTables, "response index")
 }
+
+func TestTableQueryPage(t *testing.T) {
+	var engine = makeTestEngine(testConfig{clientMock: true})
+	var table = engine.Tables().List()[0]
+
+	engine.mockClient("localhost", nil)
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil)
+	engine.clientMock.On("WalkTablePage", table, snmp.OID{1}, 1).Return([]TableResult{
+		{IndexValues: mibs.IndexValues{2}, EntryValues: mibs.EntryValues{"test2"}},
+	}, snmp.OID{2}, nil)
+	engine.clientMock.On("WalkTablePage", table, snmp.OID{5}, 1).Return([]TableResult{
+		{IndexValues: mibs.IndexValues{6}, EntryValues: mibs.EntryValues{"test6"}},
+	}, snmp.OID(nil), nil)
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP: "localhost",
+	})
+	if err != nil {
+		t.Fatalf("loadHost: %v", err)
+	}
+	host2, err := loadHost(engine, HostID("test2"), HostConfig{
+		SNMP: "localhost",
+	})
+	if err != nil {
+		t.Fatalf("loadHost: %v", err)
+	}
+
+	var q = tableQuery{
+		TableQuery: TableQuery{
+			Hosts:  MakeHosts(host, host2),
+			Tables: MakeTables(table),
+			Limit:  1,
+			After:  map[HostID]snmp.OID{"test": {1}, "test2": {5}},
+		},
+		resultChan: make(chan TableResult),
+	}
+	var results []TableResult
+
+	go q.query()
+
+	for result := range q.resultChan {
+		results = append(results, result)
+	}
+
+	assert.ElementsMatch(t, []TableResult{
+		{Host: host, Table: table, IndexValues: mibs.IndexValues{2}, EntryValues: mibs.EntryValues{"test2"}},
+		{Host: host, Table: table, Next: snmp.OID{2}},
+		{Host: host2, Table: table, IndexValues: mibs.IndexValues{6}, EntryValues: mibs.EntryValues{"test6"}},
+	}, results)
+}
+
+func TestTableHandlerParseAfter(t *testing.T) {
+	var host1 = &Host{id: "test1"}
+	var host2 = &Host{id: "192.0.2.2:1161"}
+	var hosts = Hosts{host1.id: host1, host2.id: host2}
+
+	for _, test := range []struct {
+		hosts Hosts
+		after []string
+		err   string
+
+		queryHosts Hosts
+		queryAfter map[HostID]snmp.OID
+	}{
+		{
+			hosts:      hosts,
+			after:      []string{"test1:.2", "192.0.2.2:1161:.1.5"},
+			queryHosts: hosts,
+			queryAfter: map[HostID]snmp.OID{"test1": {2}, "192.0.2.2:1161": {1, 5}},
+		},
+		{
+			hosts:      hosts,
+			after:      []string{"test1:.2"},
+			queryHosts: Hosts{host1.id: host1},
+			queryAfter: map[HostID]snmp.OID{"test1": {2}},
+		},
+		{
+			hosts:      Hosts{host1.id: host1},
+			after:      []string{".2"},
+			queryHosts: Hosts{host1.id: host1},
+			queryAfter: map[HostID]snmp.OID{"test1": {2}},
+		},
+		{
+			hosts: hosts,
+			after: []string{".2"},
+			err:   "Invalid after .2: must be given as HOST:INDEX when querying multiple hosts",
+		},
+		{
+			hosts: hosts,
+			after: []string{"test3:.2"},
+			err:   "Invalid after test3:.2: unknown host test3",
+		},
+		{
+			hosts: hosts,
+			after: []string{"test1:x"},
+			err:   "Invalid after test1:x: Invalid OID: does not start with .",
+		},
+	} {
+		var handler = tableHandler{hosts: test.hosts}
+
+		if err := handler.parseAfter(test.after); test.err != "" {
+			assert.EqualErrorf(t, err, test.err, "parseAfter %v", test.after)
+		} else if assert.NoErrorf(t, err, "parseAfter %v", test.after) {
+			assert.Equalf(t, test.queryHosts, handler.hosts, "parseAfter %v hosts", test.after)
+			assert.Equalf(t, test.queryAfter, handler.after, "parseAfter %v after", test.after)
+		}
+	}
+}
+
+func TestGetTableInvalidAfter(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/tables/TEST-MIB::testTable?after=x",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 422,
+		},
+	})
+}
+
+func TestGetTablesInvalidLimit(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	for _, target := range []string{"/tables/?limit=10", "/tables/?after=.1"} {
+		webtest.TestAPI(t, webtest.APITest{
+			Handler: WebAPI(engine),
+			Request: webtest.APIRequest{
+				Method: "GET",
+				Target: target,
+			},
+			Response: webtest.APIResponse{
+				StatusCode: 422,
+			},
+		})
+	}
+}
+
+func TestTableQueryContexts(t *testing.T) {
+	var engine = makeTestEngine(testConfig{clientMock: true})
+	var table = engine.Tables().List()[0]
+	var contextsTable = &mibs.Table{
+		ID:          mibs.ID{Name: "testContextTable"},
+		IndexSyntax: mibs.IndexSyntax{testMIB.ResolveObject("test")},
+	}
+	var contexts = []mibs.Context{
+		{Name: "1", Table: contextsTable, IndexValues: mibs.IndexValues{1}},
+		{Name: "2", Table: contextsTable, IndexValues: mibs.IndexValues{2}},
+	}
+
+	engine.mockClient("localhost", nil)
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil)
+	engine.clientMock.On("WalkContexts", contextsTable).Return(contexts, nil)
+	engine.clientMock.On("WalkTableContexts", table, contexts).Return([]TableResult{
+		{Context: &contexts[0], IndexValues: mibs.IndexValues{1}, EntryValues: mibs.EntryValues{"test1"}},
+		{Context: &contexts[1], IndexValues: mibs.IndexValues{1}, EntryValues: mibs.EntryValues{"test1"}},
+	}, nil)
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP: "localhost",
+	})
+	if err != nil {
+		t.Fatalf("loadHost: %v", err)
+	}
+
+	var q = tableQuery{
+		TableQuery: TableQuery{
+			Hosts:    MakeHosts(host),
+			Tables:   MakeTables(table),
+			Contexts: contextsTable,
+		},
+		resultChan: make(chan TableResult),
+	}
+	var entries []api.TableEntry
+
+	go q.query()
+
+	for result := range q.resultChan {
+		assert.NoError(t, result.Error)
+
+		entries = append(entries, tableView{table}.entryFromResult(result))
+	}
+
+	assert.Equal(t, []api.TableEntry{
+		{HostID: "test", Index: api.TableIndexMap{"TEST-MIB::test": 1, "TEST-MIB::testID": 1}, Objects: api.TableObjectsMap{"TEST-MIB::testName": "test1"}},
+		{HostID: "test", Index: api.TableIndexMap{"TEST-MIB::test": 2, "TEST-MIB::testID": 1}, Objects: api.TableObjectsMap{"TEST-MIB::testName": "test1"}},
+	}, entries)
+	assert.Equal(t, []string{"TEST-MIB::test", "TEST-MIB::testID"}, tableView{table}.makeAPIContextIndex(contextsTable).IndexKeys)
+}
+
+func TestTableQueryContextsWalkError(t *testing.T) {
+	var engine = makeTestEngine(testConfig{clientMock: true})
+	var table = engine.Tables().List()[0]
+	var contextsTable = &mibs.Table{
+		ID:          mibs.ID{Name: "testContextTable"},
+		IndexSyntax: mibs.IndexSyntax{testMIB.ResolveObject("test")},
+	}
+	var contexts = []mibs.Context{
+		{Name: "1", Table: contextsTable, IndexValues: mibs.IndexValues{1}},
+		{Name: "2", Table: contextsTable, IndexValues: mibs.IndexValues{2}},
+	}
+
+	engine.mockClient("localhost", nil)
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil)
+	engine.clientMock.On("WalkContexts", contextsTable).Return(contexts, nil)
+	engine.clientMock.On("WalkTableContexts", table, contexts).Return([]TableResult{
+		{Context: &contexts[0], Error: fmt.Errorf("Context 1: Test error")},
+		{Context: &contexts[1], IndexValues: mibs.IndexValues{1}, EntryValues: mibs.EntryValues{"test1"}},
+	}, nil)
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP: "localhost",
+	})
+	if err != nil {
+		t.Fatalf("loadHost: %v", err)
+	}
+
+	var q = tableQuery{
+		TableQuery: TableQuery{
+			Hosts:    MakeHosts(host),
+			Tables:   MakeTables(table),
+			Contexts: contextsTable,
+		},
+		resultChan: make(chan TableResult),
+	}
+	var results []TableResult
+
+	go q.query()
+
+	for result := range q.resultChan {
+		results = append(results, result)
+	}
+
+	if assert.Len(t, results, 2) {
+		assert.EqualError(t, results[0].Error, "Context 1: Test error")
+		assert.Equal(t, &contexts[0], results[0].Context)
+		assert.NoError(t, results[1].Error)
+		assert.Equal(t, &contexts[1], results[1].Context)
+	}
+}
+
+func TestGetTableContextsLimit(t *testing.T) {
+	var engine = makeTestEngine(testConfig{
+		mibs: testMIBs,
+	})
+
+	webtest.TestAPI(t, webtest.APITest{
+		Handler: WebAPI(engine),
+		Request: webtest.APIRequest{
+			Method: "GET",
+			Target: "/tables/TEST-MIB::testTable?contexts=TEST-MIB::testTable&limit=1",
+		},
+		Response: webtest.APIResponse{
+			StatusCode: 422,
+		},
+	})
+}
+
+func TestTableQueryContextsError(t *testing.T) {
+	var engine = makeTestEngine(testConfig{clientMock: true})
+	var table = engine.Tables().List()[0]
+
+	engine.mockClient("localhost", nil)
+	engine.clientMock.On("Probe", []mibs.ID{testMIB.ID}).Return([]bool{true}, nil)
+	engine.clientMock.On("WalkContexts", table).Return(nil, fmt.Errorf("Test error"))
+
+	var host, err = loadHost(engine, HostID("test"), HostConfig{
+		SNMP: "localhost",
+	})
+	if err != nil {
+		t.Fatalf("loadHost: %v", err)
+	}
+
+	var q = tableQuery{
+		TableQuery: TableQuery{
+			Hosts:    MakeHosts(host),
+			Tables:   MakeTables(table),
+			Contexts: table,
+		},
+		resultChan: make(chan TableResult),
+	}
+	var results []TableResult
+
+	go q.query()
+
+	for result := range q.resultChan {
+		results = append(results, result)
+	}
+
+	if assert.Len(t, results, 1) {
+		assert.EqualError(t, results[0].Error, "Contexts TEST-MIB::testTable: Test error")
+	}
+}
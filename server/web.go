@@ -18,13 +18,15 @@ func (route indexRoute) Index(name string) (web.Resource, error) {
 	case "":
 		return indexView{route.engine}, nil
 	case "mibs":
-		return mibsRoute{route.engine.MIBs()}, nil
+		return mibsRoute{engine: route.engine, mibs: route.engine.MIBs()}, nil
 	case "objects":
 		return objectsRoute{route.engine}, nil
 	case "tables":
 		return tablesRoute{route.engine}, nil
 	case "hosts":
 		return &hostsRoute{engine: route.engine, hosts: route.engine.Hosts()}, nil
+	case "status":
+		return statusView{route.engine}, nil
 	default:
 		return nil, nil
 	}
@@ -36,7 +38,7 @@ type indexView struct {
 
 func (view indexView) makeAPIIndex() api.Index {
 	return api.Index{
-		MIBs:         mibsView{view.engine.MIBs()}.makeAPIIndex(),
+		MIBs:         mibsView{mibs: view.engine.MIBs()}.makeAPIIndex(),
 		IndexObjects: objectsRoute{view.engine}.makeIndex(),
 		IndexTables:  tablesRoute{view.engine}.makeIndex(),
 		Hosts:        hostsView{engine: view.engine, hosts: view.engine.Hosts()}.makeAPIIndex(),
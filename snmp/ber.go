@@ -0,0 +1,595 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math"
+)
+
+// Reflection-free BER decoding and encoding of the SNMP packet structures.
+//
+// The decoded values reference the original buffer without copying: any RawValue.Bytes/FullBytes, OCTET STRING
+// values and the Packet.Community slice the input buffer, which must not be modified or re-used while the
+// decoded values are in use. The VarBind values are left as raw values, and only decoded by VarBind.Value().
+//
+// The decoding follows the same rules as the reflection-based github.com/geoffgarside/ber decoder: INTEGERs
+// must be minimally encoded, indefinite lengths are not supported, and any trailing bytes within a SEQUENCE
+// are ignored. In addition, the decoded integer fields are checked to be within the ranges defined by the RFCs.
+//
+// Any decoding errors are returned as a DecodeError with the byte offset of the invalid field.
+
+// Decoding state, used to report DecodeError offsets relative to the start of the decoded buffer.
+//
+// Any parsed buffers must be slices of the decoder buffer.
+type decoder struct {
+	buf []byte
+}
+
+// Return the byte offset of the buffer, which must be a slice of the decoder buffer.
+func (d decoder) offset(buf []byte) int {
+	if buf == nil {
+		return 0 // not decoded
+	}
+
+	return cap(d.buf) - cap(buf)
+}
+
+func (d decoder) error(buf []byte, field string, err error) DecodeError {
+	return DecodeError{Offset: d.offset(buf), Field: field, Err: err}
+}
+
+// Parse a BER value, see parseBER.
+func (d decoder) parse(buf []byte, field string) (asn1.RawValue, []byte, error) {
+	if raw, rest, err := parseBER(buf); err != nil {
+		return raw, nil, d.error(buf, field, err)
+	} else {
+		return raw, rest, nil
+	}
+}
+
+// Parse a BER value with the expected class, tag and form, returning the contents and the remaining bytes.
+func (d decoder) expect(buf []byte, field string, cls int, tag int, compound bool) ([]byte, []byte, error) {
+	if raw, rest, err := d.parse(buf, field); err != nil {
+		return nil, nil, err
+	} else if raw.Class != cls || raw.Tag != tag || raw.IsCompound != compound {
+		return nil, nil, d.error(buf, field, fmt.Errorf("%w: class=%d tag=%d compound=%v, expected class=%d tag=%d compound=%v",
+			ErrUnexpectedType, raw.Class, raw.Tag, raw.IsCompound, cls, tag, compound,
+		))
+	} else {
+		return raw.Bytes, rest, nil
+	}
+}
+
+func (d decoder) parseSequence(buf []byte, field string, cls int, tag int) ([]byte, []byte, error) {
+	return d.expect(buf, field, cls, tag, true)
+}
+
+// Parse an INTEGER within the given range.
+func (d decoder) parseInt(buf []byte, field string, min int64, max int64) (int, []byte, error) {
+	if contents, rest, err := d.expect(buf, field, asn1.ClassUniversal, asn1.TagInteger, false); err != nil {
+		return 0, nil, err
+	} else if value, err := decodeBERInteger(contents); err != nil {
+		return 0, nil, d.error(buf, field, err)
+	} else if value < min || value > max {
+		return 0, nil, d.error(buf, field, fmt.Errorf("%w: %d", ErrInvalidValue, value))
+	} else {
+		return int(value), rest, nil
+	}
+}
+
+func (d decoder) parseOctetString(buf []byte, field string) ([]byte, []byte, error) {
+	return d.expect(buf, field, asn1.ClassUniversal, asn1.TagOctetString, false)
+}
+
+func (d decoder) parseObjectIdentifier(buf []byte, field string) (asn1.ObjectIdentifier, []byte, error) {
+	if contents, rest, err := d.expect(buf, field, asn1.ClassUniversal, asn1.TagOID, false); err != nil {
+		return nil, nil, err
+	} else if oid, err := decodeBERObjectIdentifier(contents); err != nil {
+		return nil, nil, d.error(buf, field, err)
+	} else {
+		return asn1.ObjectIdentifier(oid), rest, nil
+	}
+}
+
+// Parse a SEQUENCE OF VarBind.
+func (d decoder) parseVarBinds(buf []byte) ([]VarBind, []byte, error) {
+	var count = 0
+
+	contents, rest, err := d.parseSequence(buf, "variable-bindings", asn1.ClassUniversal, asn1.TagSequence)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// count the number of elements, to allocate the slice once
+	for buf := contents; len(buf) > 0; count++ {
+		if _, next, err := parseBERExpect(buf, asn1.ClassUniversal, asn1.TagSequence, true); err != nil {
+			return nil, nil, d.error(buf, fmt.Sprintf("variable-bindings[%d]", count), err)
+		} else {
+			buf = next
+		}
+	}
+
+	var varBinds = make([]VarBind, count)
+
+	for i := range varBinds {
+		if varBind, next, err := d.parseVarBind(contents, i); err != nil {
+			return nil, nil, err
+		} else {
+			varBinds[i] = varBind
+			contents = next
+		}
+	}
+
+	return varBinds, rest, nil
+}
+
+// The VarBind SEQUENCE has already been validated by parseVarBinds.
+func (d decoder) parseVarBind(buf []byte, index int) (varBind VarBind, rest []byte, err error) {
+	var name, value []byte
+
+	if name, rest, err = parseBERExpect(buf, asn1.ClassUniversal, asn1.TagSequence, true); err != nil {
+		err = d.error(buf, fmt.Sprintf("variable-bindings[%d]", index), err)
+	} else if varBind.Name, value, err = decodeBERVarBindName(name); err != nil {
+		err = d.error(name, fmt.Sprintf("variable-bindings[%d].name", index), err)
+	} else if varBind.RawValue, _, err = parseBER(value); err != nil {
+		err = d.error(value, fmt.Sprintf("variable-bindings[%d].value", index), err)
+	}
+
+	return
+}
+
+// Parse the tag and length header of a BER value, returning the header size and contents length.
+func parseBERHeader(buf []byte) (raw asn1.RawValue, size int, length int, err error) {
+	if len(buf) == 0 {
+		return raw, 0, 0, ErrTruncated
+	}
+
+	var b = buf[0]
+	var offset = 1
+
+	raw.Class = int(b >> 6)
+	raw.IsCompound = b&0x20 != 0
+	raw.Tag = int(b & 0x1f)
+
+	if raw.Tag == 0x1f {
+		// multi-byte tag, up to 3 bytes
+		raw.Tag = 0
+
+		for {
+			if offset >= len(buf) {
+				return raw, 0, 0, fmt.Errorf("%w tag", ErrTruncated)
+			} else if offset > 3 {
+				return raw, 0, 0, fmt.Errorf("%w: too large", ErrInvalidTag)
+			}
+
+			b = buf[offset]
+			offset++
+
+			raw.Tag = raw.Tag<<7 | int(b&0x7f)
+
+			if b&0x80 == 0 {
+				break
+			}
+		}
+
+		if raw.Tag < 0x1f {
+			return raw, 0, 0, fmt.Errorf("%w: non-minimal", ErrInvalidTag)
+		}
+	}
+
+	if offset >= len(buf) {
+		return raw, 0, 0, fmt.Errorf("%w length", ErrTruncated)
+	}
+
+	b = buf[offset]
+	offset++
+
+	if b&0x80 == 0 {
+		length = int(b)
+	} else if b == 0x80 {
+		return raw, 0, 0, ErrIndefiniteLength
+	} else {
+		for i := 0; i < int(b&0x7f); i++ {
+			if offset >= len(buf) {
+				return raw, 0, 0, fmt.Errorf("%w length", ErrTruncated)
+			} else if length >= 1<<23 {
+				// would overflow 31 bits
+				return raw, 0, 0, ErrLengthOverflow
+			}
+
+			length = length<<8 | int(buf[offset])
+			offset++
+		}
+	}
+
+	return raw, offset, length, nil
+}
+
+// Parse a BER value, returning the RawValue with Bytes and FullBytes slicing the buffer, and the remaining bytes.
+func parseBER(buf []byte) (asn1.RawValue, []byte, error) {
+	raw, size, length, err := parseBERHeader(buf)
+	if err != nil {
+		return raw, nil, err
+	} else if length > len(buf)-size {
+		return raw, nil, fmt.Errorf("%w contents: length %d with %d bytes", ErrTruncated, length, len(buf)-size)
+	}
+
+	raw.Bytes = buf[size : size+length]
+	raw.FullBytes = buf[:size+length]
+
+	return raw, buf[size+length:], nil
+}
+
+// Parse a BER value with the expected class, tag and form, returning the contents and the remaining bytes.
+func parseBERExpect(buf []byte, cls int, tag int, compound bool) ([]byte, []byte, error) {
+	if raw, rest, err := parseBER(buf); err != nil {
+		return nil, nil, err
+	} else if raw.Class != cls || raw.Tag != tag || raw.IsCompound != compound {
+		return nil, nil, fmt.Errorf("%w: class=%d tag=%d compound=%v, expected class=%d tag=%d compound=%v",
+			ErrUnexpectedType, raw.Class, raw.Tag, raw.IsCompound, cls, tag, compound,
+		)
+	} else {
+		return raw.Bytes, rest, nil
+	}
+}
+
+func decodeBERVarBindName(buf []byte) (asn1.ObjectIdentifier, []byte, error) {
+	if contents, rest, err := parseBERExpect(buf, asn1.ClassUniversal, asn1.TagOID, false); err != nil {
+		return nil, nil, err
+	} else if oid, err := decodeBERObjectIdentifier(contents); err != nil {
+		return nil, nil, err
+	} else {
+		return asn1.ObjectIdentifier(oid), rest, nil
+	}
+}
+
+// Return the contents of a primitive value.
+func unpackPrimitive(raw asn1.RawValue) ([]byte, error) {
+	if raw.IsCompound {
+		return nil, fmt.Errorf("%w: constructed class=%d tag=%d", ErrUnexpectedType, raw.Class, raw.Tag)
+	}
+
+	return rawContents(raw)
+}
+
+// Decode the common universal types used for VarBind values, falling back to the reflection-based unpack for
+// any other types.
+func unpackUniversal(raw asn1.RawValue) (interface{}, error) {
+	var value interface{}
+
+	if raw.IsCompound {
+		return value, unpack(raw, &value)
+	}
+
+	switch raw.Tag {
+	case asn1.TagInteger:
+		if contents, err := rawContents(raw); err != nil {
+			return nil, err
+		} else if value, err := decodeBERInteger(contents); err != nil {
+			return nil, err
+		} else {
+			return value, nil
+		}
+
+	case asn1.TagOctetString:
+		if contents, err := rawContents(raw); err != nil {
+			return nil, err
+		} else {
+			return contents, nil
+		}
+
+	case asn1.TagOID:
+		if contents, err := rawContents(raw); err != nil {
+			return nil, err
+		} else if value, err := decodeBERObjectIdentifier(contents); err != nil {
+			return nil, err
+		} else {
+			return value, nil
+		}
+
+	default:
+		return value, unpack(raw, &value)
+	}
+}
+
+// Decode a minimally encoded, signed BER INTEGER.
+func decodeBERInteger(contents []byte) (int64, error) {
+	if len(contents) == 0 {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidInteger)
+	} else if len(contents) > 8 {
+		return 0, fmt.Errorf("%w: overflows 64 bits", ErrInvalidInteger)
+	} else if len(contents) > 1 && ((contents[0] == 0x00 && contents[1]&0x80 == 0) || (contents[0] == 0xff && contents[1]&0x80 != 0)) {
+		return 0, fmt.Errorf("%w: not minimally encoded", ErrInvalidInteger)
+	}
+
+	return decodeSigned(contents, 64)
+}
+
+// Decode a BER OBJECT IDENTIFIER.
+func decodeBERObjectIdentifier(contents []byte) ([]int, error) {
+	var count = 1 // the first sub-identifier encodes two components
+
+	if len(contents) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidOID)
+	} else if contents[len(contents)-1]&0x80 != 0 {
+		return nil, fmt.Errorf("%w: truncated", ErrInvalidOID)
+	}
+
+	for _, b := range contents {
+		if b&0x80 == 0 {
+			count++
+		}
+	}
+
+	var oid = make([]int, count)
+	var i = 1
+	var value int
+
+	for _, b := range contents {
+		if value > math.MaxUint32>>7 {
+			return nil, fmt.Errorf("%w: object identifier sub-identifier overflows 32 bits", ErrInvalidValue)
+		}
+
+		value = value<<7 | int(b&0x7f)
+
+		if b&0x80 != 0 {
+			continue
+		}
+
+		if i == 1 && value < 80 {
+			oid[0] = value / 40
+			oid[1] = value % 40
+		} else if i == 1 {
+			oid[0] = 2
+			oid[1] = value - 80
+		} else {
+			oid[i] = value
+		}
+
+		i++
+		value = 0
+	}
+
+	return oid, nil
+}
+
+// Encoded length of a BER tag and length header.
+func berHeaderSize(tag int, length int) int {
+	var size = 2
+
+	if tag >= 0x1f {
+		size += berBase128Size(int64(tag))
+	}
+
+	if length >= 0x80 {
+		for ; length > 0; length >>= 8 {
+			size++
+		}
+	}
+
+	return size
+}
+
+func berBase128Size(value int64) int {
+	var size = 0
+
+	if value == 0 {
+		return 1
+	}
+
+	for ; value > 0; value >>= 7 {
+		size++
+	}
+
+	return size
+}
+
+func appendBERBase128(buf []byte, value int64) []byte {
+	for i := berBase128Size(value) - 1; i >= 0; i-- {
+		var b = byte(value>>uint(i*7)) & 0x7f
+
+		if i != 0 {
+			b |= 0x80
+		}
+
+		buf = append(buf, b)
+	}
+
+	return buf
+}
+
+func appendBERHeader(buf []byte, cls int, tag int, compound bool, length int) []byte {
+	var b = byte(cls) << 6
+
+	if compound {
+		b |= 0x20
+	}
+
+	if tag < 0x1f {
+		buf = append(buf, b|byte(tag))
+	} else {
+		buf = appendBERBase128(append(buf, b|0x1f), int64(tag))
+	}
+
+	if length < 0x80 {
+		return append(buf, byte(length))
+	}
+
+	var size = 0
+
+	for l := length; l > 0; l >>= 8 {
+		size++
+	}
+
+	buf = append(buf, 0x80|byte(size))
+
+	for i := size - 1; i >= 0; i-- {
+		buf = append(buf, byte(length>>uint(i*8)))
+	}
+
+	return buf
+}
+
+func berIntSize(value int) int {
+	var size = 1
+
+	for v := int64(value); v > 127 || v < -128; v >>= 8 {
+		size++
+	}
+
+	return size
+}
+
+func appendBERInt(buf []byte, value int) []byte {
+	var size = berIntSize(value)
+
+	buf = appendBERHeader(buf, asn1.ClassUniversal, asn1.TagInteger, false, size)
+
+	for i := size - 1; i >= 0; i-- {
+		buf = append(buf, byte(int64(value)>>uint(i*8)))
+	}
+
+	return buf
+}
+
+func appendBEROctetString(buf []byte, value []byte) []byte {
+	return append(appendBERHeader(buf, asn1.ClassUniversal, asn1.TagOctetString, false, len(value)), value...)
+}
+
+func berObjectIdentifierSize(oid asn1.ObjectIdentifier) (int, error) {
+	if len(oid) < 2 || oid[0] > 2 || (oid[0] < 2 && oid[1] >= 40) {
+		return 0, fmt.Errorf("Invalid object identifier: %v", oid)
+	}
+
+	var size = berBase128Size(int64(oid[0]*40 + oid[1]))
+
+	for _, id := range oid[2:] {
+		size += berBase128Size(int64(id))
+	}
+
+	return size, nil
+}
+
+func appendBERObjectIdentifier(buf []byte, oid asn1.ObjectIdentifier, size int) []byte {
+	buf = appendBERHeader(buf, asn1.ClassUniversal, asn1.TagOID, false, size)
+	buf = appendBERBase128(buf, int64(oid[0]*40+oid[1]))
+
+	for _, id := range oid[2:] {
+		buf = appendBERBase128(buf, int64(id))
+	}
+
+	return buf
+}
+
+// Encoded size of a RawValue, using the FullBytes if set.
+func berRawSize(raw asn1.RawValue) int {
+	if len(raw.FullBytes) > 0 {
+		return len(raw.FullBytes)
+	} else {
+		return berHeaderSize(raw.Tag, len(raw.Bytes)) + len(raw.Bytes)
+	}
+}
+
+func appendBERRaw(buf []byte, raw asn1.RawValue) []byte {
+	if len(raw.FullBytes) > 0 {
+		return append(buf, raw.FullBytes...)
+	} else {
+		return append(appendBERHeader(buf, raw.Class, raw.Tag, raw.IsCompound, len(raw.Bytes)), raw.Bytes...)
+	}
+}
+
+// Encoded size of the VarBind SEQUENCE contents.
+func (varBind VarBind) berSize() (int, error) {
+	if oidSize, err := berObjectIdentifierSize(varBind.Name); err != nil {
+		return 0, err
+	} else {
+		return berHeaderSize(asn1.TagOID, oidSize) + oidSize + berRawSize(varBind.RawValue), nil
+	}
+}
+
+// Encoded size of the SEQUENCE OF VarBind contents.
+func berVarBindsSize(varBinds []VarBind) (int, error) {
+	var size = 0
+
+	for _, varBind := range varBinds {
+		if varBindSize, err := varBind.berSize(); err != nil {
+			return 0, err
+		} else {
+			size += berHeaderSize(asn1.TagSequence, varBindSize) + varBindSize
+		}
+	}
+
+	return size, nil
+}
+
+func appendBERVarBinds(buf []byte, varBinds []VarBind, size int) []byte {
+	buf = appendBERHeader(buf, asn1.ClassUniversal, asn1.TagSequence, true, size)
+
+	for _, varBind := range varBinds {
+		// already validated by berVarBindsSize
+		var varBindSize, _ = varBind.berSize()
+		var oidSize, _ = berObjectIdentifierSize(varBind.Name)
+
+		buf = appendBERHeader(buf, asn1.ClassUniversal, asn1.TagSequence, true, varBindSize)
+		buf = appendBERObjectIdentifier(buf, varBind.Name, oidSize)
+		buf = appendBERRaw(buf, varBind.RawValue)
+	}
+
+	return buf
+}
+
+// Pack the common PDU structure of three integers followed by the variable-bindings, as used by the GenericPDU
+// and BulkPDU. Returns a RawValue with the Bytes set, allocating a single buffer.
+func packPDU(meta PDUMeta, a int, b int, varBinds []VarBind) (asn1.RawValue, error) {
+	var raw = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: int(meta.PDUType), IsCompound: true}
+
+	varBindsSize, err := berVarBindsSize(varBinds)
+	if err != nil {
+		return raw, err
+	}
+
+	var size = 0
+
+	size += berHeaderSize(asn1.TagInteger, berIntSize(meta.RequestID)) + berIntSize(meta.RequestID)
+	size += berHeaderSize(asn1.TagInteger, berIntSize(a)) + berIntSize(a)
+	size += berHeaderSize(asn1.TagInteger, berIntSize(b)) + berIntSize(b)
+	size += berHeaderSize(asn1.TagSequence, varBindsSize) + varBindsSize
+
+	var buf = make([]byte, 0, size)
+
+	buf = appendBERInt(buf, meta.RequestID)
+	buf = appendBERInt(buf, a)
+	buf = appendBERInt(buf, b)
+	buf = appendBERVarBinds(buf, varBinds, varBindsSize)
+
+	raw.Bytes = buf
+
+	return raw, nil
+}
+
+// Unpack the common PDU structure of three integers followed by the variable-bindings.
+//
+// The request-id is an Integer32, and the other two integers are within 0..2147483647, per RFC 3416.
+func (d decoder) unpackPDUFields(raw asn1.RawValue, aField string, bField string) (requestID int, a int, b int, varBinds []VarBind, err error) {
+	var buf []byte
+
+	if raw.Class != asn1.ClassContextSpecific || !raw.IsCompound {
+		err = d.error(raw.FullBytes, "pdu", fmt.Errorf("%w: class=%d compound=%v", ErrUnexpectedType, raw.Class, raw.IsCompound))
+		return
+	}
+
+	if buf, err = rawContents(raw); err != nil {
+		err = d.error(raw.FullBytes, "pdu", err)
+		return
+	} else if requestID, buf, err = d.parseInt(buf, "request-id", math.MinInt32, math.MaxInt32); err != nil {
+		return
+	} else if a, buf, err = d.parseInt(buf, aField, 0, math.MaxInt32); err != nil {
+		return
+	} else if b, buf, err = d.parseInt(buf, bField, 0, math.MaxInt32); err != nil {
+		return
+	} else if varBinds, _, err = d.parseVarBinds(buf); err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,346 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"github.com/geoffgarside/ber"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+// Packets used for the codec equivalence tests and benchmarks.
+var testBERPackets = []string{
+	// GetNextRequest
+	`30 21 02 01 01 04 06 70 75 62 6c 69 63 a1 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05 00`,
+	// GetResponse OCTET STRING
+	`30 38 02 01 01 04 06 70 75 62 6c 69 63 a2 2b 02 04 01 7a 6d f3 02 01 00 02 01 00 30 1d 30 1b 06
+	 08 2b 06 01 02 01 01 05 00 04 0f 55 42 4e 54 20 45 64 67 65 53 77 69 74 63 68`,
+	// GetResponse Counter32
+	`30 30 02 01 01 04 06 70 75 62 6c 69 63 a2 23 02 04 29 9e 37 ef 02 01 00 02 01 00 30 15 30 13 06
+	 0a 2b 06 01 02 01 02 02 01 0a 01 41 05 00 a8 dc 8b 3b`,
+	// GetResponse NoSuchInstance
+	`30 29 02 01 01 04 06 70 75 62 6c 69 63 a2 1c 02 04 47 6b 38 88 02 01 00 02 01 00 30 0e 30 0c 06
+	 08 2b 06 01 02 01 01 05 01 81 00`,
+	// GetBulkRequest
+	`30 39 02 01 01 04 06 70 75 62 6c 69 63 a5 2c 02 04 2c 6a 76 19 02 01 00 02 01 0a 30 1e 30 0d 06
+	 09 2b 06 01 02 01 02 02 01 01 05 00 30 0d 06 09 2b 06 01 02 01 02 02 01 02 05 00`,
+	// TrapV1
+	`30 3c 02 01 00 04 06 70 75 62 6c 69 63 a4 2f 06 0a 2b 06 01 04 01 bf 08 03 02 0a 40 04 0a 00 00
+	 01 02 01 02 02 01 00 43 02 30 39 30 11 30 0f 06 0a 2b 06 01 02 01 02 02 01 01 02 02 01 02`,
+}
+
+// GetResponse with a typical mix of ifTable values, for benchmarks.
+func makeTestBERResponse() (Packet, PDUMeta, GenericPDU) {
+	var packet = Packet{Version: SNMPv2c, Community: []byte("public")}
+	var meta = PDUMeta{GetResponseType, 1337}
+	var pdu = GenericPDU{RequestID: 1337}
+
+	for i := 1; i <= 8; i++ {
+		pdu.VarBinds = append(pdu.VarBinds,
+			MakeVarBind(OID{1, 3, 6, 1, 2, 1, 2, 2, 1, 1, i}, i),
+			MakeVarBind(OID{1, 3, 6, 1, 2, 1, 2, 2, 1, 2, i}, []byte(fmt.Sprintf("eth%d", i))),
+			MakeVarBind(OID{1, 3, 6, 1, 2, 1, 2, 2, 1, 10, i}, Counter32(2833025851)),
+			MakeVarBind(OID{1, 3, 6, 1, 2, 1, 31, 1, 1, 1, 6, i}, Counter64(1<<40+uint64(i))),
+		)
+	}
+
+	return packet, meta, pdu
+}
+
+// The previous reflection-based decoding, using github.com/geoffgarside/ber.
+func legacyUnmarshal(buf []byte) (Packet, PDUMeta, PDU, error) {
+	var packet Packet
+	var pduType PDUType
+
+	if _, err := ber.Unmarshal(buf, &packet); err != nil {
+		return packet, PDUMeta{}, nil, err
+	} else if packet.RawPDU.Class != asn1.ClassContextSpecific {
+		return packet, PDUMeta{}, nil, fmt.Errorf("unexpected PDU: ASN.1 class %d", packet.RawPDU.Class)
+	} else {
+		pduType = PDUType(packet.RawPDU.Tag)
+	}
+
+	switch pduType {
+	case GetRequestType, GetNextRequestType, GetResponseType, SetRequestType, InformRequestType, TrapV2Type, ReportType:
+		var pdu GenericPDU
+
+		err := unpack(packet.RawPDU, &pdu)
+
+		return packet, PDUMeta{pduType, pdu.RequestID}, pdu, err
+
+	case GetBulkRequestType:
+		var pdu BulkPDU
+
+		err := unpack(packet.RawPDU, &pdu)
+
+		return packet, PDUMeta{pduType, pdu.RequestID}, pdu, err
+
+	case TrapV1Type:
+		var trapPDU struct {
+			Enterprise   asn1.ObjectIdentifier
+			AgentAddr    asn1.RawValue
+			GenericTrap  GenericTrap
+			SpecificTrap int
+			TimeStamp    asn1.RawValue
+			VarBinds     []VarBind
+		}
+
+		if err := unpack(packet.RawPDU, &trapPDU); err != nil {
+			return packet, PDUMeta{pduType, 0}, nil, err
+		}
+
+		// re-use the new decoding for the agent-addr and time-stamp values
+		var pdu TrapPDU
+
+		err := pdu.unpack(decoder{packet.RawPDU.FullBytes}, packet.RawPDU)
+
+		return packet, PDUMeta{pduType, 0}, TrapPDU{
+			Enterprise:   trapPDU.Enterprise,
+			AgentAddr:    pdu.AgentAddr,
+			GenericTrap:  trapPDU.GenericTrap,
+			SpecificTrap: trapPDU.SpecificTrap,
+			TimeStamp:    pdu.TimeStamp,
+			VarBinds:     trapPDU.VarBinds,
+		}, err
+
+	default:
+		return packet, PDUMeta{PDUType: pduType}, nil, fmt.Errorf("Unknown PDUType=%v", pduType)
+	}
+}
+
+// The previous reflection-based VarBind value decoding for universal types.
+func legacyValue(varBind VarBind) (interface{}, error) {
+	var value interface{}
+
+	if varBind.RawValue.Class != asn1.ClassUniversal || varBind.RawValue.Tag == asn1.TagNull {
+		return varBind.Value()
+	}
+
+	return value, unpack(varBind.RawValue, &value)
+}
+
+// The previous reflection-based encoding, using encoding/asn1.
+func legacyMarshal(packet Packet, meta PDUMeta, pdu PDU) ([]byte, error) {
+	var err error
+
+	switch pdu := pdu.(type) {
+	case GenericPDU:
+		packet.RawPDU, err = packSequence(asn1.ClassContextSpecific, int(meta.PDUType), meta.RequestID, pdu.ErrorStatus, pdu.ErrorIndex, pdu.VarBinds)
+	case BulkPDU:
+		packet.RawPDU, err = packSequence(asn1.ClassContextSpecific, int(meta.PDUType), meta.RequestID, pdu.NonRepeaters, pdu.MaxRepetitions, pdu.VarBinds)
+	default:
+		packet.RawPDU, err = pdu.Pack(meta)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(packet)
+}
+
+func varBindsOf(pdu PDU) []VarBind {
+	switch pdu := pdu.(type) {
+	case GenericPDU:
+		return pdu.VarBinds
+	case BulkPDU:
+		return pdu.VarBinds
+	case TrapPDU:
+		return pdu.VarBinds
+	default:
+		return nil
+	}
+}
+
+// Verify that the new and legacy decoding and encoding produce the same results.
+//
+// The new decoding is stricter, and also rejects any out-of-range values.
+func testBEREquivalence(t *testing.T, buf []byte) {
+	legacyPacket, legacyMeta, legacyPDU, legacyErr := legacyUnmarshal(buf)
+
+	var packet Packet
+	var meta PDUMeta
+	var pdu PDU
+	var err = packet.Unmarshal(buf)
+
+	if err == nil {
+		meta, pdu, err = packet.UnpackPDU()
+	}
+
+	if legacyErr != nil && err != nil {
+		return
+	} else if errors.Is(err, ErrInvalidValue) {
+		return
+	} else if legacyErr != nil {
+		t.Fatalf("Unmarshal(% x): legacy error %v, but decoded %v", buf, legacyErr, pdu)
+	} else if err != nil {
+		t.Fatalf("Unmarshal(% x): error %v, but legacy decoded %v", buf, err, legacyPDU)
+	}
+
+	assert.Equal(t, legacyPacket.Version, packet.Version, "Packet.Version")
+	assert.Equal(t, legacyPacket.Community, packet.Community, "Packet.Community")
+	assert.Equal(t, legacyPacket.RawPDU, packet.RawPDU, "Packet.RawPDU")
+	assert.Equal(t, legacyMeta, meta, "PDUMeta")
+	assert.Equal(t, legacyPDU, pdu, "PDU")
+
+	for _, varBind := range varBindsOf(pdu) {
+		legacyValue, legacyErr := legacyValue(varBind)
+		value, err := varBind.Value()
+
+		if legacyErr != nil || err != nil {
+			assert.Equal(t, legacyErr != nil, err != nil, "VarBind.Value(% x): %v <=> %v", varBind.RawValue.FullBytes, legacyErr, err)
+		} else {
+			assert.Equal(t, legacyValue, value, "VarBind.Value(% x)", varBind.RawValue.FullBytes)
+		}
+	}
+
+	if _, ok := pdu.(TrapPDU); ok {
+		return // not re-encodable if the agent-addr was not IPv4
+	}
+
+	legacyBytes, legacyErr := legacyMarshal(legacyPacket, legacyMeta, legacyPDU)
+
+	if err := packet.PackPDU(meta, pdu); err != nil {
+		assert.Error(t, legacyErr, "PackPDU(%v): %v", pdu, err)
+	} else if bytes, err := packet.Marshal(); err != nil {
+		assert.Error(t, legacyErr, "Marshal(%v): %v", pdu, err)
+	} else if legacyErr != nil {
+		t.Errorf("Marshal(%v): legacy error %v, but encoded % x", pdu, legacyErr, bytes)
+	} else {
+		assert.Equal(t, legacyBytes, bytes, "Marshal(%v)", pdu)
+	}
+}
+
+func TestBEREquivalence(t *testing.T) {
+	for _, str := range testBERPackets {
+		testBEREquivalence(t, decodeTestPacket(str))
+	}
+}
+
+// Deterministic mutations of the test packets, see also the native fuzz tests.
+func TestBEREquivalenceMutations(t *testing.T) {
+	var random = rand.New(rand.NewSource(1))
+
+	for _, str := range testBERPackets {
+		var buf = decodeTestPacket(str)
+
+		for i := 0; i < len(buf); i++ {
+			testBEREquivalence(t, buf[:i])
+		}
+
+		for i := 0; i < 1000; i++ {
+			var mutated = append([]byte(nil), buf...)
+
+			for n := random.Intn(3); n >= 0; n-- {
+				mutated[random.Intn(len(mutated))] = byte(random.Intn(256))
+			}
+
+			testBEREquivalence(t, mutated)
+		}
+	}
+}
+
+func TestBERMarshalEquivalence(t *testing.T) {
+	var packet, meta, pdu = makeTestBERResponse()
+
+	legacyBytes, err := legacyMarshal(packet, meta, pdu)
+	if err != nil {
+		t.Fatalf("legacyMarshal: %v", err)
+	}
+
+	if err := packet.PackPDU(meta, pdu); err != nil {
+		t.Fatalf("PackPDU: %v", err)
+	} else if bytes, err := packet.Marshal(); err != nil {
+		t.Fatalf("Marshal: %v", err)
+	} else {
+		assert.Equal(t, legacyBytes, bytes)
+	}
+
+	testBEREquivalence(t, legacyBytes)
+}
+
+func TestBERMarshalLength(t *testing.T) {
+	var packet = Packet{Version: SNMPv2c, Community: []byte("public")}
+	var pdu = GenericPDU{RequestID: -1}
+
+	// long-form lengths for the SEQUENCE and OCTET STRING
+	pdu.VarBinds = append(pdu.VarBinds, MakeVarBind(OID{1, 3, 6, 1, 2, 1, 1, 1, 0}, make([]byte, 300)))
+
+	if err := packet.PackPDU(PDUMeta{GetResponseType, pdu.RequestID}, pdu); err != nil {
+		t.Fatalf("PackPDU: %v", err)
+	} else if bytes, err := packet.Marshal(); err != nil {
+		t.Fatalf("Marshal: %v", err)
+	} else {
+		assert.Equal(t, []byte{0x30, 0x82, 0x01, 0x5a}, bytes[:4])
+
+		testBEREquivalence(t, bytes)
+	}
+}
+
+func TestBERMarshalInvalidOID(t *testing.T) {
+	var packet Packet
+	var pdu = GenericPDU{VarBinds: []VarBind{{Name: asn1.ObjectIdentifier{3, 1}}}}
+
+	assert.EqualError(t, packet.PackPDU(PDUMeta{GetRequestType, 0}, pdu), "Invalid object identifier: 3.1")
+}
+
+func BenchmarkPacketUnmarshal(b *testing.B) {
+	var packet, meta, pdu = makeTestBERResponse()
+	var buf, _ = legacyMarshal(packet, meta, pdu)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var packet Packet
+
+		if err := packet.Unmarshal(buf); err != nil {
+			b.Fatalf("Unmarshal: %v", err)
+		} else if _, _, err := packet.UnpackPDU(); err != nil {
+			b.Fatalf("UnpackPDU: %v", err)
+		}
+	}
+}
+
+func BenchmarkPacketUnmarshalLegacy(b *testing.B) {
+	var packet, meta, pdu = makeTestBERResponse()
+	var buf, _ = legacyMarshal(packet, meta, pdu)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := legacyUnmarshal(buf); err != nil {
+			b.Fatalf("legacyUnmarshal: %v", err)
+		}
+	}
+}
+
+func BenchmarkPacketMarshal(b *testing.B) {
+	var packet, meta, pdu = makeTestBERResponse()
+	var buf []byte
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var err error
+
+		if err = packet.PackPDU(meta, pdu); err != nil {
+			b.Fatalf("PackPDU: %v", err)
+		} else if buf, err = packet.MarshalAppend(buf[:0]); err != nil {
+			b.Fatalf("Marshal: %v", err)
+		}
+	}
+}
+
+func BenchmarkPacketMarshalLegacy(b *testing.B) {
+	var packet, meta, pdu = makeTestBERResponse()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyMarshal(packet, meta, pdu); err != nil {
+			b.Fatalf("legacyMarshal: %v", err)
+		}
+	}
+}
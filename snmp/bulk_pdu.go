@@ -14,8 +14,12 @@ type BulkPDU struct {
 	VarBinds       []VarBind
 }
 
-func (pdu *BulkPDU) unpack(raw asn1.RawValue) error {
-	return unpack(raw, pdu)
+func (pdu *BulkPDU) unpack(d decoder, raw asn1.RawValue) error {
+	var err error
+
+	pdu.RequestID, pdu.NonRepeaters, pdu.MaxRepetitions, pdu.VarBinds, err = d.unpackPDUFields(raw, "non-repeaters", "max-repetitions")
+
+	return err
 }
 
 func (pdu BulkPDU) GetRequestID() int {
@@ -42,10 +46,5 @@ func (pdu BulkPDU) GetError() PDUError {
 }
 
 func (pdu BulkPDU) Pack(meta PDUMeta) (asn1.RawValue, error) {
-	return packSequence(asn1.ClassContextSpecific, int(meta.PDUType),
-		meta.RequestID,
-		pdu.NonRepeaters,
-		pdu.MaxRepetitions,
-		pdu.VarBinds,
-	)
+	return packPDU(meta, pdu.NonRepeaters, pdu.MaxRepetitions, pdu.VarBinds)
 }
@@ -0,0 +1,33 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of DecodeError, for use with errors.Is()
+var (
+	ErrTruncated        = errors.New("truncated")
+	ErrIndefiniteLength = errors.New("indefinite length")
+	ErrLengthOverflow   = errors.New("length too large")
+	ErrInvalidTag       = errors.New("invalid tag")
+	ErrUnexpectedType   = errors.New("unexpected type")
+	ErrInvalidInteger   = errors.New("invalid integer")
+	ErrInvalidOID       = errors.New("invalid object identifier")
+	ErrInvalidValue     = errors.New("invalid value") // well-formed, but out of range
+)
+
+// Error decoding a packet or PDU.
+type DecodeError struct {
+	Offset int    // byte offset of the invalid field, relative to the start of the packet or PDU
+	Field  string // name of the invalid field, e.g. "variable-bindings[1].name"
+	Err    error  // one of the Err* kinds, possibly wrapped with further details
+}
+
+func (err DecodeError) Error() string {
+	return fmt.Sprintf("Invalid %v at offset %d: %v", err.Field, err.Offset, err.Err)
+}
+
+func (err DecodeError) Unwrap() error {
+	return err.Err
+}
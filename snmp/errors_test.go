@@ -0,0 +1,136 @@
+package snmp
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type decodeErrorTest struct {
+	bytes  string
+	offset int
+	field  string
+	err    error
+	str    string
+}
+
+func testDecodeError(t *testing.T, test decodeErrorTest) {
+	var buf = decodeTestPacket(test.bytes)
+	var packet Packet
+
+	_, _, err := packet.Decode(buf)
+
+	if decodeErr, ok := err.(DecodeError); !ok {
+		t.Errorf("Decode(% x): expected DecodeError, got %#v", buf, err)
+	} else {
+		assert.Equal(t, test.offset, decodeErr.Offset, "Decode(% x): offset", buf)
+		assert.Equal(t, test.field, decodeErr.Field, "Decode(% x): field", buf)
+		assert.True(t, errors.Is(err, test.err), "Decode(% x): errors.Is(%v, %v)", buf, err, test.err)
+
+		if test.str != "" {
+			assert.EqualError(t, err, test.str, "Decode(% x)", buf)
+		}
+	}
+}
+
+func TestDecodeErrorTruncated(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: ``,
+		field: "packet", offset: 0, err: ErrTruncated,
+		str: "Invalid packet at offset 0: truncated",
+	})
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 05 02 01`,
+		field: "packet", offset: 0, err: ErrTruncated,
+		str: "Invalid packet at offset 0: truncated contents: length 5 with 2 bytes",
+	})
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 04 06 70 75 62 6c 69 63 a1 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05`,
+		field: "packet", offset: 0, err: ErrTruncated,
+	})
+}
+
+func TestDecodeErrorIndefiniteLength(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 80 02 01 01 04 06 70 75 62 6c 69 63 00 00`,
+		field: "packet", offset: 0, err: ErrIndefiniteLength,
+		str: "Invalid packet at offset 0: indefinite length",
+	})
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 04 06 70 75 62 6c 69 63 a1 14 02 02 05 39 02 01 00 02 01 00 30 80 30 06 06 02 2b 06 05 00`,
+		field: "variable-bindings", offset: 25, err: ErrIndefiniteLength,
+	})
+}
+
+func TestDecodeErrorVersion(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 03 04 06 70 75 62 6c 69 63 a1 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05 00`,
+		field: "version", offset: 2, err: ErrInvalidValue,
+		str: "Invalid version at offset 2: invalid value: 3",
+	})
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 22 02 02 00 01 04 06 70 75 62 6c 69 63 a1 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05 00`,
+		field: "version", offset: 2, err: ErrInvalidInteger,
+		str: "Invalid version at offset 2: invalid integer: not minimally encoded",
+	})
+}
+
+func TestDecodeErrorCommunity(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 24 06 70 75 62 6c 69 63 a1 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05 00`,
+		field: "community", offset: 5, err: ErrUnexpectedType,
+		str: "Invalid community at offset 5: unexpected type: class=0 tag=4 compound=true, expected class=0 tag=4 compound=false",
+	})
+}
+
+func TestDecodeErrorPDUType(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 04 06 70 75 62 6c 69 63 a9 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05 00`,
+		field: "pdu", offset: 13, err: ErrUnexpectedType,
+		str: "Invalid pdu at offset 13: unexpected type: unknown PDUType=PDUType(9)",
+	})
+}
+
+func TestDecodeErrorIndex(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 04 06 70 75 62 6c 69 63 a2 14 02 02 05 39 02 01 00 02 01 ff 30 08 30 06 06 02 2b 06 05 00`,
+		field: "error-index", offset: 22, err: ErrInvalidValue,
+		str: "Invalid error-index at offset 22: invalid value: -1",
+	})
+}
+
+func TestDecodeErrorRequestID(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 24 02 01 01 04 06 70 75 62 6c 69 63 a2 17 02 05 01 00 00 00 00 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05 00`,
+		field: "request-id", offset: 15, err: ErrInvalidValue,
+	})
+}
+
+func TestDecodeErrorVarBind(t *testing.T) {
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 04 06 70 75 62 6c 69 63 a2 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 86 05 00`,
+		field: "variable-bindings[0].name", offset: 29, err: ErrInvalidOID,
+		str: "Invalid variable-bindings[0].name at offset 29: invalid object identifier: truncated",
+	})
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 04 06 70 75 62 6c 69 63 a2 14 02 02 05 39 02 01 00 02 01 00 30 08 30 06 06 02 2b 06 05 01`,
+		field: "variable-bindings[0].value", offset: 33, err: ErrTruncated,
+	})
+	testDecodeError(t, decodeErrorTest{
+		bytes: `30 21 02 01 01 04 06 70 75 62 6c 69 63 a2 14 02 02 05 39 02 01 00 02 01 00 30 08 31 06 06 02 2b 06 05 00`,
+		field: "variable-bindings[0]", offset: 27, err: ErrUnexpectedType,
+	})
+}
+
+func TestDecodeErrorUnpackPDU(t *testing.T) {
+	var packet Packet
+
+	if err := packet.Unmarshal(decodeTestPacket(`30 21 02 01 01 04 06 70 75 62 6c 69 63 a2 14 02 02 05 39 02 01 00 02 01 ff 30 08 30 06 06 02 2b 06 05 00`)); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	_, _, err := packet.UnpackPDU()
+
+	// relative to the PDU
+	assert.EqualError(t, err, "Invalid error-index at offset 9: invalid value: -1")
+}
@@ -0,0 +1,132 @@
+//go:build go1.18
+// +build go1.18
+
+package snmp
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Run with e.g.: go test -run=- -fuzz=FuzzPacketUnmarshal ./snmp
+//
+// Any failing inputs are written to testdata/fuzz, and should be committed as regression tests.
+
+func addFuzzPackets(f *testing.F) {
+	for _, str := range testBERPackets {
+		f.Add(decodeTestPacket(str))
+	}
+}
+
+// All decoding errors must be a DecodeError.
+func assertDecodeError(t *testing.T, err error) {
+	var decodeErr DecodeError
+
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected DecodeError, got %#v", err)
+	}
+}
+
+func FuzzBEREquivalence(f *testing.F) {
+	addFuzzPackets(f)
+
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		testBEREquivalence(t, buf)
+	})
+}
+
+func FuzzPacketUnmarshal(f *testing.F) {
+	addFuzzPackets(f)
+
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		var packet Packet
+
+		if err := packet.Unmarshal(buf); err != nil {
+			assertDecodeError(t, err)
+			return
+		}
+
+		var unmarshalPacket Packet
+
+		if bytes, err := packet.Marshal(); err != nil {
+			t.Fatalf("Marshal: %v", err)
+		} else if err := unmarshalPacket.Unmarshal(bytes); err != nil {
+			t.Fatalf("Unmarshal(Marshal(% x)): %v", buf, err)
+		} else {
+			assert.Equal(t, packet, unmarshalPacket)
+		}
+	})
+}
+
+func FuzzUnpackPDU(f *testing.F) {
+	for _, str := range testBERPackets {
+		var packet Packet
+
+		if err := packet.Unmarshal(decodeTestPacket(str)); err != nil {
+			f.Fatalf("Unmarshal: %v", err)
+		}
+
+		f.Add(packet.RawPDU.FullBytes)
+	}
+
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		var raw, _, err = parseBER(buf)
+
+		if err != nil {
+			return
+		}
+
+		meta, pdu, err := UnpackPDU(raw)
+		if err != nil {
+			assertDecodeError(t, err)
+			return
+		}
+
+		// must not panic
+		_ = pdu.GetError()
+		_ = pdu.GetRequestID()
+
+		if rawPDU, err := pdu.Pack(meta); err != nil {
+			t.Fatalf("Pack(%#v): %v", pdu, err)
+		} else if unpackMeta, unpackPDU, err := UnpackPDU(rawPDU); err != nil {
+			t.Fatalf("UnpackPDU(Pack(%#v)): %v", pdu, err)
+		} else {
+			assert.Equal(t, meta, unpackMeta)
+			assert.Equal(t, pdu, unpackPDU)
+		}
+	})
+}
+
+func FuzzVarBindValue(f *testing.F) {
+	for _, value := range []interface{}{
+		nil,
+		int(-1),
+		[]byte("test"),
+		IPAddress{10, 0, 0, 1},
+		Counter32(2833025851),
+		Gauge32(1),
+		TimeTicks32(12345),
+		Counter64(1 << 40),
+		OpaqueFloat(0.5),
+		OpaqueDouble(-1.25),
+		OpaqueInt64(-1),
+		NoSuchInstanceValue,
+	} {
+		f.Add(MakeVarBind(OID{1, 3, 6}, value).RawValue.FullBytes)
+	}
+
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		var varBind = VarBind{Name: []int{1, 3, 6}}
+		var err error
+
+		if varBind.RawValue, _, err = parseBER(buf); err != nil {
+			return
+		}
+
+		// must not panic
+		_, _ = varBind.Value()
+		_ = varBind.ErrorValue()
+		_ = varBind.String()
+	})
+}
@@ -13,8 +13,14 @@ type GenericPDU struct {
 	VarBinds    []VarBind
 }
 
-func (pdu *GenericPDU) unpack(raw asn1.RawValue) error {
-	return unpack(raw, pdu)
+func (pdu *GenericPDU) unpack(d decoder, raw asn1.RawValue) error {
+	var err error
+	var errorStatus int
+
+	pdu.RequestID, errorStatus, pdu.ErrorIndex, pdu.VarBinds, err = d.unpackPDUFields(raw, "error-status", "error-index")
+	pdu.ErrorStatus = ErrorStatus(errorStatus)
+
+	return err
 }
 
 func (pdu GenericPDU) GetRequestID() int {
@@ -36,7 +42,7 @@ func (pdu GenericPDU) String() string {
 }
 
 func (pdu GenericPDU) GetVarBind(index int) VarBind {
-	if index < len(pdu.VarBinds) {
+	if index >= 0 && index < len(pdu.VarBinds) {
 		return pdu.VarBinds[index]
 	} else {
 		return VarBind{}
@@ -51,10 +57,5 @@ func (pdu GenericPDU) GetError() PDUError {
 }
 
 func (pdu GenericPDU) Pack(meta PDUMeta) (asn1.RawValue, error) {
-	return packSequence(asn1.ClassContextSpecific, int(meta.PDUType),
-		meta.RequestID,
-		pdu.ErrorStatus,
-		pdu.ErrorIndex,
-		pdu.VarBinds,
-	)
+	return packPDU(meta, int(pdu.ErrorStatus), pdu.ErrorIndex, pdu.VarBinds)
 }
@@ -68,7 +68,3 @@ func marshalSequence(cls int, tag int, values ...interface{}) ([]byte, error) {
 		return asn1.Marshal(raw)
 	}
 }
-
-func marshal(obj interface{}) ([]byte, error) {
-	return asn1.Marshal(obj)
-}
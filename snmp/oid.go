@@ -80,6 +80,27 @@ func (oid OID) Equals(other OID) bool {
 	return true
 }
 
+// Compare OIDs in lexicographic order, with any prefix ordered first.
+//
+// Returns -1 if this OID is before the other OID, +1 if after, and 0 if equal.
+func (oid OID) Compare(other OID) int {
+	for i := 0; i < len(oid) && i < len(other); i++ {
+		if oid[i] < other[i] {
+			return -1
+		} else if oid[i] > other[i] {
+			return +1
+		}
+	}
+
+	if len(oid) < len(other) {
+		return -1
+	} else if len(oid) > len(other) {
+		return +1
+	} else {
+		return 0
+	}
+}
+
 // Test if the given OID is a more-specific of this OID, returning the extended part if so.
 // Returns {} if the OIDs are an exact match
 // Returns nil if the OIDs do not match
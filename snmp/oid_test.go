@@ -72,3 +72,24 @@ func TestOIDIndex(t *testing.T) {
 		assert.Equal(t, test.index, index, "OID(%#v).Index(%#v)", test.oid, test.oid2)
 	}
 }
+
+var testOIDCompare = []struct {
+	oid  OID
+	oid2 OID
+	cmp  int
+}{
+	{OID{1, 3, 6, 1}, OID{1, 3, 6, 1}, 0},
+	{OID{1, 3, 6, 1}, OID{1, 3, 6, 2}, -1},
+	{OID{1, 3, 6, 2}, OID{1, 3, 6, 1}, +1},
+	{OID{1, 3, 6}, OID{1, 3, 6, 1}, -1},
+	{OID{1, 3, 6, 1}, OID{1, 3, 6}, +1},
+	{OID{1, 3, 6, 1, 10}, OID{1, 3, 6, 2}, -1},
+	{OID{1, 3, 6, 10}, OID{1, 3, 6, 2, 1}, +1},
+	{nil, OID{}, 0},
+}
+
+func TestOIDCompare(t *testing.T) {
+	for _, test := range testOIDCompare {
+		assert.Equal(t, test.cmp, test.oid.Compare(test.oid2), "OID(%v).Compare(%v)", test.oid, test.oid2)
+	}
+}
@@ -0,0 +1,155 @@
+package snmp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Special types wrapped within Opaque values, as used by net-snmp.
+//
+// The Opaque value contains a BER-encoded value with an extended context-specific tag.
+type OpaqueFloat float32
+type OpaqueDouble float64
+type OpaqueCounter64 uint64
+type OpaqueInt64 int64
+type OpaqueUInt64 uint64
+
+type opaqueTag byte
+
+const (
+	opaqueTagPrefix byte = 0x9f // context-specific, extended tag
+
+	opaqueCounter64Tag opaqueTag = 0x76
+	opaqueFloatTag     opaqueTag = 0x78
+	opaqueDoubleTag    opaqueTag = 0x79
+	opaqueInt64Tag     opaqueTag = 0x7a
+	opaqueUInt64Tag    opaqueTag = 0x7b
+)
+
+// Decode an Opaque value, returning any special opaque types, or the raw Opaque bytes.
+func decodeOpaque(buf []byte) (interface{}, error) {
+	if len(buf) < 3 || buf[0] != opaqueTagPrefix {
+		return Opaque(buf), nil
+	}
+
+	var tag = opaqueTag(buf[1])
+	var length = int(buf[2])
+	var contents = buf[3:]
+
+	switch tag {
+	case opaqueCounter64Tag, opaqueFloatTag, opaqueDoubleTag, opaqueInt64Tag, opaqueUInt64Tag:
+	default:
+		return Opaque(buf), nil
+	}
+
+	if length != len(contents) {
+		return nil, fmt.Errorf("Invalid Opaque value: length %d with %d bytes", length, len(contents))
+	}
+
+	switch tag {
+	case opaqueFloatTag:
+		if len(contents) != 4 {
+			return nil, fmt.Errorf("Invalid Opaque float: length %d", len(contents))
+		}
+
+		return OpaqueFloat(math.Float32frombits(binary.BigEndian.Uint32(contents))), nil
+
+	case opaqueDoubleTag:
+		if len(contents) != 8 {
+			return nil, fmt.Errorf("Invalid Opaque double: length %d", len(contents))
+		}
+
+		return OpaqueDouble(math.Float64frombits(binary.BigEndian.Uint64(contents))), nil
+
+	case opaqueCounter64Tag:
+		if value, err := decodeUnsigned(contents, 64); err != nil {
+			return nil, err
+		} else {
+			return OpaqueCounter64(value), nil
+		}
+
+	case opaqueUInt64Tag:
+		if value, err := decodeUnsigned(contents, 64); err != nil {
+			return nil, err
+		} else {
+			return OpaqueUInt64(value), nil
+		}
+
+	case opaqueInt64Tag:
+		if value, err := decodeSigned(contents, 64); err != nil {
+			return nil, err
+		} else {
+			return OpaqueInt64(value), nil
+		}
+
+	default:
+		panic("unreachable")
+	}
+}
+
+// Decode a signed BER integer of the given bit size.
+func decodeSigned(contents []byte, bitSize int) (int64, error) {
+	var value int64
+
+	if len(contents) == 0 {
+		return 0, fmt.Errorf("Invalid integer: empty")
+	} else if len(contents) > bitSize/8 {
+		return 0, fmt.Errorf("Invalid integer: overflows %d bits", bitSize)
+	}
+
+	if contents[0]&0x80 != 0 {
+		value = -1 // sign-extend
+	}
+
+	for _, b := range contents {
+		value = value<<8 | int64(b)
+	}
+
+	return value, nil
+}
+
+// Encode a signed BER integer, using the minimal number of octets.
+func encodeSigned(value int64) []byte {
+	var buf = make([]byte, 8)
+
+	binary.BigEndian.PutUint64(buf, uint64(value))
+
+	for len(buf) > 1 && ((buf[0] == 0x00 && buf[1]&0x80 == 0) || (buf[0] == 0xff && buf[1]&0x80 != 0)) {
+		buf = buf[1:]
+	}
+
+	return buf
+}
+
+func encodeOpaque(tag opaqueTag, contents []byte) Opaque {
+	return append(Opaque{opaqueTagPrefix, byte(tag), byte(len(contents))}, contents...)
+}
+
+func (value OpaqueFloat) opaque() Opaque {
+	var buf = make([]byte, 4)
+
+	binary.BigEndian.PutUint32(buf, math.Float32bits(float32(value)))
+
+	return encodeOpaque(opaqueFloatTag, buf)
+}
+
+func (value OpaqueDouble) opaque() Opaque {
+	var buf = make([]byte, 8)
+
+	binary.BigEndian.PutUint64(buf, math.Float64bits(float64(value)))
+
+	return encodeOpaque(opaqueDoubleTag, buf)
+}
+
+func (value OpaqueCounter64) opaque() Opaque {
+	return encodeOpaque(opaqueCounter64Tag, encodeUnsigned(uint64(value)))
+}
+
+func (value OpaqueInt64) opaque() Opaque {
+	return encodeOpaque(opaqueInt64Tag, encodeSigned(int64(value)))
+}
+
+func (value OpaqueUInt64) opaque() Opaque {
+	return encodeOpaque(opaqueUInt64Tag, encodeUnsigned(uint64(value)))
+}
@@ -0,0 +1,81 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+type opaqueTest struct {
+	value interface{}
+	bytes []byte
+}
+
+func testOpaque(t *testing.T, test opaqueTest) {
+	var varBind = VarBind{Name: asn1.ObjectIdentifier{1}}
+
+	if err := varBind.Set(test.value); err != nil {
+		t.Fatalf("VarBind.Set(%#v): %v", test.value, err)
+	}
+
+	assert.Equal(t, test.bytes, varBind.RawValue.FullBytes, "VarBind.Set(%#v)", test.value)
+
+	var unmarshalVarBind = VarBind{Name: asn1.ObjectIdentifier{1}}
+
+	if _, err := asn1.Unmarshal(test.bytes, &unmarshalVarBind.RawValue); err != nil {
+		t.Fatalf("asn1.Unmarshal: %v", err)
+	}
+
+	if value, err := unmarshalVarBind.Value(); err != nil {
+		t.Errorf("VarBind.Value(% x): %v", test.bytes, err)
+	} else {
+		assert.Equal(t, test.value, value, "VarBind.Value(% x)", test.bytes)
+	}
+}
+
+func TestOpaqueFloat(t *testing.T) {
+	// UCD-SNMP-MIB::laLoadFloat
+	testOpaque(t, opaqueTest{OpaqueFloat(0.5), []byte{0x44, 0x07, 0x9f, 0x78, 0x04, 0x3f, 0x00, 0x00, 0x00}})
+	testOpaque(t, opaqueTest{OpaqueFloat(-1.25), []byte{0x44, 0x07, 0x9f, 0x78, 0x04, 0xbf, 0xa0, 0x00, 0x00}})
+	testOpaque(t, opaqueTest{OpaqueFloat(math.MaxFloat32), []byte{0x44, 0x07, 0x9f, 0x78, 0x04, 0x7f, 0x7f, 0xff, 0xff}})
+}
+
+func TestOpaqueDouble(t *testing.T) {
+	testOpaque(t, opaqueTest{OpaqueDouble(0.1), []byte{0x44, 0x0b, 0x9f, 0x79, 0x08, 0x3f, 0xb9, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}})
+}
+
+func TestOpaqueCounter64(t *testing.T) {
+	testOpaque(t, opaqueTest{OpaqueCounter64(0), []byte{0x44, 0x04, 0x9f, 0x76, 0x01, 0x00}})
+	testOpaque(t, opaqueTest{OpaqueCounter64(1<<64 - 1), []byte{0x44, 0x0c, 0x9f, 0x76, 0x09, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}})
+}
+
+func TestOpaqueUInt64(t *testing.T) {
+	testOpaque(t, opaqueTest{OpaqueUInt64(1 << 63), []byte{0x44, 0x0c, 0x9f, 0x7b, 0x09, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}})
+}
+
+func TestOpaqueInt64(t *testing.T) {
+	testOpaque(t, opaqueTest{OpaqueInt64(0), []byte{0x44, 0x04, 0x9f, 0x7a, 0x01, 0x00}})
+	testOpaque(t, opaqueTest{OpaqueInt64(-1), []byte{0x44, 0x04, 0x9f, 0x7a, 0x01, 0xff}})
+	testOpaque(t, opaqueTest{OpaqueInt64(128), []byte{0x44, 0x05, 0x9f, 0x7a, 0x02, 0x00, 0x80}})
+	testOpaque(t, opaqueTest{OpaqueInt64(-129), []byte{0x44, 0x05, 0x9f, 0x7a, 0x02, 0xff, 0x7f}})
+	testOpaque(t, opaqueTest{OpaqueInt64(math.MinInt64), []byte{0x44, 0x0b, 0x9f, 0x7a, 0x08, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}})
+	testOpaque(t, opaqueTest{OpaqueInt64(math.MaxInt64), []byte{0x44, 0x0b, 0x9f, 0x7a, 0x08, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}})
+}
+
+func TestOpaqueRaw(t *testing.T) {
+	testOpaque(t, opaqueTest{Opaque{0x01, 0x02}, []byte{0x44, 0x02, 0x01, 0x02}})
+	testOpaque(t, opaqueTest{Opaque{0x9f, 0x01, 0x00}, []byte{0x44, 0x03, 0x9f, 0x01, 0x00}})
+}
+
+func TestOpaqueError(t *testing.T) {
+	var varBind = VarBind{Name: asn1.ObjectIdentifier{1}}
+
+	if _, err := asn1.Unmarshal([]byte{0x44, 0x06, 0x9f, 0x78, 0x03, 0x3f, 0x00, 0x00}, &varBind.RawValue); err != nil {
+		t.Fatalf("asn1.Unmarshal: %v", err)
+	}
+
+	_, err := varBind.Value()
+
+	assert.EqualError(t, err, "Invalid Opaque float: length 3")
+}
@@ -11,18 +11,52 @@ type Packet struct {
 	RawPDU    asn1.RawValue
 }
 
+// Decode the packet from the given buffer.
+//
+// The decoded Community and RawPDU reference the buffer, which must not be modified while the packet is in use.
+// Any trailing bytes are ignored.
+//
+// Returns a DecodeError for any invalid packets.
 func (packet *Packet) Unmarshal(buf []byte) error {
-	if err := unmarshal(buf, packet); err != nil {
+	return packet.unmarshal(decoder{buf}, buf)
+}
+
+func (packet *Packet) unmarshal(d decoder, buf []byte) error {
+	var version int
+	var err error
+
+	if buf, _, err = d.parseSequence(buf, "packet", asn1.ClassUniversal, asn1.TagSequence); err != nil {
+		return err
+	} else if version, buf, err = d.parseInt(buf, "version", int64(SNMPv1), int64(SNMPv2c)); err != nil {
+		return err
+	} else if packet.Community, buf, err = d.parseOctetString(buf, "community"); err != nil {
 		return err
+	} else if packet.RawPDU, _, err = d.parse(buf, "pdu"); err != nil {
+		return err
+	} else {
+		packet.Version = Version(version)
 	}
 
 	if packet.RawPDU.Class != asn1.ClassContextSpecific {
-		return fmt.Errorf("unexpected PDU: ASN.1 class %d", packet.RawPDU.Class)
+		return d.error(buf, "pdu", fmt.Errorf("%w: class=%d", ErrUnexpectedType, packet.RawPDU.Class))
 	}
 
 	return nil
 }
 
+// Decode the packet and unpack the PDU from the given buffer, see Unmarshal and UnpackPDU.
+//
+// Any DecodeError offsets are relative to the start of the buffer.
+func (packet *Packet) Decode(buf []byte) (PDUMeta, PDU, error) {
+	var d = decoder{buf}
+
+	if err := packet.unmarshal(d, buf); err != nil {
+		return PDUMeta{}, nil, err
+	}
+
+	return d.unpackPDU(packet.RawPDU)
+}
+
 func (packet *Packet) PDUType() PDUType {
 	// assuming packet.PDU.Class == asn1.ClassContextSpecific
 	return PDUType(packet.RawPDU.Tag)
@@ -42,6 +76,29 @@ func (packet *Packet) PackPDU(meta PDUMeta, pdu PDU) error {
 	return nil
 }
 
+func (packet *Packet) size() int {
+	var versionSize = berIntSize(int(packet.Version))
+
+	return berHeaderSize(asn1.TagInteger, versionSize) + versionSize +
+		berHeaderSize(asn1.TagOctetString, len(packet.Community)) + len(packet.Community) +
+		berRawSize(packet.RawPDU)
+}
+
+// Encode the packet, returning a new buffer.
 func (packet *Packet) Marshal() ([]byte, error) {
-	return marshal(*packet)
+	var size = packet.size()
+
+	return packet.MarshalAppend(make([]byte, 0, berHeaderSize(asn1.TagSequence, size)+size))
+}
+
+// Encode the packet, appending to the given buffer.
+//
+// This allows re-using the same buffer for multiple packets.
+func (packet *Packet) MarshalAppend(buf []byte) ([]byte, error) {
+	buf = appendBERHeader(buf, asn1.ClassUniversal, asn1.TagSequence, true, packet.size())
+	buf = appendBERInt(buf, int(packet.Version))
+	buf = appendBEROctetString(buf, packet.Community)
+	buf = appendBERRaw(buf, packet.RawPDU)
+
+	return buf, nil
 }
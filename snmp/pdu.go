@@ -23,29 +23,47 @@ type PDU interface {
 	Pack(PDUMeta) (asn1.RawValue, error)
 }
 
+// Unpack the PDU from the raw value.
+//
+// Returns a DecodeError for any invalid PDUs, with the offset relative to the start of the PDU.
 func UnpackPDU(raw asn1.RawValue) (PDUMeta, PDU, error) {
+	if raw.FullBytes == nil {
+		return decoder{raw.Bytes}.unpackPDU(raw)
+	} else {
+		return decoder{raw.FullBytes}.unpackPDU(raw)
+	}
+}
+
+func (d decoder) unpackPDU(raw asn1.RawValue) (PDUMeta, PDU, error) {
 	var pduType = PDUType(raw.Tag)
 
 	if raw.Class != asn1.ClassContextSpecific {
-		return PDUMeta{PDUType: pduType}, nil, fmt.Errorf("unexpected PDU: ASN.1 class=%d tag=%d", raw.Class, raw.Tag)
+		return PDUMeta{PDUType: pduType}, nil, d.error(raw.FullBytes, "pdu", fmt.Errorf("%w: class=%d tag=%d", ErrUnexpectedType, raw.Class, raw.Tag))
 	}
 
 	switch pduType {
-	case GetRequestType, GetNextRequestType, GetResponseType, SetRequestType:
+	case GetRequestType, GetNextRequestType, GetResponseType, SetRequestType, InformRequestType, TrapV2Type, ReportType:
 		var pdu GenericPDU
 
-		err := pdu.unpack(raw)
+		err := pdu.unpack(d, raw)
 
 		return PDUMeta{pduType, pdu.RequestID}, pdu, err
 
 	case GetBulkRequestType:
 		var pdu BulkPDU
 
-		err := pdu.unpack(raw)
+		err := pdu.unpack(d, raw)
 
 		return PDUMeta{pduType, pdu.RequestID}, pdu, err
 
+	case TrapV1Type:
+		var pdu TrapPDU
+
+		err := pdu.unpack(d, raw)
+
+		return PDUMeta{pduType, 0}, pdu, err
+
 	default:
-		return PDUMeta{PDUType: pduType}, nil, fmt.Errorf("Unknown PDUType=%v", pduType)
+		return PDUMeta{PDUType: pduType}, nil, d.error(raw.FullBytes, "pdu", fmt.Errorf("%w: unknown PDUType=%v", ErrUnexpectedType, pduType))
 	}
 }
@@ -1,10 +1,7 @@
 package snmp
 
 import (
-	"encoding/asn1"
 	"fmt"
-	"net"
-	"time"
 )
 
 type Version int
@@ -65,6 +62,27 @@ const (
 	TrapEnterpriseSpecific    GenericTrap = 6
 )
 
+func (genericTrap GenericTrap) String() string {
+	switch genericTrap {
+	case TrapColdStart:
+		return "coldStart"
+	case TrapWarmStart:
+		return "warmStart"
+	case TrapLinkDown:
+		return "linkDown"
+	case TrapLinkUp:
+		return "linkUp"
+	case TrapAuthenticationFailure:
+		return "authenticationFailure"
+	case TrapEgpNeighborLoss:
+		return "egpNeighborLoss"
+	case TrapEnterpriseSpecific:
+		return "enterpriseSpecific"
+	default:
+		return fmt.Sprintf("GenericTrap(%d)", genericTrap)
+	}
+}
+
 type ErrorStatus int
 
 const (
@@ -134,13 +152,3 @@ const (
 	OpaqueType      ApplicationValueType = 4
 	Counter64Type   ApplicationValueType = 6
 )
-
-// SNMPv1 Trap-PDU
-type TrapPDU struct {
-	Enterprise   asn1.ObjectIdentifier
-	AgentAddr    net.IP // []byte
-	GenericTrap  GenericTrap
-	SpecificTrap int
-	TimeStamp    time.Duration // int64
-	VarBinds     []VarBind
-}
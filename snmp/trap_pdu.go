@@ -0,0 +1,156 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+)
+
+var (
+	SysUpTimeOID          = OID{1, 3, 6, 1, 2, 1, 1, 3, 0}       // SNMPv2-MIB::sysUpTime.0
+	SNMPTrapOID           = OID{1, 3, 6, 1, 6, 3, 1, 1, 4, 1, 0} // SNMPv2-MIB::snmpTrapOID.0
+	SNMPTrapEnterpriseOID = OID{1, 3, 6, 1, 6, 3, 1, 1, 4, 3, 0} // SNMPv2-MIB::snmpTrapEnterprise.0
+	SNMPTrapAddressOID    = OID{1, 3, 6, 1, 6, 3, 18, 1, 3, 0}   // SNMP-COMMUNITY-MIB::snmpTrapAddress.0
+	SNMPTrapsOID          = OID{1, 3, 6, 1, 6, 3, 1, 1, 5}       // SNMPv2-MIB::snmpTraps
+)
+
+// SNMPv1 Trap-PDU
+type TrapPDU struct {
+	Enterprise   asn1.ObjectIdentifier
+	AgentAddr    net.IP // []byte
+	GenericTrap  GenericTrap
+	SpecificTrap int
+	TimeStamp    TimeTicks32
+	VarBinds     []VarBind
+}
+
+func (pdu *TrapPDU) unpack(d decoder, raw asn1.RawValue) error {
+	var buf []byte
+	var agentAddr, timeStamp asn1.RawValue
+	var genericTrap int
+	var err error
+
+	if raw.Class != asn1.ClassContextSpecific || !raw.IsCompound {
+		return d.error(raw.FullBytes, "pdu", fmt.Errorf("%w: class=%d compound=%v", ErrUnexpectedType, raw.Class, raw.IsCompound))
+	} else if buf, err = rawContents(raw); err != nil {
+		return d.error(raw.FullBytes, "pdu", err)
+	} else if pdu.Enterprise, buf, err = d.parseObjectIdentifier(buf, "enterprise"); err != nil {
+		return err
+	} else if agentAddr, buf, err = d.parse(buf, "agent-addr"); err != nil {
+		return err
+	} else if genericTrap, buf, err = d.parseInt(buf, "generic-trap", int64(TrapColdStart), int64(TrapEnterpriseSpecific)); err != nil {
+		return err
+	} else if pdu.SpecificTrap, buf, err = d.parseInt(buf, "specific-trap", math.MinInt32, math.MaxInt32); err != nil {
+		return err
+	} else if timeStamp, buf, err = d.parse(buf, "time-stamp"); err != nil {
+		return err
+	} else if pdu.VarBinds, _, err = d.parseVarBinds(buf); err != nil {
+		return err
+	} else {
+		pdu.GenericTrap = GenericTrap(genericTrap)
+	}
+
+	if value, err := (VarBind{RawValue: agentAddr}).Value(); err != nil {
+		return d.error(agentAddr.FullBytes, "agent-addr", fmt.Errorf("%w: %v", ErrInvalidValue, err))
+	} else if ipAddress, ok := value.(IPAddress); !ok {
+		return d.error(agentAddr.FullBytes, "agent-addr", fmt.Errorf("%w: %#v", ErrUnexpectedType, value))
+	} else {
+		pdu.AgentAddr = net.IP{ipAddress[0], ipAddress[1], ipAddress[2], ipAddress[3]}
+	}
+
+	if value, err := (VarBind{RawValue: timeStamp}).Value(); err != nil {
+		return d.error(timeStamp.FullBytes, "time-stamp", fmt.Errorf("%w: %v", ErrInvalidValue, err))
+	} else if timeTicks, ok := value.(TimeTicks32); !ok {
+		return d.error(timeStamp.FullBytes, "time-stamp", fmt.Errorf("%w: %#v", ErrUnexpectedType, value))
+	} else {
+		pdu.TimeStamp = timeTicks
+	}
+
+	return nil
+}
+
+// SNMPv1 traps do not have any request-id
+func (pdu TrapPDU) GetRequestID() int {
+	return 0
+}
+
+func (pdu TrapPDU) String() string {
+	var varBinds = make([]string, len(pdu.VarBinds))
+
+	for i, varBind := range pdu.VarBinds {
+		varBinds[i] = varBind.String()
+	}
+
+	return fmt.Sprintf("%v@%v %v: %s", pdu.TrapOID(), pdu.AgentAddr, pdu.TimeStamp, strings.Join(varBinds, ", "))
+}
+
+func (pdu TrapPDU) GetError() PDUError {
+	return PDUError{}
+}
+
+func (pdu TrapPDU) Pack(meta PDUMeta) (asn1.RawValue, error) {
+	var agentAddr IPAddress
+
+	if ip4 := pdu.AgentAddr.To4(); ip4 != nil {
+		copy(agentAddr[:], ip4)
+	} else if pdu.AgentAddr != nil {
+		return asn1.RawValue{}, fmt.Errorf("Invalid Trap-PDU agent-addr: %v", pdu.AgentAddr)
+	}
+
+	if agentAddrValue, err := pack(asn1.ClassApplication, int(IPAddressType), agentAddr[:]); err != nil {
+		return asn1.RawValue{}, err
+	} else if timeStampValue, err := pack(asn1.ClassApplication, int(TimeTicks32Type), int(pdu.TimeStamp)); err != nil {
+		return asn1.RawValue{}, err
+	} else {
+		return packSequence(asn1.ClassContextSpecific, int(meta.PDUType),
+			pdu.Enterprise,
+			agentAddrValue,
+			pdu.GenericTrap,
+			pdu.SpecificTrap,
+			timeStampValue,
+			pdu.VarBinds,
+		)
+	}
+}
+
+// Translate the SNMPv1 enterprise/generic-trap/specific-trap to an SNMPv2 snmpTrapOID value, per RFC 3584 section 3.1.
+func (pdu TrapPDU) TrapOID() OID {
+	if pdu.GenericTrap == TrapEnterpriseSpecific {
+		return OID(pdu.Enterprise).Extend(0, pdu.SpecificTrap)
+	} else {
+		return SNMPTrapsOID.Extend(int(pdu.GenericTrap) + 1)
+	}
+}
+
+// Translate the SNMPv1 Trap-PDU to the equivalent SNMPv2-Trap-PDU variable-bindings, per RFC 3584 section 3.1:
+//
+//	sysUpTime.0, snmpTrapOID.0, variable-bindings..., snmpTrapAddress.0, snmpTrapEnterprise.0
+//
+// The snmpTrapAddress.0 is omitted if already present in the original variable-bindings.
+func (pdu TrapPDU) TrapV2VarBinds() []VarBind {
+	var varBinds = make([]VarBind, 0, len(pdu.VarBinds)+4)
+	var hasTrapAddress = false
+
+	varBinds = append(varBinds,
+		MakeVarBind(SysUpTimeOID, pdu.TimeStamp),
+		MakeVarBind(SNMPTrapOID, asn1.ObjectIdentifier(pdu.TrapOID())),
+	)
+
+	for _, varBind := range pdu.VarBinds {
+		if varBind.OID().Equals(SNMPTrapAddressOID) {
+			hasTrapAddress = true
+		}
+
+		varBinds = append(varBinds, varBind)
+	}
+
+	if ip4 := pdu.AgentAddr.To4(); ip4 != nil && !hasTrapAddress {
+		varBinds = append(varBinds, MakeVarBind(SNMPTrapAddressOID, IPAddress{ip4[0], ip4[1], ip4[2], ip4[3]}))
+	}
+
+	varBinds = append(varBinds, MakeVarBind(SNMPTrapEnterpriseOID, pdu.Enterprise))
+
+	return varBinds
+}
@@ -0,0 +1,102 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestPacketTrapV1(t *testing.T) {
+	testPacket(t, packetTest{
+		bytes: decodeTestPacket(`
+			30 3c 											-- SEQUENCE
+			02 01 00 										-- INTEGER version
+			04 06 70 75 62 6c 69 63 		-- OCTET STRING community
+			a4 2f 											-- Trap-PDU
+			  06 0a 2b 06 01 04 01 bf 08 03 02 0a 	-- OID enterprise
+			  40 04 0a 00 00 01 				-- IpAddress agent-addr
+			  02 01 02 									-- INTEGER generic-trap
+			  02 01 00 									-- INTEGER specific-trap
+			  43 02 30 39 							-- TimeTicks time-stamp
+			  30 11 										-- SEQUENCE variable-bindings
+			    30 0f 										-- SEQUENCE
+			      06 0a 2b 06 01 02 01 02 02 01 01 02 	-- OID name
+			      02 01 02 									-- INTEGER value
+		`),
+		packet: Packet{
+			Version:   SNMPv1,
+			Community: []byte("public"),
+		},
+		meta: PDUMeta{TrapV1Type, 0},
+		pdu: TrapPDU{
+			Enterprise:  asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 8072, 3, 2, 10},
+			AgentAddr:   net.IP{10, 0, 0, 1},
+			GenericTrap: TrapLinkDown,
+			TimeStamp:   12345,
+			VarBinds: []VarBind{
+				testVarBind(MustParseOID(".1.3.6.1.2.1.2.2.1.1.2"), 2),
+			},
+		},
+	})
+}
+
+func TestPacketTrapV2(t *testing.T) {
+	testPacket(t, packetTest{
+		bytes: decodeTestPacket(`
+			30 42 02 01 01 04 06 70 75 62 6c 69 63 a7 35 02
+			04 11 22 33 44 02 01 00 02 01 00 30 27 30 0d 06
+			08 2b 06 01 02 01 01 03 00 43 01 64 30 16 06 0a
+			2b 06 01 06 03 01 01 04 01 00 06 08 2b 06 01 06
+			03 01 01 05
+		`),
+		packet: Packet{
+			Version:   SNMPv2c,
+			Community: []byte("public"),
+		},
+		meta: PDUMeta{TrapV2Type, 0x11223344},
+		pdu: GenericPDU{
+			RequestID: 0x11223344,
+			VarBinds: []VarBind{
+				testVarBind(SysUpTimeOID, TimeTicks32(100)),
+				testVarBind(SNMPTrapOID, asn1.ObjectIdentifier{1, 3, 6, 1, 6, 3, 1, 1, 5}),
+			},
+		},
+	})
+}
+
+var testTrapOID = []struct {
+	pdu TrapPDU
+	oid OID
+}{
+	{TrapPDU{Enterprise: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 8072}, GenericTrap: TrapColdStart}, MustParseOID(".1.3.6.1.6.3.1.1.5.1")},
+	{TrapPDU{Enterprise: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 8072}, GenericTrap: TrapLinkDown}, MustParseOID(".1.3.6.1.6.3.1.1.5.3")},
+	{TrapPDU{Enterprise: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 8072}, GenericTrap: TrapEnterpriseSpecific, SpecificTrap: 17}, MustParseOID(".1.3.6.1.4.1.8072.0.17")},
+}
+
+func TestTrapOID(t *testing.T) {
+	for _, test := range testTrapOID {
+		assert.Equal(t, test.oid, test.pdu.TrapOID(), "TrapOID(%v/%v/%v)", test.pdu.Enterprise, test.pdu.GenericTrap, test.pdu.SpecificTrap)
+	}
+}
+
+func TestTrapV2VarBinds(t *testing.T) {
+	var ifIndex = MustParseOID(".1.3.6.1.2.1.2.2.1.1.2")
+	var pdu = TrapPDU{
+		Enterprise:  asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 8072, 3, 2, 10},
+		AgentAddr:   net.IP{10, 0, 0, 1},
+		GenericTrap: TrapLinkDown,
+		TimeStamp:   12345,
+		VarBinds: []VarBind{
+			MakeVarBind(ifIndex, 2),
+		},
+	}
+
+	assert.Equal(t, []VarBind{
+		MakeVarBind(SysUpTimeOID, TimeTicks32(12345)),
+		MakeVarBind(SNMPTrapOID, asn1.ObjectIdentifier{1, 3, 6, 1, 6, 3, 1, 1, 5, 3}),
+		MakeVarBind(ifIndex, 2),
+		MakeVarBind(SNMPTrapAddressOID, IPAddress{10, 0, 0, 1}),
+		MakeVarBind(SNMPTrapEnterpriseOID, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 8072, 3, 2, 10}),
+	}, pdu.TrapV2VarBinds())
+}
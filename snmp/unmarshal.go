@@ -28,13 +28,3 @@ func unpack(raw asn1.RawValue, value interface{}) error {
 
 	return nil
 }
-
-func unmarshal(data []byte, obj interface{}) error {
-	if _, err := ber.Unmarshal(data, obj); err != nil {
-		return err
-	} else {
-		// XXX: ignore trailing bytes
-	}
-
-	return nil
-}
@@ -0,0 +1,140 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// Return the contents octets of a primitive BER value.
+func rawContents(raw asn1.RawValue) ([]byte, error) {
+	if raw.Bytes != nil || raw.FullBytes == nil {
+		return raw.Bytes, nil
+	}
+
+	var buf = raw.FullBytes
+	var offset = 1
+
+	if len(buf) < 2 {
+		return nil, fmt.Errorf("Invalid BER value: truncated header")
+	} else if buf[0]&0x20 != 0 {
+		return nil, fmt.Errorf("Invalid BER value: constructed")
+	} else if buf[0]&0x1f == 0x1f {
+		// multi-byte tag
+		for offset < len(buf) && buf[offset]&0x80 != 0 {
+			offset++
+		}
+		offset++
+	}
+
+	if offset >= len(buf) {
+		return nil, fmt.Errorf("Invalid BER value: truncated header")
+	}
+
+	var length = int(buf[offset])
+	offset++
+
+	if length == 0x80 {
+		return nil, fmt.Errorf("Invalid BER value: indefinite length")
+	} else if length > 0x80 {
+		var lengthSize = length & 0x7f
+
+		if lengthSize > 4 || offset+lengthSize > len(buf) {
+			return nil, fmt.Errorf("Invalid BER value: invalid length")
+		}
+
+		length = 0
+		for _, b := range buf[offset : offset+lengthSize] {
+			length = length<<8 | int(b)
+		}
+		offset += lengthSize
+	}
+
+	if length < 0 || offset+length > len(buf) {
+		return nil, fmt.Errorf("Invalid BER value: truncated contents")
+	}
+
+	return buf[offset : offset+length], nil
+}
+
+// Decode an unsigned BER integer of the given bit size.
+//
+// Any redundant leading zero octets are ignored. For compatibility with agents that encode values >= 2^31 without
+// the leading zero octet, negative values encoded within the bit size are interpreted as unsigned.
+func decodeUnsigned(contents []byte, bitSize int) (uint64, error) {
+	var value uint64
+
+	if len(contents) == 0 {
+		return 0, fmt.Errorf("Invalid unsigned integer: empty")
+	}
+
+	if contents[0]&0x80 != 0 {
+		if len(contents) > bitSize/8 {
+			return 0, fmt.Errorf("Invalid unsigned integer: negative")
+		}
+
+		value = ^uint64(0) // sign-extend
+	}
+
+	for len(contents) > 1 && contents[0] == 0x00 {
+		contents = contents[1:]
+	}
+
+	if len(contents) > bitSize/8 {
+		return 0, fmt.Errorf("Invalid unsigned integer: overflows %d bits", bitSize)
+	}
+
+	for _, b := range contents {
+		value = value<<8 | uint64(b)
+	}
+
+	if bitSize < 64 {
+		value &= (uint64(1) << uint(bitSize)) - 1
+	}
+
+	return value, nil
+}
+
+// Encode an unsigned BER integer, using the minimal number of octets.
+func encodeUnsigned(value uint64) []byte {
+	var buf = make([]byte, 0, 9)
+
+	for shift := 56; shift > 0; shift -= 8 {
+		if b := byte(value >> uint(shift)); b != 0 || len(buf) > 0 {
+			buf = append(buf, b)
+		}
+	}
+
+	buf = append(buf, byte(value))
+
+	if buf[0]&0x80 != 0 {
+		buf = append([]byte{0x00}, buf...)
+	}
+
+	return buf
+}
+
+func unpackUnsigned(raw asn1.RawValue, bitSize int) (uint64, error) {
+	if contents, err := rawContents(raw); err != nil {
+		return 0, err
+	} else {
+		return decodeUnsigned(contents, bitSize)
+	}
+}
+
+// pack an unsigned application value, returning a RawValue with FullBytes set.
+func packUnsigned(tag ApplicationValueType, value uint64) (asn1.RawValue, error) {
+	var raw = asn1.RawValue{
+		Class: asn1.ClassApplication,
+		Tag:   int(tag),
+		Bytes: encodeUnsigned(value),
+	}
+
+	if bytes, err := asn1.Marshal(raw); err != nil {
+		return raw, err
+	} else {
+		raw.FullBytes = bytes
+		raw.Bytes = nil
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,146 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type unsignedTest struct {
+	value interface{}
+	bytes []byte
+}
+
+func testUnsignedRoundTrip(t *testing.T, test unsignedTest) {
+	var varBind VarBind
+
+	if err := varBind.Set(test.value); err != nil {
+		t.Fatalf("VarBind.Set(%#v): %v", test.value, err)
+	}
+
+	assert.Equal(t, test.bytes, varBind.RawValue.FullBytes, "VarBind.Set(%#v)", test.value)
+
+	// decode from both FullBytes only, and as unmarshalled with Bytes
+	if value, err := varBind.Value(); err != nil {
+		t.Errorf("VarBind.Value(%#v): %v", test.value, err)
+	} else {
+		assert.Equal(t, test.value, value)
+	}
+
+	var testVarBind = testVarBind(OID{1}, test.value)
+
+	if value, err := testVarBind.Value(); err != nil {
+		t.Errorf("VarBind.Value(%#v): %v", test.value, err)
+	} else {
+		assert.Equal(t, test.value, value)
+	}
+}
+
+func TestUnsignedCounter32(t *testing.T) {
+	for _, test := range []unsignedTest{
+		{Counter32(0), []byte{0x41, 0x01, 0x00}},
+		{Counter32(127), []byte{0x41, 0x01, 0x7f}},
+		{Counter32(128), []byte{0x41, 0x02, 0x00, 0x80}},
+		{Counter32(1<<31 - 1), []byte{0x41, 0x04, 0x7f, 0xff, 0xff, 0xff}},
+		{Counter32(1 << 31), []byte{0x41, 0x05, 0x00, 0x80, 0x00, 0x00, 0x00}},
+		{Counter32(1<<32 - 1), []byte{0x41, 0x05, 0x00, 0xff, 0xff, 0xff, 0xff}},
+	} {
+		testUnsignedRoundTrip(t, test)
+	}
+}
+
+func TestUnsignedGauge32(t *testing.T) {
+	for _, test := range []unsignedTest{
+		{Gauge32(0), []byte{0x42, 0x01, 0x00}},
+		{Gauge32(1<<32 - 1), []byte{0x42, 0x05, 0x00, 0xff, 0xff, 0xff, 0xff}},
+	} {
+		testUnsignedRoundTrip(t, test)
+	}
+}
+
+func TestUnsignedTimeTicks32(t *testing.T) {
+	for _, test := range []unsignedTest{
+		{TimeTicks32(0), []byte{0x43, 0x01, 0x00}},
+		{TimeTicks32(1<<32 - 1), []byte{0x43, 0x05, 0x00, 0xff, 0xff, 0xff, 0xff}},
+	} {
+		testUnsignedRoundTrip(t, test)
+	}
+}
+
+func TestUnsignedCounter64(t *testing.T) {
+	for _, test := range []unsignedTest{
+		{Counter64(0), []byte{0x46, 0x01, 0x00}},
+		{Counter64(1<<32 - 1), []byte{0x46, 0x05, 0x00, 0xff, 0xff, 0xff, 0xff}},
+		{Counter64(1 << 32), []byte{0x46, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00}},
+		{Counter64(1<<63 - 1), []byte{0x46, 0x08, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{Counter64(1 << 63), []byte{0x46, 0x09, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{Counter64(1<<64 - 1), []byte{0x46, 0x09, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	} {
+		testUnsignedRoundTrip(t, test)
+	}
+}
+
+type unsignedDecodeTest struct {
+	bytes []byte
+	value interface{}
+	err   string
+}
+
+func testUnsignedDecode(t *testing.T, test unsignedDecodeTest) {
+	var varBind = VarBind{Name: []int{1}}
+
+	varBind.RawValue.Class = asn1.ClassApplication
+	varBind.RawValue.Tag = int(test.bytes[0] & 0x1f)
+	varBind.RawValue.FullBytes = test.bytes
+
+	value, err := varBind.Value()
+
+	if test.err != "" {
+		assert.EqualError(t, err, test.err, "VarBind.Value(% x)", test.bytes)
+	} else if err != nil {
+		t.Errorf("VarBind.Value(% x): %v", test.bytes, err)
+	} else {
+		assert.Equal(t, test.value, value, "VarBind.Value(% x)", test.bytes)
+	}
+}
+
+func TestUnsignedDecode(t *testing.T) {
+	for _, test := range []unsignedDecodeTest{
+		// redundant leading zeros
+		{bytes: []byte{0x41, 0x06, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}, value: Counter32(1<<32 - 1)},
+		// agents encoding values >= 2^31 without the leading zero
+		{bytes: []byte{0x41, 0x04, 0xa8, 0xdc, 0x8b, 0x3b}, value: Counter32(2833025851)},
+		{bytes: []byte{0x46, 0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, value: Counter64(1<<64 - 1)},
+		{bytes: []byte{0x42, 0x01, 0xff}, value: Gauge32(1<<32 - 1)},
+
+		{bytes: []byte{0x41, 0x00}, err: "Invalid unsigned integer: empty"},
+		{bytes: []byte{0x41, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00}, err: "Invalid unsigned integer: overflows 32 bits"},
+		{bytes: []byte{0x41, 0x05, 0x80, 0x00, 0x00, 0x00, 0x00}, err: "Invalid unsigned integer: negative"},
+		{bytes: []byte{0x46, 0x09, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, err: "Invalid unsigned integer: overflows 64 bits"},
+		{bytes: []byte{0x41, 0x05, 0x00}, err: "Invalid BER value: truncated contents"},
+	} {
+		testUnsignedDecode(t, test)
+	}
+}
+
+func TestTruncateCounter64(t *testing.T) {
+	for _, test := range []unsignedDecodeTest{
+		{bytes: []byte{0x46, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00}, value: Counter64(1 << 32)},
+		{bytes: []byte{0x46, 0x09, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02}, value: Counter64(2)},
+		{bytes: []byte{0x46, 0x09, 0xff, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, value: Counter64(1 << 63)},
+		{bytes: []byte{0x46, 0x0a, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, value: Counter64(1<<64 - 1)},
+		{bytes: []byte{0x41, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00}, err: "Invalid unsigned integer: overflows 32 bits"},
+	} {
+		var varBind = VarBind{Name: []int{1}}
+
+		varBind.RawValue.Class = asn1.ClassApplication
+		varBind.RawValue.Tag = int(test.bytes[0] & 0x1f)
+		varBind.RawValue.FullBytes = test.bytes
+
+		if err := varBind.TruncateCounter64(); err != nil {
+			t.Errorf("VarBind.TruncateCounter64(% x): %v", test.bytes, err)
+		}
+
+		testUnsignedDecode(t, unsignedDecodeTest{bytes: varBind.RawValue.FullBytes, value: test.value, err: test.err})
+	}
+}
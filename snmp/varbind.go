@@ -61,17 +61,13 @@ func (varBind VarBind) Value() (interface{}, error) {
 		if varBind.RawValue.Tag == asn1.TagNull {
 			return nil, nil
 		} else {
-			var value interface{}
-
-			return value, unpack(varBind.RawValue, &value)
+			return unpackUniversal(varBind.RawValue)
 		}
 
 	case asn1.ClassApplication:
 		switch ApplicationValueType(varBind.RawValue.Tag) {
 		case IPAddressType:
-			var value []byte
-
-			if err := unpack(varBind.RawValue, &value); err != nil {
+			if value, err := unpackPrimitive(varBind.RawValue); err != nil {
 				return nil, err
 			} else if len(value) != 4 {
 				return nil, fmt.Errorf("Invalid IPAddress value: %#v", value)
@@ -86,41 +82,35 @@ func (varBind VarBind) Value() (interface{}, error) {
 			}
 
 		case Counter32Type:
-			var value int
-
-			if err := unpack(varBind.RawValue, &value); err != nil {
+			if value, err := unpackUnsigned(varBind.RawValue, 32); err != nil {
 				return nil, err
 			} else {
 				return Counter32(value), nil
 			}
 
 		case Gauge32Type:
-			var value int
-
-			if err := unpack(varBind.RawValue, &value); err != nil {
+			if value, err := unpackUnsigned(varBind.RawValue, 32); err != nil {
 				return nil, err
 			} else {
 				return Gauge32(value), nil
 			}
 
 		case TimeTicks32Type:
-			var value int
-
-			if err := unpack(varBind.RawValue, &value); err != nil {
+			if value, err := unpackUnsigned(varBind.RawValue, 32); err != nil {
 				return nil, err
 			} else {
 				return TimeTicks32(value), nil
 			}
 
 		case OpaqueType:
-			var value Opaque
-
-			return value, unpack(varBind.RawValue, &value)
+			if value, err := unpackPrimitive(varBind.RawValue); err != nil {
+				return nil, err
+			} else {
+				return decodeOpaque(value)
+			}
 
 		case Counter64Type:
-			var value int64 // XXX: no support for uint64?
-
-			if err := unpack(varBind.RawValue, &value); err != nil {
+			if value, err := unpackUnsigned(varBind.RawValue, 64); err != nil {
 				return nil, err
 			} else {
 				return Counter64(value), nil
@@ -157,15 +147,27 @@ func (varBind *VarBind) Set(genericValue interface{}) error {
 	case ErrorValue:
 		return varBind.SetError(value)
 	case IPAddress:
-		return varBind.setApplication(IPAddressType, value)
+		return varBind.setApplication(IPAddressType, value[:])
 	case Counter32:
-		return varBind.setApplication(Counter32Type, int(value))
+		return varBind.setUnsigned(Counter32Type, uint64(value))
 	case Gauge32:
-		return varBind.setApplication(Gauge32Type, int(value))
+		return varBind.setUnsigned(Gauge32Type, uint64(value))
 	case TimeTicks32:
-		return varBind.setApplication(TimeTicks32Type, int(value))
+		return varBind.setUnsigned(TimeTicks32Type, uint64(value))
 	case Opaque:
-		return varBind.setApplication(OpaqueType, value)
+		return varBind.setApplication(OpaqueType, []byte(value))
+	case OpaqueFloat:
+		return varBind.setApplication(OpaqueType, []byte(value.opaque()))
+	case OpaqueDouble:
+		return varBind.setApplication(OpaqueType, []byte(value.opaque()))
+	case OpaqueCounter64:
+		return varBind.setApplication(OpaqueType, []byte(value.opaque()))
+	case OpaqueInt64:
+		return varBind.setApplication(OpaqueType, []byte(value.opaque()))
+	case OpaqueUInt64:
+		return varBind.setApplication(OpaqueType, []byte(value.opaque()))
+	case Counter64:
+		return varBind.setUnsigned(Counter64Type, uint64(value))
 	default:
 		if rawValue, err := pack(asn1.ClassUniversal, 0, value); err != nil {
 			return err
@@ -195,6 +197,23 @@ func (varBind *VarBind) SetError(errorValue ErrorValue) error {
 	return nil
 }
 
+// Truncate any Counter64 value encoded with more than 64 bits to the low-order 64 bits, for agents with broken Counter64 encodings.
+//
+// Any other values are left as-is.
+func (varBind *VarBind) TruncateCounter64() error {
+	if varBind.RawValue.Class != asn1.ClassApplication || ApplicationValueType(varBind.RawValue.Tag) != Counter64Type {
+		return nil
+	} else if contents, err := rawContents(varBind.RawValue); err != nil {
+		return err
+	} else if len(contents) <= 8 {
+		return nil
+	} else if value, err := decodeUnsigned(contents[len(contents)-8:], 64); err != nil {
+		return err
+	} else {
+		return varBind.setUnsigned(Counter64Type, value)
+	}
+}
+
 func (varBind *VarBind) setApplication(tag ApplicationValueType, value interface{}) error {
 	if rawValue, err := pack(asn1.ClassApplication, int(tag), value); err != nil {
 		return err
@@ -204,3 +223,13 @@ func (varBind *VarBind) setApplication(tag ApplicationValueType, value interface
 
 	return nil
 }
+
+func (varBind *VarBind) setUnsigned(tag ApplicationValueType, value uint64) error {
+	if rawValue, err := packUnsigned(tag, value); err != nil {
+		return err
+	} else {
+		varBind.RawValue = rawValue
+	}
+
+	return nil
+}